@@ -1,23 +1,47 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"strings"
 
+	"github.com/fastbyt3/query-engine/datasources"
+	"github.com/fastbyt3/query-engine/datatypes"
 	"github.com/fastbyt3/query-engine/execution"
 	"github.com/fastbyt3/query-engine/logicalplans"
 )
 
 func main() {
 	ctx := execution.NewExecutionContext(1024)
-	df := ctx.CSV("test-data/employee.csv")
+	df, err := ctx.CSV("test-data/employee.csv", datasources.DefaultCSVOptions())
+	if err != nil {
+		log.Fatalf("failed to load csv: %s", err)
+	}
 	df = df.Filter(logicalplans.NewEqExpr(logicalplans.NewColumn("state"), logicalplans.NewLiteralString("CO")))
-	df.Project([]logicalplans.LogicalExpr{
+	// salary is inferred as an int64 column, it is cast to be multiplied by a float
+	df = df.Project([]logicalplans.LogicalExpr{
 		logicalplans.NewColumn("id"),
 		logicalplans.NewColumn("first_name"),
 		logicalplans.NewColumn("last_name"),
 		logicalplans.NewColumn("state"),
-		logicalplans.NewMult(logicalplans.NewColumn("salary"), logicalplans.NewLiteralFloat(2.0)),
+		logicalplans.NewMult(logicalplans.NewCast(logicalplans.NewColumn("salary"), datatypes.FloatType), logicalplans.NewLiteralFloat(2.0)),
 	})
 
 	fmt.Println(logicalplans.PprintPlan(df.LogicalPlan(), 2))
+
+	batches, err := ctx.Collect(context.Background(), df)
+	if err != nil {
+		log.Fatalf("failed to execute query: %s", err)
+	}
+
+	for _, rb := range batches {
+		for row := range rb.RowCount() {
+			values := make([]string, rb.ColumnCount())
+			for col := range rb.ColumnCount() {
+				values[col] = fmt.Sprint(rb.Field(col).GetValue(row))
+			}
+			fmt.Println(strings.Join(values, ", "))
+		}
+	}
 }
@@ -1,35 +1,44 @@
 package datasources
 
 import (
-	"bytes"
-	"encoding/csv"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"iter"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
 
 	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/arrow/memory"
 	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/metrics"
 )
 
+// CSVDatasource reads a CSV file in the dialect described by its CSVOptions. Unless the options
+// have a schema, the type of every column is inferred from the first rows of the file, see
+// inferSchema
 type CSVDatasource struct {
 	Filename  string
 	schema    datatypes.Schema
 	batchSize int
-
-	reader *csv.Reader
-
-	pjSchema  datatypes.Schema
-	pjIndices []int
-	builders  []datatypes.ArrowArrayBuilder
+	options   CSVOptions
 }
 
-func NewCSVDatasource(filename string, batchSize int) *CSVDatasource {
+// NewCSVDatasource validates the options and reads the schema of the file
+func NewCSVDatasource(filename string, batchSize int, options CSVOptions) (*CSVDatasource, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	if options.RejectsFile != "" && filepath.Clean(options.RejectsFile) == filepath.Clean(filename) {
+		return nil, fmt.Errorf("%w: rejects file is the file read %s", ErrInvalidCSVOptions, filename)
+	}
+
 	ds := CSVDatasource{
 		Filename: filename,
+		options:  options.withDefaults(),
 	}
 
 	if batchSize == 0 {
@@ -38,87 +47,312 @@ func NewCSVDatasource(filename string, batchSize int) *CSVDatasource {
 		ds.batchSize = batchSize
 	}
 
-	ds.inferSchema()
+	if err := ds.inferSchema(); err != nil {
+		return nil, err
+	}
+
+	return &ds, nil
+}
+
+// Scan opens the file on every call and lazily reads it in batches of `batchSize` rows,
+// the file is closed once all rows are read, the consumer stops iterating or ctx is done.
+//
+// Bad rows are handled by the BadRecordPolicy of the options and counted in the metrics of ctx,
+// see MetricCSVRejectedRows and MetricCSVNulledRows
+func (c *CSVDatasource) Scan(ctx context.Context, projection []string) iter.Seq2[datatypes.RecordBatch, error] {
+	return c.ScanWithFilters(ctx, projection, nil)
+}
 
-	return &ds
+// SupportsFilters fully applies filters on existing columns when the filter value has the type of the column
+func (c *CSVDatasource) SupportsFilters(filters []Filter) []FilterSupport {
+	support := make([]FilterSupport, len(filters))
+	for i, f := range filters {
+		indices := c.schema.FieldIndices(f.Column)
+		if len(indices) > 0 && f.Value != nil && datatypes.IsValueOfType(f.Value, c.schema.Field(indices[0]).Type) {
+			support[i] = FilterExact
+		}
+	}
+	return support
 }
 
-func (c *CSVDatasource) Scan(projection []string) iter.Seq[datatypes.RecordBatch] {
-	slog.Info(fmt.Sprintf("scan() projection=%v", projection))
-	c.inferProjection(projection)
-	return func(yield func(datatypes.RecordBatch) bool) {
-		rowsParsed := 0
+// ScanWithFilters is Scan which drops rows not matching the filters before they're added to the
+// record batch, so a full batch always contains `batchSize` matching rows
+func (c *CSVDatasource) ScanWithFilters(ctx context.Context, projection []string, filters []Filter) iter.Seq2[datatypes.RecordBatch, error] {
+	slog.Debug(fmt.Sprintf("scan() projection=%v filters=%v", projection, filters))
+	pjSchema, pjIndices := c.schema.Select(projection)
+
+	return func(yield func(datatypes.RecordBatch, error) bool) {
+		filterIndices := make([]int, len(filters))
+		for i, filter := range filters {
+			indices := c.schema.FieldIndices(filter.Column)
+			if len(indices) == 0 {
+				yield(datatypes.RecordBatch{}, fmt.Errorf("filter %s references unknown column of %s", filter, c.Filename))
+				return
+			}
+			filterIndices[i] = indices[0]
+		}
+
+		f, err := os.Open(c.Filename)
+		if err != nil {
+			yield(datatypes.RecordBatch{}, &ReadError{c.Filename, err})
+			return
+		}
+		defer f.Close()
+
+		reader := newCSVReader(f, c.options)
+		reader.numCells = c.schema.NumFields()
+		if !c.options.NoHeader {
+			if _, err := reader.Read(); err != nil {
+				if !errors.Is(err, io.EOF) {
+					yield(datatypes.RecordBatch{}, &ReadError{c.Filename, err})
+				}
+				return
+			}
+		}
+
+		bad := &badRecords{filename: c.Filename, options: c.options, metrics: metrics.FromContext(ctx)}
+		defer bad.Close()
+
+		builders := make([]datatypes.ArrowArrayBuilder, len(pjIndices))
+		for i, field := range pjSchema.Fields() {
+			builders[i] = datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), field.Type)
+		}
+
+		values := make([]any, c.schema.NumFields())
+		rowsInBatch := 0
 		for {
-			slog.Info("createBatch: rows parsed so far", "count", rowsParsed)
-			row, err := c.reader.Read()
+			select {
+			case <-ctx.Done():
+				yield(datatypes.RecordBatch{}, ctx.Err())
+				return
+			default:
+			}
 
-			if err != nil {
-				if errors.Is(err, io.EOF) {
-					if !yield(c.createBatch()) {
-						return
-					}
-				} else {
-					slog.Error("Unexpected error parsing file", "err", err)
-					panic("unexpected error parsing file")
+			ok, err := c.readRow(reader, bad, values)
+			if errors.Is(err, io.EOF) {
+				if rowsInBatch > 0 {
+					yield(c.createBatch(pjSchema, builders), nil)
 				}
+				return
+			}
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+			if !ok {
+				continue
+			}
+			matches, err := matchesFilters(values, filters, filterIndices)
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+			if !matches {
+				continue
 			}
 
-			slog.Info("Row content", "value", row)
-			for i := range c.pjIndices {
-				c.builders[i].Append(row[c.pjIndices[i]])
+			for i, idx := range pjIndices {
+				if err := builders[i].Append(values[idx]); err != nil {
+					yield(datatypes.RecordBatch{}, err)
+					return
+				}
 			}
-			rowsParsed += 1
+			rowsInBatch += 1
 
-			if rowsParsed == c.batchSize {
-				if !yield(c.createBatch()) {
+			if rowsInBatch == c.batchSize {
+				slog.Debug("createBatch", "rows", rowsInBatch)
+				if !yield(c.createBatch(pjSchema, builders), nil) {
 					return
 				}
+				rowsInBatch = 0
 			}
 		}
 	}
 }
 
-func (c *CSVDatasource) createBatch() datatypes.RecordBatch {
-	fields := make([]datatypes.ColumnArray, len(c.pjSchema.Fields()))
-	for _, i := range c.pjIndices {
-		fields[i] = c.builders[i].Build()
+// readRow reads the next row and parses its cells into values, a bad row is handled by the
+// BadRecordPolicy and ok is false when it is dropped. io.EOF is returned after the last row
+func (c *CSVDatasource) readRow(reader *csvReader, bad *badRecords, values []any) (ok bool, err error) {
+	row, malformed := reader.Read()
+	if errors.Is(malformed, io.EOF) {
+		return false, malformed
+	}
+	if malformed != nil && !errors.Is(malformed, ErrMalformedCSV) {
+		slog.Error("Unexpected error parsing file", "err", malformed)
+		return false, &ReadError{c.Filename, malformed}
+	}
+	slog.Debug("Row content", "value", row)
+
+	// only rows with the wrong number of cells can be kept
+	if malformed != nil && (row == nil || c.options.BadRecords != BadRecordSetNull) {
+		return false, bad.reject(reader, malformed)
 	}
 
-	return *datatypes.NewRecordBatch(c.pjSchema, fields)
+	cause, err := c.parseRow(reader, row, values)
+	if err != nil {
+		return false, bad.reject(reader, err)
+	}
+	if malformed != nil {
+		cause = malformed
+	}
+	if cause != nil {
+		return true, bad.setNull(reader, cause)
+	}
+	return true, nil
+}
+
+// parseRow parses every cell of row into values, not only the cells of the scanned columns, so
+// the rows dropped by a scan don't depend on its projection. Under BadRecordSetNull the missing
+// and unparseable cells of nullable columns are nulls, cause is then the first of their errors
+func (c *CSVDatasource) parseRow(reader *csvReader, row []string, values []any) (cause error, err error) {
+	for idx := range values {
+		value, err := c.parseCell(reader, row, idx)
+		if err != nil {
+			if c.options.BadRecords != BadRecordSetNull || !c.schema.Field(idx).Nullable {
+				return nil, err
+			}
+			if cause == nil {
+				cause = err
+			}
+		}
+		values[idx] = value
+	}
+	return cause, nil
+}
+
+func matchesFilters(values []any, filters []Filter, filterIndices []int) (bool, error) {
+	for i, filter := range filters {
+		matches, err := filter.Matches(values[filterIndices[i]])
+		if err != nil || !matches {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// parseCell converts the cell of column idx, in the row last read by reader, into a value of the
+// type of the column
+func (c *CSVDatasource) parseCell(reader *csvReader, row []string, idx int) (any, error) {
+	field := c.schema.Field(idx)
+	if idx >= len(row) {
+		return nil, fmt.Errorf("line %d, column %q: missing cell", reader.Line(), field.Name)
+	}
+
+	value, err := c.options.parseValue(row[idx], field.Type)
+	if err != nil {
+		return nil, fmt.Errorf("line %d, column %q: %w", reader.Line(), field.Name, err)
+	}
+	return value, nil
 }
 
-func (c *CSVDatasource) inferProjection(projection []string) {
-	c.pjSchema, c.pjIndices = c.schema.Select(projection)
+func (c *CSVDatasource) createBatch(schema datatypes.Schema, builders []datatypes.ArrowArrayBuilder) datatypes.RecordBatch {
+	fields := make([]datatypes.ColumnArray, len(builders))
+	for i := range builders {
+		fields[i] = builders[i].Build()
+	}
+
+	return *datatypes.NewRecordBatch(schema, fields)
 }
 
 func (c *CSVDatasource) Schema() datatypes.Schema {
 	return c.schema
 }
 
-func (c *CSVDatasource) inferSchema() {
-	f, err := os.ReadFile(c.Filename)
+// inferSchema reads the header row, which names the columns, and up to InferRows rows to infer
+// the type of every column: boolean, int64, float64, date, timestamp or string when the values
+// don't agree on anything narrower. The schema of the options is only checked against the
+// number of columns of the first row.
+//
+// A column is nullable when one of the sampled cells is null, every column is nullable when the
+// sample doesn't cover the whole file as the rows which weren't read may have nulls. Bad rows are
+// sampled like the scans read them: they fail, are skipped or have nulls in place of missing cells
+func (c *CSVDatasource) inferSchema() error {
+	f, err := os.Open(c.Filename)
 	if err != nil {
-		panic(fmt.Sprintf("failed to read file: %s. Error = %s", c.Filename, err.Error()))
+		return &ReadError{c.Filename, err}
 	}
+	defer f.Close()
 
-	reader := csv.NewReader(bytes.NewReader(f))
-	headerRow, err := reader.Read()
-	if err != nil {
-		panic(fmt.Sprintf("faild to read header row. Error = %s", err.Error()))
+	reader := newCSVReader(f, c.options)
+	firstRow, err := reader.Read()
+	switch {
+	case errors.Is(err, io.EOF) && c.options.Schema != nil:
+		c.schema = *c.options.Schema
+		return nil
+	case err != nil && !c.options.NoHeader:
+		return &ReadError{c.Filename, fmt.Errorf("failed to read header row: %w", err)}
+	case err != nil:
+		return &ReadError{c.Filename, fmt.Errorf("failed to read first row: %w", err)}
 	}
 
-	var headers []arrow.Field
-	for _, cell := range headerRow {
-		headers = append(headers, arrow.Field{Name: cell, Type: datatypes.StringType})
+	if c.options.Schema != nil {
+		if len(firstRow) != c.options.Schema.NumFields() {
+			return fmt.Errorf("%w: schema has %d fields but %s has %d columns", ErrInvalidCSVOptions, c.options.Schema.NumFields(), c.Filename, len(firstRow))
+		}
+		c.schema = *c.options.Schema
+		return nil
+	}
+
+	types := make([]csvType, len(firstRow))
+	nullable := make([]bool, len(firstRow))
+	sample := func(row []string) {
+		for i := range types {
+			// the missing cells of a row kept by BadRecordSetNull are nulls
+			if i >= len(row) || c.options.isNull(row[i]) {
+				nullable[i] = true
+				continue
+			}
+			types[i] = types[i].merge(c.options.inferCellType(row[i]))
+		}
 	}
 
-	c.reader = reader
-	c.schema = *datatypes.NewSchema(headers)
+	names := firstRow
+	sampled := 0
+	if c.options.NoHeader {
+		names = make([]string, len(firstRow))
+		for i := range names {
+			names[i] = fmt.Sprintf("column_%d", i+1)
+		}
+		sample(firstRow)
+		sampled++
+	}
 
-	c.builders = make([]datatypes.ArrowArrayBuilder, len(c.schema.Fields()))
-	for i, field := range c.schema.Fields() {
-		c.builders[i] = datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), field.Type)
+	for i, name := range names {
+		if slices.Contains(names[:i], name) {
+			return &ReadError{c.Filename, fmt.Errorf("%w: %q, a schema can name the columns instead", ErrDuplicateHeader, name)}
+		}
 	}
+
+	sampledAll := false
+	for ; sampled < c.options.InferRows; sampled++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			sampledAll = true
+			break
+		}
+		if err != nil {
+			if !errors.Is(err, ErrMalformedCSV) || c.options.BadRecords == BadRecordFail {
+				return &ReadError{c.Filename, err}
+			}
+			// the bad rows are counted and written to the rejects file by the scans
+			if row == nil || c.options.BadRecords != BadRecordSetNull {
+				continue
+			}
+		}
+		sample(row)
+	}
+	if !sampledAll {
+		_, err := reader.Read()
+		sampledAll = errors.Is(err, io.EOF)
+	}
+
+	fields := make([]arrow.Field, len(names))
+	for i, name := range names {
+		fields[i] = arrow.Field{Name: name, Type: types[i].arrowType(), Nullable: nullable[i] || !sampledAll}
+	}
+
+	c.schema = *datatypes.NewSchema(fields)
+	return nil
 }
 
-var _ DataSource = (*CSVDatasource)(nil)
+var _ FilterableDataSource = (*CSVDatasource)(nil)
@@ -1,25 +1,35 @@
 package datasources_test
 
 import (
+	"context"
+	"encoding/csv"
 	"fmt"
 	"iter"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/fastbyt3/query-engine/datasources"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/metrics"
 	"github.com/stretchr/testify/require"
 )
 
 const SAMPLE_CSV_FILE = "../test-data/sample.csv"
 
 func TestCSVDatasourceSchema(t *testing.T) {
-	ds := datasources.NewCSVDatasource(SAMPLE_CSV_FILE, 10)
-	dsIterator := ds.Scan([]string{})
+	ds, err := datasources.NewCSVDatasource(SAMPLE_CSV_FILE, 10, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+	dsIterator := ds.Scan(context.Background(), []string{})
 
-	next, stop := iter.Pull(dsIterator)
+	next, stop := iter.Pull2(dsIterator)
 	defer stop()
 
-	recordBatch, valid := next()
+	recordBatch, err, valid := next()
 	require.True(t, valid)
+	require.NoError(t, err)
 	require.Len(t, recordBatch.Fields, 13)
 
 	actualHeaders := []string{"id", "model", "mpg", "cyl", "disp", "hp", "drat", "wt", "qsec", "vs", "am", "gear", "carb"}
@@ -29,15 +39,17 @@ func TestCSVDatasourceSchema(t *testing.T) {
 }
 
 func TestCSVDatasourceScan(t *testing.T) {
-	firstRowData := []string{"1", "Mazda RX4", "21", "6", "160", "110", "3.9", "2.62", "16.46", "0", "1", "4", "4"}
+	firstRowData := []any{int64(1), "Mazda RX4", 21.0, int64(6), 160.0, int64(110), 3.9, 2.62, 16.46, int64(0), int64(1), int64(4), int64(4)}
 
 	// Read with batch size of 1024 => read all rows
-	ds := datasources.NewCSVDatasource(SAMPLE_CSV_FILE, 1024)
-	dsIterator := ds.Scan([]string{})
-	next, stop := iter.Pull(dsIterator)
+	ds, err := datasources.NewCSVDatasource(SAMPLE_CSV_FILE, 1024, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+	dsIterator := ds.Scan(context.Background(), []string{})
+	next, stop := iter.Pull2(dsIterator)
 	defer stop()
-	recordBatch, valid := next()
+	recordBatch, err, valid := next()
 	require.True(t, valid)
+	require.NoError(t, err)
 	firstCol := recordBatch.Field(0)
 	require.Equal(t, 32, firstCol.Size())
 	fmt.Println("firstCol :: ", firstCol)
@@ -49,29 +61,418 @@ func TestCSVDatasourceScan(t *testing.T) {
 	}
 
 	// Read with batch size of 10 => read only 10 rows
-	ds = datasources.NewCSVDatasource(SAMPLE_CSV_FILE, 10)
-	dsIterator = ds.Scan([]string{})
-	next, stop = iter.Pull(dsIterator)
+	ds, err = datasources.NewCSVDatasource(SAMPLE_CSV_FILE, 10, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+	dsIterator = ds.Scan(context.Background(), []string{})
+	next, stop = iter.Pull2(dsIterator)
 	defer stop()
-	recordBatch, valid = next()
+	recordBatch, err, valid = next()
 	require.True(t, valid)
+	require.NoError(t, err)
 	firstCol = recordBatch.Field(0)
 	require.Equal(t, 10, firstCol.Size())
 	// check if last row's id matches
-	require.Equal(t, "10", firstCol.GetValue(firstCol.Size()-1))
+	require.Equal(t, int64(10), firstCol.GetValue(firstCol.Size()-1))
 }
 
 func TestCSVDatasourceProjectionScan(t *testing.T) {
-	ds := datasources.NewCSVDatasource(SAMPLE_CSV_FILE, 10)
-	dsIterator := ds.Scan([]string{"id", "model"})
-	next, stop := iter.Pull(dsIterator)
+	ds, err := datasources.NewCSVDatasource(SAMPLE_CSV_FILE, 10, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+	dsIterator := ds.Scan(context.Background(), []string{"id", "model"})
+	next, stop := iter.Pull2(dsIterator)
 	defer stop()
-	rb, valid := next()
+	rb, err, valid := next()
 	require.True(t, valid)
+	require.NoError(t, err)
 	require.Len(t, rb.Fields, 2)
 
-	firstRowData := []string{"1", "Mazda RX4"}
+	firstRowData := []any{int64(1), "Mazda RX4"}
 	for i := range len(firstRowData) {
 		require.Equal(t, firstRowData[i], rb.Field(i).GetValue(0))
 	}
 }
+
+func TestCSVDatasourceMissingFile(t *testing.T) {
+	_, err := datasources.NewCSVDatasource("../test-data/missing.csv", 10, datasources.DefaultCSVOptions())
+	var readErr *datasources.ReadError
+	require.ErrorAs(t, err, &readErr)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCSVDatasourceScanCancelled(t *testing.T) {
+	ds, err := datasources.NewCSVDatasource(SAMPLE_CSV_FILE, 10, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batches := 0
+	for _, err := range ds.Scan(ctx, []string{}) {
+		if batches == 1 {
+			require.ErrorIs(t, err, context.Canceled)
+			break
+		}
+		require.NoError(t, err)
+		batches++
+		cancel()
+	}
+	require.Equal(t, 1, batches)
+}
+
+func TestCSVDatasourceScanWithFilters(t *testing.T) {
+	ds, err := datasources.NewCSVDatasource(SAMPLE_CSV_FILE, 10, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+
+	filters := []datasources.Filter{
+		{Column: "cyl", Op: "=", Value: int64(6)},
+		{Column: "mpg", Op: ">=", Value: 21.0},
+	}
+	require.Equal(t,
+		[]datasources.FilterSupport{datasources.FilterExact, datasources.FilterExact},
+		ds.SupportsFilters(filters),
+	)
+	require.Equal(t,
+		[]datasources.FilterSupport{datasources.FilterUnsupported, datasources.FilterUnsupported},
+		ds.SupportsFilters([]datasources.Filter{
+			{Column: "cyl", Op: "=", Value: "6"},
+			{Column: "unknown", Op: "=", Value: int64(6)},
+		}),
+	)
+
+	var models []any
+	for rb, err := range ds.ScanWithFilters(context.Background(), []string{"model"}, filters) {
+		require.NoError(t, err)
+		require.Equal(t, 1, rb.ColumnCount())
+		for i := range rb.RowCount() {
+			models = append(models, rb.Field(0).GetValue(i))
+		}
+	}
+	require.Equal(t, []any{"Mazda RX4", "Mazda RX4 Wag", "Hornet 4 Drive"}, models)
+}
+
+// scanRows returns the rows of all the columns of ds, up to the first error
+func scanRows(ctx context.Context, ds *datasources.CSVDatasource) ([][]any, error) {
+	var rows [][]any
+	for rb, err := range ds.Scan(ctx, []string{}) {
+		if err != nil {
+			return rows, err
+		}
+		for row := range rb.RowCount() {
+			values := make([]any, rb.ColumnCount())
+			for col := range rb.ColumnCount() {
+				values[col] = rb.Field(col).GetValue(row)
+			}
+			rows = append(rows, values)
+		}
+	}
+	return rows, nil
+}
+
+func writeCSV(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestCSVDatasourceInferSchema(t *testing.T) {
+	path := writeCSV(t, `b,i,f,d,ts,s,empty
+true,1,1.5,2024-01-31,2024-01-31 10:00:00,abc,
+FALSE,-2,3,2024-02-01,2024-02-01,12,
+,3,,2024-02-29,2024-02-01T12:30:00.5Z,NaN,
+`)
+	ds, err := datasources.NewCSVDatasource(path, 10, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+
+	expected := []arrow.Field{
+		{Name: "b", Type: datatypes.BooleanType, Nullable: true},
+		{Name: "i", Type: datatypes.Int64Type},
+		{Name: "f", Type: datatypes.DoubleType, Nullable: true},
+		{Name: "d", Type: datatypes.Date32Type},
+		{Name: "ts", Type: datatypes.TimestampType},
+		{Name: "s", Type: datatypes.StringType},
+		{Name: "empty", Type: datatypes.StringType, Nullable: true},
+	}
+	schema := ds.Schema()
+	require.Equal(t, expected, schema.Fields())
+
+	rows, err := scanRows(context.Background(), ds)
+	require.NoError(t, err)
+
+	date := func(s string) arrow.Date32 {
+		d, err := datatypes.ParseDate(s)
+		require.NoError(t, err)
+		return d
+	}
+	ts := func(s string) arrow.Timestamp {
+		v, err := datatypes.ParseTimestamp(s)
+		require.NoError(t, err)
+		return v
+	}
+	require.Equal(t, [][]any{
+		{true, int64(1), 1.5, date("2024-01-31"), ts("2024-01-31T10:00:00Z"), "abc", nil},
+		{false, int64(-2), 3.0, date("2024-02-01"), ts("2024-02-01T00:00:00Z"), "12", nil},
+		{nil, int64(3), nil, date("2024-02-29"), ts("2024-02-01T12:30:00.5Z"), "NaN", nil},
+	}, rows)
+}
+
+func TestCSVOptionsDialect(t *testing.T) {
+	path := writeCSV(t, `-- exported from the billing system
+'Acme; Inc.';  NA ;03/02/2024
+'O\'Brien';  12 ; \N
+
+-- a comment in the middle of the file
+'multi
+line' ;7;01/01/2024
+`)
+	ds, err := datasources.NewCSVDatasource(path, 10, datasources.CSVOptions{
+		NoHeader:    true,
+		Delimiter:   ';',
+		Quote:       '\'',
+		Escape:      '\\',
+		Comment:     "--",
+		NullValues:  []string{"", "NA", `\N`},
+		TrimSpace:   true,
+		DateFormats: []string{"02/01/2006"},
+	})
+	require.NoError(t, err)
+
+	schema := ds.Schema()
+	require.Equal(t, []arrow.Field{
+		{Name: "column_1", Type: datatypes.StringType},
+		{Name: "column_2", Type: datatypes.Int64Type, Nullable: true},
+		{Name: "column_3", Type: datatypes.Date32Type, Nullable: true},
+	}, schema.Fields())
+
+	rows, err := scanRows(context.Background(), ds)
+	require.NoError(t, err)
+	require.Equal(t, [][]any{
+		{"Acme; Inc.", nil, arrow.Date32FromTime(time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC))},
+		{"O'Brien", int64(12), nil},
+		{"multi\nline", int64(7), arrow.Date32FromTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))},
+	}, rows)
+}
+
+func TestCSVOptionsZeroValue(t *testing.T) {
+	expected, err := datasources.NewCSVDatasource(SAMPLE_CSV_FILE, 10, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+
+	// unset options, the header included, behave like the defaults
+	ds, err := datasources.NewCSVDatasource(SAMPLE_CSV_FILE, 10, datasources.CSVOptions{Delimiter: ','})
+	require.NoError(t, err)
+	require.Equal(t, expected.Schema(), ds.Schema())
+}
+
+func TestCSVEscapedLineBreak(t *testing.T) {
+	path := writeCSV(t, "note,n\n\"first\\\r\nsecond\",1\n\"a \\\\\",2\n")
+	options := datasources.DefaultCSVOptions()
+	options.Escape = '\\'
+	ds, err := datasources.NewCSVDatasource(path, 10, options)
+	require.NoError(t, err)
+
+	rows, err := scanRows(context.Background(), ds)
+	require.NoError(t, err)
+	require.Equal(t, [][]any{{"first\nsecond", int64(1)}, {`a \`, int64(2)}}, rows)
+}
+
+func TestCSVByteOrderMark(t *testing.T) {
+	path := writeCSV(t, "\ufeffname,age\nann,30\n")
+	ds, err := datasources.NewCSVDatasource(path, 10, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+
+	schema := ds.Schema()
+	require.Equal(t, []arrow.Field{
+		{Name: "name", Type: datatypes.StringType},
+		{Name: "age", Type: datatypes.Int64Type},
+	}, schema.Fields())
+}
+
+func TestCSVDuplicateHeader(t *testing.T) {
+	path := writeCSV(t, "a,b,a\n1,2,3\n")
+	_, err := datasources.NewCSVDatasource(path, 10, datasources.DefaultCSVOptions())
+	var readErr *datasources.ReadError
+	require.ErrorAs(t, err, &readErr)
+	require.ErrorIs(t, err, datasources.ErrDuplicateHeader)
+	require.ErrorContains(t, err, `"a"`)
+
+	// a schema names the columns instead of the header
+	options := datasources.DefaultCSVOptions()
+	options.Schema = datatypes.NewSchema([]arrow.Field{
+		{Name: "a", Type: datatypes.Int64Type},
+		{Name: "b", Type: datatypes.Int64Type},
+		{Name: "c", Type: datatypes.Int64Type},
+	})
+	ds, err := datasources.NewCSVDatasource(path, 10, options)
+	require.NoError(t, err)
+	rows, err := scanRows(context.Background(), ds)
+	require.NoError(t, err)
+	require.Equal(t, [][]any{{int64(1), int64(2), int64(3)}}, rows)
+
+	// without a header the first row is data, its repeated values don't name columns
+	path = writeCSV(t, "1,1\n2,3\n")
+	options = datasources.DefaultCSVOptions()
+	options.NoHeader = true
+	ds, err = datasources.NewCSVDatasource(path, 10, options)
+	require.NoError(t, err)
+	rows, err = scanRows(context.Background(), ds)
+	require.NoError(t, err)
+	require.Equal(t, [][]any{{int64(1), int64(1)}, {int64(2), int64(3)}}, rows)
+}
+
+func TestCSVOptionsSchema(t *testing.T) {
+	path := writeCSV(t, "name,age\nann,30\nbob,x\n")
+	schema := datatypes.NewSchema([]arrow.Field{
+		{Name: "first_name", Type: datatypes.StringType},
+		{Name: "age", Type: datatypes.Int32Type, Nullable: true},
+	})
+
+	options := datasources.DefaultCSVOptions()
+	options.Schema = schema
+	ds, err := datasources.NewCSVDatasource(path, 1, options)
+	require.NoError(t, err)
+	require.Equal(t, *schema, ds.Schema())
+
+	// the schema isn't checked against the cells until they're read
+	rows, err := scanRows(context.Background(), ds)
+	require.ErrorIs(t, err, datasources.ErrInvalidValue)
+	require.ErrorContains(t, err, `line 3, column "age"`)
+	require.Equal(t, [][]any{{"ann", int32(30)}}, rows)
+
+	options.Schema = datatypes.NewSchema([]arrow.Field{{Name: "name", Type: datatypes.StringType}})
+	_, err = datasources.NewCSVDatasource(path, 10, options)
+	require.ErrorIs(t, err, datasources.ErrInvalidCSVOptions)
+	require.ErrorContains(t, err, "schema has 1 fields but")
+}
+
+func TestCSVOptionsValidate(t *testing.T) {
+	require.NoError(t, datasources.DefaultCSVOptions().Validate())
+	require.NoError(t, datasources.CSVOptions{}.Validate())
+
+	tests := []struct {
+		name     string
+		options  datasources.CSVOptions
+		expected string
+	}{
+		{"line break delimiter", datasources.CSVOptions{Delimiter: '\n'}, `delimiter can't be '\n'`},
+		{"delimiter is the quote", datasources.CSVOptions{Delimiter: '"'}, `delimiter and quote are both '"'`},
+		{"delimiter is the escape", datasources.CSVOptions{Delimiter: '\\', Escape: '\\'}, `delimiter and escape are both '\\'`},
+		{"comment starts with quote", datasources.CSVOptions{Comment: `"#`}, `comment prefix "\"#" starts with the quote`},
+		{"unknown bad record policy", datasources.CSVOptions{BadRecords: 7}, "unknown bad record policy BadRecordPolicy(7)"},
+		{"negative infer rows", datasources.CSVOptions{InferRows: -1}, "is negative: -1"},
+		{"empty schema", datasources.CSVOptions{Schema: datatypes.NewSchema(nil)}, "schema has no field"},
+		{
+			"unsupported schema type",
+			datasources.CSVOptions{Schema: datatypes.NewSchema([]arrow.Field{{Name: "at", Type: &arrow.TimestampType{Unit: arrow.Second}}})},
+			`column "at" has type timestamp[s] which can't be read from CSV`,
+		},
+		{"untrimmed null marker", datasources.CSVOptions{TrimSpace: true, NullValues: []string{" NA"}}, `null marker " NA" can't match trimmed cells`},
+		{"date format without day", datasources.CSVOptions{DateFormats: []string{"2006-01"}}, `date format "2006-01" doesn't contain a year, a month and a day`},
+		{"not a layout", datasources.CSVOptions{DateFormats: []string{"YYYY-MM-DD"}}, `date format "YYYY-MM-DD" doesn't contain a year, a month and a day`},
+		{"timestamp format without seconds", datasources.CSVOptions{TimestampFormats: []string{"2006-01-02 15h"}}, "doesn't contain hours, minutes and seconds"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.options.Validate()
+			require.ErrorIs(t, err, datasources.ErrInvalidCSVOptions)
+			require.ErrorContains(t, err, test.expected)
+
+			_, err = datasources.NewCSVDatasource(SAMPLE_CSV_FILE, 10, test.options)
+			require.ErrorIs(t, err, datasources.ErrInvalidCSVOptions)
+		})
+	}
+}
+
+func TestCSVMalformed(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"missing cell", "a,b\n1,2\n3\n", "line 3: expected 2 cells, found 1"},
+		{"unclosed quote", "a,b\n1,2\n1,\"2\n3,4\n", "line 3: quoted cell is never closed"},
+		{"bare quote", "a,b\n1,2\n1,2\"\n", `line 3: unexpected quote in unquoted cell 2`},
+		{"text after quote", "a,b\n1,2\n\"1\"x,2\n", `line 3: unexpected 'x' after the closing quote of cell 1`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := datasources.DefaultCSVOptions()
+			// the malformed rows aren't sampled so the errors come from the scan
+			options.InferRows = 1
+			ds, err := datasources.NewCSVDatasource(writeCSV(t, test.content), 10, options)
+			require.NoError(t, err)
+
+			_, err = scanRows(context.Background(), ds)
+			var readErr *datasources.ReadError
+			require.ErrorAs(t, err, &readErr)
+			require.ErrorIs(t, err, datasources.ErrMalformedCSV)
+			require.ErrorContains(t, err, test.expected)
+		})
+	}
+}
+
+func TestCSVBadRecords(t *testing.T) {
+	path := writeCSV(t, `name,age
+ann,30
+bob,x
+cy
+dan,40,extra
+"eve,50
+`)
+
+	tests := []struct {
+		policy   datasources.BadRecordPolicy
+		expected [][]any
+		rejected int64
+		nulled   int64
+	}{
+		{datasources.BadRecordSkip, [][]any{{"ann", int64(30)}}, 4, 0},
+		{datasources.BadRecordSetNull, [][]any{{"ann", int64(30)}, {"bob", nil}, {"cy", nil}, {"dan", int64(40)}}, 1, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.policy.String(), func(t *testing.T) {
+			options := datasources.DefaultCSVOptions()
+			options.InferRows = 1
+			options.BadRecords = test.policy
+			options.RejectsFile = filepath.Join(t.TempDir(), "rejects.csv")
+			ds, err := datasources.NewCSVDatasource(path, 10, options)
+			require.NoError(t, err)
+
+			m := metrics.New()
+			rows, err := scanRows(metrics.NewContext(context.Background(), m), ds)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, rows)
+			require.Equal(t, test.rejected, m.Get(datasources.MetricCSVRejectedRows))
+			require.Equal(t, test.nulled, m.Get(datasources.MetricCSVNulledRows))
+
+			f, err := os.Open(options.RejectsFile)
+			require.NoError(t, err)
+			defer f.Close()
+			rejects, err := csv.NewReader(f).ReadAll()
+			require.NoError(t, err)
+			require.Len(t, rejects, 5)
+			require.Equal(t, []string{"file", "line", "error", "row"}, rejects[0])
+			require.Equal(t, []string{path, "3", `line 3, column "age": invalid value: "x" is not a int64`, "bob,x"}, rejects[1])
+			require.Equal(t, []string{path, "6", "malformed CSV: line 6: quoted cell is never closed", `"eve,50`}, rejects[4])
+		})
+	}
+
+	t.Run("fail", func(t *testing.T) {
+		options := datasources.DefaultCSVOptions()
+		options.InferRows = 1
+		ds, err := datasources.NewCSVDatasource(path, 10, options)
+		require.NoError(t, err)
+
+		m := metrics.New()
+		_, err = scanRows(metrics.NewContext(context.Background(), m), ds)
+		require.ErrorIs(t, err, datasources.ErrInvalidValue)
+		require.Equal(t, int64(1), m.Get(datasources.MetricCSVRejectedRows))
+	})
+
+	t.Run("rejects file is the input", func(t *testing.T) {
+		options := datasources.DefaultCSVOptions()
+		options.RejectsFile = path
+		_, err := datasources.NewCSVDatasource(path, 10, options)
+		require.ErrorIs(t, err, datasources.ErrInvalidCSVOptions)
+	})
+}
@@ -0,0 +1,228 @@
+package datasources
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/fastbyt3/query-engine/datatypes"
+)
+
+// ErrInvalidCSVOptions is returned when the options of a CSV file contradict each other
+var ErrInvalidCSVOptions = errors.New("invalid CSV options")
+
+// BadRecordPolicy is what a CSV scan does with a bad row: a row which can't be split into cells,
+// has the wrong number of cells or has a cell which can't be parsed into the type of its column
+type BadRecordPolicy int
+
+const (
+	// BadRecordFail stops the scan with an error
+	BadRecordFail BadRecordPolicy = iota
+	// BadRecordSkip drops the row
+	BadRecordSkip
+	// BadRecordSetNull keeps the row with nulls in place of its missing and unparseable cells,
+	// extra cells are dropped. Rows which can't be split into cells, or would need a null in a
+	// column which isn't nullable, are dropped
+	BadRecordSetNull
+)
+
+func (p BadRecordPolicy) String() string {
+	switch p {
+	case BadRecordFail:
+		return "fail"
+	case BadRecordSkip:
+		return "skip"
+	case BadRecordSetNull:
+		return "set null"
+	default:
+		return fmt.Sprintf("BadRecordPolicy(%d)", int(p))
+	}
+}
+
+// CSVOptions describes the dialect of a CSV file and how its cells become values.
+//
+// Zero values are replaced by their default, so only the options which differ from
+// DefaultCSVOptions need to be set
+type CSVOptions struct {
+	// Delimiter separates the cells of a row, ',' by default
+	Delimiter rune
+	// Quote surrounds cells containing delimiters, quotes or line breaks, '"' by default
+	Quote rune
+	// Escape makes the following character of a quoted cell literal, including a line break.
+	// Without it, which is the default, or when it is the quote, a quote inside a quoted cell is
+	// written twice
+	Escape rune
+	// Comment is a prefix marking the lines to ignore, no line is ignored when it is empty
+	Comment string
+
+	// NoHeader tells the first row is a row of data rather than the names of the columns.
+	// Files without a header name their columns column_1, column_2...
+	NoHeader bool
+	// Schema is the schema of the file, the column names of the header are ignored and no type
+	// is inferred when it is set
+	Schema *datatypes.Schema
+	// InferRows is the number of rows sampled to infer the schema, DefaultSchemaInferenceRows by default
+	InferRows int
+
+	// NullValues are the cells read as null, whatever the type of their column. Only empty
+	// cells are nulls by default
+	NullValues []string
+	// TrimSpace removes the leading and trailing white space of every cell
+	TrimSpace bool
+	// DateFormats are the time.Parse layouts of dates, tried in order.
+	// datatypes.DefaultDateLayouts by default
+	DateFormats []string
+	// TimestampFormats are the time.Parse layouts of timestamps, tried in order.
+	// datatypes.DefaultTimestampLayouts by default
+	TimestampFormats []string
+
+	// BadRecords is what scans do with bad rows, BadRecordFail by default
+	BadRecords BadRecordPolicy
+	// RejectsFile is a CSV file scans append the bad rows to, with the file and line they come
+	// from and why they're bad. Nothing is written when it is empty
+	RejectsFile string
+}
+
+// DefaultCSVOptions reads comma separated files with a header row
+func DefaultCSVOptions() CSVOptions {
+	return CSVOptions{}.withDefaults()
+}
+
+// withDefaults replaces the zero values by their default
+func (o CSVOptions) withDefaults() CSVOptions {
+	if o.Delimiter == 0 {
+		o.Delimiter = ','
+	}
+	if o.Quote == 0 {
+		o.Quote = '"'
+	}
+	if o.InferRows == 0 {
+		o.InferRows = DefaultSchemaInferenceRows
+	}
+	if o.NullValues == nil {
+		o.NullValues = []string{""}
+	}
+	if o.DateFormats == nil {
+		o.DateFormats = slices.Clone(datatypes.DefaultDateLayouts)
+	}
+	if o.TimestampFormats == nil {
+		o.TimestampFormats = slices.Clone(datatypes.DefaultTimestampLayouts)
+	}
+	return o
+}
+
+// Validate reports the first option which can't be used to read a file, the defaults are
+// applied before validating
+func (o CSVOptions) Validate() error {
+	o = o.withDefaults()
+
+	characters := []struct {
+		name string
+		r    rune
+	}{{"delimiter", o.Delimiter}, {"quote", o.Quote}, {"escape", o.Escape}}
+	for _, c := range characters {
+		if c.r == '\r' || c.r == '\n' || c.r == utf8.RuneError {
+			return fmt.Errorf("%w: %s can't be %q", ErrInvalidCSVOptions, c.name, c.r)
+		}
+	}
+	if o.Delimiter == o.Quote {
+		return fmt.Errorf("%w: delimiter and quote are both %q", ErrInvalidCSVOptions, o.Delimiter)
+	}
+	if o.Delimiter == o.Escape {
+		return fmt.Errorf("%w: delimiter and escape are both %q", ErrInvalidCSVOptions, o.Delimiter)
+	}
+
+	if strings.ContainsAny(o.Comment, "\r\n") {
+		return fmt.Errorf("%w: comment prefix %q contains a line break", ErrInvalidCSVOptions, o.Comment)
+	}
+	if o.Comment != "" && strings.HasPrefix(o.Comment, string(o.Quote)) {
+		return fmt.Errorf("%w: comment prefix %q starts with the quote", ErrInvalidCSVOptions, o.Comment)
+	}
+
+	if o.BadRecords < BadRecordFail || o.BadRecords > BadRecordSetNull {
+		return fmt.Errorf("%w: unknown bad record policy %s", ErrInvalidCSVOptions, o.BadRecords)
+	}
+
+	if o.InferRows < 0 {
+		return fmt.Errorf("%w: number of rows to infer the schema from is negative: %d", ErrInvalidCSVOptions, o.InferRows)
+	}
+
+	if o.Schema != nil {
+		if o.Schema.NumFields() == 0 {
+			return fmt.Errorf("%w: schema has no field", ErrInvalidCSVOptions)
+		}
+		for _, field := range o.Schema.Fields() {
+			if !isCSVType(field.Type) {
+				return fmt.Errorf("%w: column %q has type %s which can't be read from CSV", ErrInvalidCSVOptions, field.Name, field.Type)
+			}
+		}
+	}
+
+	if o.TrimSpace {
+		for _, marker := range o.NullValues {
+			if marker != strings.TrimSpace(marker) {
+				return fmt.Errorf("%w: null marker %q can't match trimmed cells", ErrInvalidCSVOptions, marker)
+			}
+		}
+	}
+
+	for _, layout := range o.DateFormats {
+		if err := validateLayout(layout, false); err != nil {
+			return fmt.Errorf("%w: date format %q %w", ErrInvalidCSVOptions, layout, err)
+		}
+	}
+	for _, layout := range o.TimestampFormats {
+		if err := validateLayout(layout, true); err != nil {
+			return fmt.Errorf("%w: timestamp format %q %w", ErrInvalidCSVOptions, layout, err)
+		}
+	}
+	return nil
+}
+
+// validateLayout checks a time.Parse layout reads back the date (and the time, when withTime is
+// set) it formats, which catches layouts missing a component or not using the reference time
+func validateLayout(layout string, withTime bool) error {
+	if layout == "" {
+		return errors.New("is empty")
+	}
+
+	reference := time.Date(2001, time.February, 3, 4, 5, 6, 0, time.UTC)
+	parsed, err := time.Parse(layout, reference.Format(layout))
+	if err != nil {
+		return errors.New("can't parse the values it formats")
+	}
+
+	y, m, d := parsed.Date()
+	if y != reference.Year() || m != reference.Month() || d != reference.Day() {
+		return errors.New("doesn't contain a year, a month and a day")
+	}
+	// a timestamp layout may leave out the whole time, the timestamps are then at midnight
+	if withTime && !parsed.Equal(reference) && parsed.Hour()+parsed.Minute()+parsed.Second() != 0 {
+		return errors.New("doesn't contain hours, minutes and seconds")
+	}
+	return nil
+}
+
+// isCSVType reports whether parseValue can read values of dt, timestamps are only read in
+// microseconds
+func isCSVType(dt arrow.DataType) bool {
+	if ts, ok := dt.(*arrow.TimestampType); ok {
+		return ts.Unit == arrow.Microsecond
+	}
+	return slices.Contains([]arrow.Type{
+		arrow.STRING, arrow.BOOL,
+		arrow.INT8, arrow.INT16, arrow.INT32, arrow.INT64,
+		arrow.UINT8, arrow.UINT16, arrow.UINT32, arrow.UINT64,
+		arrow.FLOAT32, arrow.FLOAT64,
+		arrow.DATE32,
+	}, dt.ID())
+}
+
+// isNull reports whether a (trimmed) cell is one of the null markers
+func (o CSVOptions) isNull(cell string) bool {
+	return slices.Contains(o.NullValues, cell)
+}
@@ -0,0 +1,158 @@
+package datasources
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// ErrMalformedCSV is returned when a row of a CSV file can't be split into cells
+var ErrMalformedCSV = errors.New("malformed CSV")
+
+// csvReader splits a CSV file into rows of cells following the dialect of CSVOptions.
+//
+// encoding/csv doesn't support other quote characters, escape characters or comment prefixes
+// longer than a character. Like encoding/csv, empty lines are skipped, line breaks of quoted
+// cells are read as '\n' and every row must have as many cells as the first one
+type csvReader struct {
+	r       *bufio.Reader
+	options CSVOptions
+	escape  rune
+
+	// number of lines read so far, and line the last row read starts at
+	line, rowLine int
+	// number of cells of every row, set by the first row when zero
+	numCells int
+	// lines of the last row read
+	raw []string
+}
+
+// newCSVReader reads r with options which already have their defaults applied
+func newCSVReader(r io.Reader, options CSVOptions) *csvReader {
+	escape := options.Escape
+	if escape == options.Quote {
+		escape = 0
+	}
+	return &csvReader{r: bufio.NewReader(r), options: options, escape: escape}
+}
+
+// readLine returns the next line without its line break, io.EOF once the whole input is read. The
+// byte order mark starting the input isn't part of the first line
+func (c *csvReader) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", err
+	}
+	c.line++
+	if c.line == 1 {
+		line = strings.TrimPrefix(line, "\ufeff")
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+// Read returns the cells of the next row, io.EOF once all the rows are read. A row which doesn't
+// have the expected number of cells is returned along with an ErrMalformedCSV error, the next
+// row can be read after any ErrMalformedCSV error
+func (c *csvReader) Read() ([]string, error) {
+	line, err := c.readLine()
+	for err == nil && (line == "" || (c.options.Comment != "" && strings.HasPrefix(line, c.options.Comment))) {
+		line, err = c.readLine()
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.rowLine = c.line
+	c.raw = append(c.raw[:0], line)
+
+	var cells []string
+	var cell strings.Builder
+	// quoted tells the current cell started with a quote, inQuotes that its closing quote
+	// wasn't read yet
+	quoted, inQuotes := false, false
+	runes := []rune(line)
+	for i := 0; ; i++ {
+		if i == len(runes) {
+			if !inQuotes {
+				cells = append(cells, c.cellValue(cell.String()))
+				break
+			}
+
+			// the quoted cell continues on the next line
+			next, err := c.readLine()
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("%w: line %d: quoted cell is never closed", ErrMalformedCSV, c.rowLine)
+			}
+			if err != nil {
+				return nil, err
+			}
+			cell.WriteByte('\n')
+			c.raw = append(c.raw, next)
+			runes, i = []rune(next), -1
+			continue
+		}
+
+		r := runes[i]
+		switch {
+		case inQuotes && c.escape != 0 && r == c.escape:
+			// an escape ending the line escapes the line break, which is added to the cell when
+			// the next line is read
+			if i+1 < len(runes) {
+				cell.WriteRune(runes[i+1])
+				i++
+			}
+		case inQuotes && r == c.options.Quote:
+			if i+1 < len(runes) && runes[i+1] == c.options.Quote {
+				cell.WriteRune(r)
+				i++
+			} else {
+				inQuotes = false
+			}
+		case inQuotes:
+			cell.WriteRune(r)
+		case r == c.options.Delimiter:
+			cells = append(cells, c.cellValue(cell.String()))
+			cell.Reset()
+			quoted = false
+		case r == c.options.Quote && !quoted && strings.TrimSpace(cell.String()) == "":
+			// white space before the opening quote is dropped
+			cell.Reset()
+			quoted, inQuotes = true, true
+		case quoted && !unicode.IsSpace(r):
+			return nil, fmt.Errorf("%w: line %d: unexpected %q after the closing quote of cell %d", ErrMalformedCSV, c.line, r, len(cells)+1)
+		case !quoted && r == c.options.Quote:
+			return nil, fmt.Errorf("%w: line %d: unexpected quote in unquoted cell %d", ErrMalformedCSV, c.line, len(cells)+1)
+		case !quoted:
+			cell.WriteRune(r)
+		}
+		// only white space is left: it follows a closing quote and is dropped
+	}
+
+	if c.numCells == 0 {
+		c.numCells = len(cells)
+	}
+	if len(cells) != c.numCells {
+		return cells, fmt.Errorf("%w: line %d: expected %d cells, found %d", ErrMalformedCSV, c.rowLine, c.numCells, len(cells))
+	}
+	return cells, nil
+}
+
+func (c *csvReader) cellValue(cell string) string {
+	if c.options.TrimSpace {
+		return strings.TrimSpace(cell)
+	}
+	return cell
+}
+
+// Line returns the line the last row read starts at
+func (c *csvReader) Line() int {
+	return c.rowLine
+}
+
+// Raw returns the text of the last row read, without its final line break
+func (c *csvReader) Raw() string {
+	return strings.Join(c.raw, "\n")
+}
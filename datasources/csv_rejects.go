@@ -0,0 +1,87 @@
+package datasources
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/fastbyt3/query-engine/metrics"
+)
+
+const (
+	// MetricCSVRejectedRows counts the bad rows CSV scans dropped or failed on
+	MetricCSVRejectedRows = "csv_rejected_rows"
+	// MetricCSVNulledRows counts the bad rows CSV scans kept with nulls, see BadRecordSetNull
+	MetricCSVNulledRows = "csv_nulled_rows"
+)
+
+// badRecords applies the BadRecordPolicy of a scan to the bad rows it reads, and writes them to
+// the rejects file
+type badRecords struct {
+	filename string
+	options  CSVOptions
+	metrics  *metrics.Metrics
+
+	// the rejects file is opened by the first bad row
+	rejects *os.File
+	writer  *csv.Writer
+}
+
+// reject drops the last row read by reader, the returned error stops the scan
+func (b *badRecords) reject(reader *csvReader, cause error) error {
+	b.metrics.Add(MetricCSVRejectedRows, 1)
+	if err := b.write(reader, cause); err != nil {
+		return err
+	}
+
+	if b.options.BadRecords == BadRecordFail {
+		return &ReadError{b.filename, cause}
+	}
+	slog.Debug("Dropped bad CSV row", "file", b.filename, "err", cause)
+	return nil
+}
+
+// setNull records the last row read by reader is kept with nulls in place of its bad cells
+func (b *badRecords) setNull(reader *csvReader, cause error) error {
+	b.metrics.Add(MetricCSVNulledRows, 1)
+	return b.write(reader, cause)
+}
+
+// write appends the last row read by reader to the rejects file, which starts with a header row
+func (b *badRecords) write(reader *csvReader, cause error) error {
+	if b.options.RejectsFile == "" {
+		return nil
+	}
+
+	if b.writer == nil {
+		f, err := os.OpenFile(b.options.RejectsFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open rejects file of %s: %w", b.filename, err)
+		}
+		b.rejects, b.writer = f, csv.NewWriter(f)
+
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to open rejects file of %s: %w", b.filename, err)
+		}
+		if info.Size() == 0 {
+			_ = b.writer.Write([]string{"file", "line", "error", "row"})
+		}
+	}
+
+	_ = b.writer.Write([]string{b.filename, strconv.Itoa(reader.Line()), cause.Error(), reader.Raw()})
+	b.writer.Flush()
+	if err := b.writer.Error(); err != nil {
+		return fmt.Errorf("failed to write rejects file of %s: %w", b.filename, err)
+	}
+	return nil
+}
+
+func (b *badRecords) Close() error {
+	if b.rejects == nil {
+		return nil
+	}
+	return b.rejects.Close()
+}
@@ -0,0 +1,179 @@
+package datasources
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/fastbyt3/query-engine/datatypes"
+)
+
+// DefaultSchemaInferenceRows is the number of rows read to infer the type of the columns of a CSV file
+const DefaultSchemaInferenceRows = 1000
+
+var (
+	// ErrInvalidValue is returned when a CSV cell can't be parsed into the type of its column
+	ErrInvalidValue = errors.New("invalid value")
+
+	// ErrDuplicateHeader is returned when two columns of the header row have the same name
+	ErrDuplicateHeader = errors.New("duplicate column name in header")
+)
+
+// csvType is what the sampled cells of a column tell about its type, types later in the list
+// accept the values of the earlier ones they're merged with
+type csvType int
+
+const (
+	// only empty cells were seen
+	csvNull csvType = iota
+	csvBoolean
+	csvInt64
+	csvFloat64
+	csvDate
+	csvTimestamp
+	csvString
+)
+
+// inferCellType returns the narrowest type which can hold a cell which isn't null
+func (o CSVOptions) inferCellType(cell string) csvType {
+	switch {
+	case strings.EqualFold(cell, "true") || strings.EqualFold(cell, "false"):
+		return csvBoolean
+	case isInt64(cell):
+		return csvInt64
+	case isFloat64(cell):
+		return csvFloat64
+	}
+
+	if _, err := datatypes.ParseDateLayouts(cell, o.DateFormats); err == nil {
+		return csvDate
+	}
+	if _, err := datatypes.ParseTimestampLayouts(cell, o.TimestampFormats); err == nil {
+		return csvTimestamp
+	}
+	return csvString
+}
+
+func isInt64(cell string) bool {
+	_, err := strconv.ParseInt(cell, 10, 64)
+	return err == nil
+}
+
+// isFloat64 doesn't accept the spelled out "NaN" and "Inf" values ParseFloat supports, so a
+// column of names isn't mistaken for numbers
+func isFloat64(cell string) bool {
+	digits := strings.TrimLeft(cell, "+-")
+	if digits == "" || (digits[0] != '.' && (digits[0] < '0' || digits[0] > '9')) {
+		return false
+	}
+	_, err := strconv.ParseFloat(cell, 64)
+	return err == nil
+}
+
+// merge returns the type of a column holding values of both types
+func (t csvType) merge(other csvType) csvType {
+	switch {
+	case t == other || other == csvNull:
+		return t
+	case t == csvNull:
+		return other
+	case min(t, other) == csvInt64 && max(t, other) == csvFloat64:
+		return csvFloat64
+	case min(t, other) == csvDate && max(t, other) == csvTimestamp:
+		return csvTimestamp
+	default:
+		return csvString
+	}
+}
+
+// arrowType is the type of the column, a column without any value is a string column
+func (t csvType) arrowType() arrow.DataType {
+	switch t {
+	case csvBoolean:
+		return datatypes.BooleanType
+	case csvInt64:
+		return datatypes.Int64Type
+	case csvFloat64:
+		return datatypes.DoubleType
+	case csvDate:
+		return datatypes.Date32Type
+	case csvTimestamp:
+		return datatypes.TimestampType
+	default:
+		return datatypes.StringType
+	}
+}
+
+// parseValue converts a cell into a value of dt, null markers are null whatever the type
+func (o CSVOptions) parseValue(cell string, dt arrow.DataType) (any, error) {
+	if o.isNull(cell) {
+		return nil, nil
+	}
+
+	switch dt.ID() {
+	case arrow.STRING:
+		return cell, nil
+	case arrow.BOOL:
+		switch {
+		case strings.EqualFold(cell, "true"):
+			return true, nil
+		case strings.EqualFold(cell, "false"):
+			return false, nil
+		}
+	case arrow.INT8:
+		if n, err := strconv.ParseInt(cell, 10, 8); err == nil {
+			return int8(n), nil
+		}
+	case arrow.INT16:
+		if n, err := strconv.ParseInt(cell, 10, 16); err == nil {
+			return int16(n), nil
+		}
+	case arrow.INT32:
+		if n, err := strconv.ParseInt(cell, 10, 32); err == nil {
+			return int32(n), nil
+		}
+	case arrow.INT64:
+		if n, err := strconv.ParseInt(cell, 10, 64); err == nil {
+			return n, nil
+		}
+	case arrow.UINT8:
+		if n, err := strconv.ParseUint(cell, 10, 8); err == nil {
+			return uint8(n), nil
+		}
+	case arrow.UINT16:
+		if n, err := strconv.ParseUint(cell, 10, 16); err == nil {
+			return uint16(n), nil
+		}
+	case arrow.UINT32:
+		if n, err := strconv.ParseUint(cell, 10, 32); err == nil {
+			return uint32(n), nil
+		}
+	case arrow.UINT64:
+		if n, err := strconv.ParseUint(cell, 10, 64); err == nil {
+			return n, nil
+		}
+	case arrow.FLOAT32:
+		if isFloat64(cell) {
+			if f, err := strconv.ParseFloat(cell, 32); err == nil {
+				return float32(f), nil
+			}
+		}
+	case arrow.FLOAT64:
+		if isFloat64(cell) {
+			return strconv.ParseFloat(cell, 64)
+		}
+	case arrow.DATE32:
+		if d, err := datatypes.ParseDateLayouts(cell, o.DateFormats); err == nil {
+			return d, nil
+		}
+	case arrow.TIMESTAMP:
+		if ts, err := datatypes.ParseTimestampLayouts(cell, o.TimestampFormats); err == nil {
+			return ts, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported CSV column type %s", dt)
+	}
+	return nil, fmt.Errorf("%w: %q is not a %s", ErrInvalidValue, cell, dt)
+}
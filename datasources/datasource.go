@@ -1,6 +1,8 @@
 package datasources
 
 import (
+	"context"
+	"fmt"
 	"iter"
 
 	"github.com/fastbyt3/query-engine/datatypes"
@@ -8,7 +10,24 @@ import (
 
 type DataSource interface {
 	Schema() datatypes.Schema
-	Scan(projection []string) iter.Seq[datatypes.RecordBatch]
+
+	// Scan lazily reads the source, yielding a non nil error stops the scan.
+	// Cancelling ctx stops the scan and yields the context's error
+	Scan(ctx context.Context, projection []string) iter.Seq2[datatypes.RecordBatch, error]
+}
+
+// ReadError is returned when the underlying data of a source can't be read or parsed
+type ReadError struct {
+	Source string
+	Err    error
+}
+
+func (e *ReadError) Error() string {
+	return fmt.Sprintf("failed to read %s: %s", e.Source, e.Err)
+}
+
+func (e *ReadError) Unwrap() error {
+	return e.Err
 }
 
 /*
@@ -0,0 +1,78 @@
+package datasources
+
+import (
+	"context"
+	"fmt"
+	"iter"
+
+	"github.com/fastbyt3/query-engine/datatypes"
+)
+
+// Filter is a simple `column <op> literal` predicate which a datasource can apply while reading
+type Filter struct {
+	Column string
+	// one of =, !=, <, <=, >, >=
+	Op    string
+	Value any
+}
+
+func (f Filter) String() string {
+	if s, ok := f.Value.(string); ok {
+		return fmt.Sprintf("%s %s '%s'", f.Column, f.Op, s)
+	}
+	return fmt.Sprintf("%s %s %v", f.Column, f.Op, f.Value)
+}
+
+// Matches evaluates the filter against a column value, a null value never matches
+func (f Filter) Matches(value any) (bool, error) {
+	if value == nil {
+		return false, nil
+	}
+
+	c, err := datatypes.CompareValues(value, f.Value)
+	if err != nil {
+		return false, err
+	}
+
+	switch f.Op {
+	case "=":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	default:
+		return false, fmt.Errorf("unsupported filter operator %q", f.Op)
+	}
+}
+
+// FilterSupport describes how much of a filter a datasource applies
+type FilterSupport int
+
+const (
+	// FilterUnsupported filters are ignored by the datasource
+	FilterUnsupported FilterSupport = iota
+	// FilterInexact filters may be used to skip rows, the filter still has to be evaluated on the output
+	FilterInexact
+	// FilterExact filters are fully applied, every row produced by the scan matches the filter
+	FilterExact
+)
+
+// FilterableDataSource is an optional capability of a DataSource which can skip rows
+// while reading them, instead of producing rows which a selection would discard
+type FilterableDataSource interface {
+	DataSource
+
+	// SupportsFilters reports for every filter how the datasource would apply it
+	SupportsFilters(filters []Filter) []FilterSupport
+
+	// ScanWithFilters is Scan that only returns rows matching all the (supported) filters, filters
+	// may reference columns which are not part of the projection
+	ScanWithFilters(ctx context.Context, projection []string, filters []Filter) iter.Seq2[datatypes.RecordBatch, error]
+}
@@ -0,0 +1,45 @@
+package datasources
+
+import (
+	"context"
+	"iter"
+
+	"github.com/fastbyt3/query-engine/datatypes"
+)
+
+// MemoryDatasource serves record batches which are already loaded in memory
+type MemoryDatasource struct {
+	schema  datatypes.Schema
+	batches []datatypes.RecordBatch
+}
+
+func NewMemoryDatasource(schema datatypes.Schema, batches []datatypes.RecordBatch) *MemoryDatasource {
+	return &MemoryDatasource{schema, batches}
+}
+
+func (m *MemoryDatasource) Schema() datatypes.Schema {
+	return m.schema
+}
+
+func (m *MemoryDatasource) Scan(ctx context.Context, projection []string) iter.Seq2[datatypes.RecordBatch, error] {
+	pjSchema, pjIndices := m.schema.Select(projection)
+	return func(yield func(datatypes.RecordBatch, error) bool) {
+		for _, rb := range m.batches {
+			if err := ctx.Err(); err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+
+			fields := make([]datatypes.ColumnArray, len(pjIndices))
+			for i, idx := range pjIndices {
+				fields[i] = rb.Field(idx)
+			}
+
+			if !yield(*datatypes.NewRecordBatch(pjSchema, fields), nil) {
+				return
+			}
+		}
+	}
+}
+
+var _ DataSource = (*MemoryDatasource)(nil)
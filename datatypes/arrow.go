@@ -1,6 +1,7 @@
 package datatypes
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/apache/arrow-go/v18/arrow"
@@ -21,8 +22,17 @@ var (
 	FloatType   = &arrow.Float32Type{}
 	DoubleType  = &arrow.Float64Type{}
 	StringType  = &arrow.StringType{}
+
+	// Date32Type values are days since the epoch
+	Date32Type = &arrow.Date32Type{}
+	// TimestampType values are microseconds since the epoch, in UTC
+	TimestampType = &arrow.TimestampType{Unit: arrow.Microsecond}
 )
 
+// ErrUnsupportedValue is returned when a value can't be stored in an array or encoded into a
+// row key because of its type
+var ErrUnsupportedValue = errors.New("unsupported value")
+
 type ArrowArrayBuilder struct {
 	builder array.Builder
 }
@@ -33,46 +43,70 @@ func NewArrowArrayBuilder(mem memory.Allocator, dtype arrow.DataType) ArrowArray
 	}
 }
 
-func (a *ArrowArrayBuilder) Append(val any) {
+// Append adds a value to the array, nil adds a null. It fails with ErrUnsupportedValue when
+// the value doesn't have the Go type of the array's values
+func (a *ArrowArrayBuilder) Append(val any) error {
 	if val == nil {
 		a.builder.AppendNull()
-		return
+		return nil
 	}
 
+	var ok bool
 	switch b := a.builder.(type) {
 	case *array.BooleanBuilder:
-		b.Append(val.(bool))
+		ok = appendAs[bool](b, val)
 	case *array.Int8Builder:
-		b.Append(val.(int8))
+		ok = appendAs[int8](b, val)
 	case *array.Int16Builder:
-		b.Append(val.(int16))
+		ok = appendAs[int16](b, val)
 	case *array.Int32Builder:
-		b.Append(val.(int32))
+		ok = appendAs[int32](b, val)
 	case *array.Int64Builder:
-		b.Append(val.(int64))
+		ok = appendAs[int64](b, val)
 	case *array.Uint8Builder:
-		b.Append(val.(uint8))
+		ok = appendAs[uint8](b, val)
 	case *array.Uint16Builder:
-		b.Append(val.(uint16))
+		ok = appendAs[uint16](b, val)
 	case *array.Uint32Builder:
-		b.Append(val.(uint32))
+		ok = appendAs[uint32](b, val)
 	case *array.Uint64Builder:
-		b.Append(val.(uint64))
+		ok = appendAs[uint64](b, val)
 	case *array.Float32Builder:
-		b.Append(val.(float32))
+		ok = appendAs[float32](b, val)
 	case *array.Float64Builder:
-		b.Append(val.(float64))
+		ok = appendAs[float64](b, val)
 	case *array.StringBuilder:
-		b.Append((val.(string)))
+		ok = appendAs[string](b, val)
+	case *array.Date32Builder:
+		ok = appendAs[arrow.Date32](b, val)
+	case *array.TimestampBuilder:
+		ok = appendAs[arrow.Timestamp](b, val)
 	default:
-		panic(fmt.Errorf("arrow/array: unsupported builder for %T", b))
+		return fmt.Errorf("%w: arrays of %s can't be built", ErrUnsupportedValue, a.builder.Type())
+	}
+	if !ok {
+		return fmt.Errorf("%w: %T value in an array of %s", ErrUnsupportedValue, val, a.builder.Type())
+	}
+	return nil
+}
+
+// appendAs appends val to b when it is a T
+func appendAs[T any](b interface{ Append(T) }, val any) bool {
+	v, ok := val.(T)
+	if ok {
+		b.Append(v)
 	}
+	return ok
 }
 
-func (a *ArrowArrayBuilder) AppendValues(values ...any) {
+// AppendValues appends the values in order, stopping at the first one Append fails on
+func (a *ArrowArrayBuilder) AppendValues(values ...any) error {
 	for _, v := range values {
-		a.Append(v)
+		if err := a.Append(v); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 func (a *ArrowArrayBuilder) Build() ColumnArray {
@@ -81,6 +81,10 @@ func (a *ArrowFieldArray) GetValue(i int) any {
 		return v.Value(i)
 	case *array.String:
 		return v.Value(i)
+	case *array.Date32:
+		return v.Value(i)
+	case *array.Timestamp:
+		return v.Value(i)
 	default:
 		panic("unsupported fieldArray type")
 	}
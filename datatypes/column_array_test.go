@@ -3,6 +3,7 @@ package datatypes_test
 import (
 	"testing"
 
+	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/arrow/array"
 	"github.com/apache/arrow-go/v18/arrow/memory"
 	"github.com/fastbyt3/query-engine/datatypes"
@@ -28,7 +29,7 @@ func TestIntArray(t *testing.T) {
 func TestArrowArrayBuilder(t *testing.T) {
 	builder := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.Int8Type)
 	for i := range 10 {
-		builder.Append(int8(i))
+		require.NoError(t, builder.Append(int8(i)))
 	}
 
 	colArr := builder.Build()
@@ -36,3 +37,23 @@ func TestArrowArrayBuilder(t *testing.T) {
 		require.Equal(t, int8(i), colArr.GetValue(i))
 	}
 }
+
+func TestArrowArrayBuilderUnsupportedValue(t *testing.T) {
+	builder := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.Int64Type)
+	require.NoError(t, builder.Append(int64(1)))
+
+	err := builder.Append(int32(2))
+	require.ErrorIs(t, err, datatypes.ErrUnsupportedValue)
+	require.ErrorContains(t, err, "int32 value in an array of int64")
+
+	err = builder.AppendValues(int64(3), "4", int64(5))
+	require.ErrorIs(t, err, datatypes.ErrUnsupportedValue)
+
+	// values before the failing one are kept, the following ones aren't appended
+	colArr := builder.Build()
+	require.Equal(t, 2, colArr.Size())
+	require.Equal(t, int64(3), colArr.GetValue(1))
+
+	list := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), arrow.ListOf(datatypes.Int64Type))
+	require.ErrorIs(t, list.Append([]int64{1}), datatypes.ErrUnsupportedValue)
+}
@@ -0,0 +1,104 @@
+package datatypes
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+
+	"github.com/apache/arrow-go/v18/arrow"
+)
+
+// ErrIncomparable is returned when two values don't have the same (comparable) type
+var ErrIncomparable = errors.New("values are not comparable")
+
+// CompareValues compares two non null values of the same type, the result is negative when a < b,
+// zero when a == b and positive when a > b. For booleans false sorts before true
+func CompareValues(a, b any) (int, error) {
+	switch av := a.(type) {
+	case bool:
+		bv, ok := b.(bool)
+		if !ok {
+			break
+		}
+		switch {
+		case av == bv:
+			return 0, nil
+		case !av:
+			return -1, nil
+		default:
+			return 1, nil
+		}
+	case int8:
+		return compareOrdered(av, b)
+	case int16:
+		return compareOrdered(av, b)
+	case int32:
+		return compareOrdered(av, b)
+	case int64:
+		return compareOrdered(av, b)
+	case uint8:
+		return compareOrdered(av, b)
+	case uint16:
+		return compareOrdered(av, b)
+	case uint32:
+		return compareOrdered(av, b)
+	case uint64:
+		return compareOrdered(av, b)
+	case float32:
+		return compareOrdered(av, b)
+	case float64:
+		return compareOrdered(av, b)
+	case string:
+		return compareOrdered(av, b)
+	case arrow.Date32:
+		return compareOrdered(av, b)
+	case arrow.Timestamp:
+		return compareOrdered(av, b)
+	}
+
+	return 0, fmt.Errorf("%w: %T and %T", ErrIncomparable, a, b)
+}
+
+func compareOrdered[T cmp.Ordered](a T, b any) (int, error) {
+	bv, ok := b.(T)
+	if !ok {
+		return 0, fmt.Errorf("%w: %T and %T", ErrIncomparable, a, b)
+	}
+	return cmp.Compare(a, bv), nil
+}
+
+// IsValueOfType reports whether a non null value has the Go type used for values of dt
+func IsValueOfType(value any, dt arrow.DataType) bool {
+	switch value.(type) {
+	case bool:
+		return dt.ID() == arrow.BOOL
+	case int8:
+		return dt.ID() == arrow.INT8
+	case int16:
+		return dt.ID() == arrow.INT16
+	case int32:
+		return dt.ID() == arrow.INT32
+	case int64:
+		return dt.ID() == arrow.INT64
+	case uint8:
+		return dt.ID() == arrow.UINT8
+	case uint16:
+		return dt.ID() == arrow.UINT16
+	case uint32:
+		return dt.ID() == arrow.UINT32
+	case uint64:
+		return dt.ID() == arrow.UINT64
+	case float32:
+		return dt.ID() == arrow.FLOAT32
+	case float64:
+		return dt.ID() == arrow.FLOAT64
+	case string:
+		return dt.ID() == arrow.STRING
+	case arrow.Date32:
+		return dt.ID() == arrow.DATE32
+	case arrow.Timestamp:
+		return dt.ID() == arrow.TIMESTAMP
+	default:
+		return false
+	}
+}
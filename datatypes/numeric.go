@@ -0,0 +1,51 @@
+package datatypes
+
+import "github.com/apache/arrow-go/v18/arrow"
+
+// IsNumeric reports whether dt is an integer or a float32/float64 type
+func IsNumeric(dt arrow.DataType) bool {
+	return arrow.IsInteger(dt.ID()) || dt.ID() == arrow.FLOAT32 || dt.ID() == arrow.FLOAT64
+}
+
+// WidenNumeric returns the type two numeric operands are cast to before they are compared or
+// combined arithmetically, it reports false when either type isn't numeric.
+//
+// Integers of the same signedness widen to the larger one, an unsigned integer combined with a
+// signed one widens to a signed integer twice its size (int64 at most) and any float widens the
+// other operand to float64
+func WidenNumeric(a, b arrow.DataType) (arrow.DataType, bool) {
+	if !IsNumeric(a) || !IsNumeric(b) {
+		return nil, false
+	}
+	if arrow.TypeEqual(a, b) {
+		return a, true
+	}
+
+	aw, bw := a.(arrow.FixedWidthDataType).BitWidth(), b.(arrow.FixedWidthDataType).BitWidth()
+	switch {
+	case !arrow.IsInteger(a.ID()) || !arrow.IsInteger(b.ID()):
+		return DoubleType, true
+	case arrow.IsSignedInteger(a.ID()) == arrow.IsSignedInteger(b.ID()):
+		if aw >= bw {
+			return a, true
+		}
+		return b, true
+	case arrow.IsSignedInteger(a.ID()):
+		return signedIntegerType(max(aw, 2*bw)), true
+	default:
+		return signedIntegerType(max(bw, 2*aw)), true
+	}
+}
+
+func signedIntegerType(bitWidth int) arrow.DataType {
+	switch {
+	case bitWidth <= 8:
+		return Int8Type
+	case bitWidth <= 16:
+		return Int16Type
+	case bitWidth <= 32:
+		return Int32Type
+	default:
+		return Int64Type
+	}
+}
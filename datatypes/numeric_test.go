@@ -0,0 +1,37 @@
+package datatypes_test
+
+import (
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWidenNumeric(t *testing.T) {
+	tests := []struct {
+		a, b     arrow.DataType
+		expected arrow.DataType
+	}{
+		{datatypes.Int64Type, datatypes.Int64Type, datatypes.Int64Type},
+		{datatypes.Int8Type, datatypes.Int32Type, datatypes.Int32Type},
+		{datatypes.UInt32Type, datatypes.UInt16Type, datatypes.UInt32Type},
+		{datatypes.Int8Type, datatypes.UInt8Type, datatypes.Int16Type},
+		{datatypes.UInt32Type, datatypes.Int16Type, datatypes.Int64Type},
+		{datatypes.UInt64Type, datatypes.Int64Type, datatypes.Int64Type},
+		{datatypes.Int64Type, datatypes.DoubleType, datatypes.DoubleType},
+		{datatypes.FloatType, datatypes.Int8Type, datatypes.DoubleType},
+		{datatypes.FloatType, datatypes.DoubleType, datatypes.DoubleType},
+	}
+
+	for _, test := range tests {
+		widened, ok := datatypes.WidenNumeric(test.a, test.b)
+		require.True(t, ok, "%s and %s", test.a, test.b)
+		require.Equal(t, test.expected, widened, "%s and %s", test.a, test.b)
+	}
+
+	_, ok := datatypes.WidenNumeric(datatypes.StringType, datatypes.Int64Type)
+	require.False(t, ok)
+	_, ok = datatypes.WidenNumeric(datatypes.BooleanType, datatypes.BooleanType)
+	require.False(t, ok)
+}
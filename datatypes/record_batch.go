@@ -1,5 +1,11 @@
 package datatypes
 
+import (
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+)
+
 // Record Batch represents a bunch of columnar data
 type RecordBatch struct {
 	Schema Schema
@@ -13,6 +19,15 @@ func NewRecordBatch(schema Schema, fields []ColumnArray) *RecordBatch {
 	}
 }
 
+// NewRecordBatchFromArrow wraps the columns of an Arrow record without copying them
+func NewRecordBatchFromArrow(rec arrow.Record) *RecordBatch {
+	fields := make([]ColumnArray, rec.NumCols())
+	for i, col := range rec.Columns() {
+		fields[i] = NewArrowFieldArray(col)
+	}
+	return NewRecordBatch(Schema{*rec.Schema()}, fields)
+}
+
 func (rb *RecordBatch) RowCount() int {
 	return rb.Fields[0].Size()
 }
@@ -24,3 +39,64 @@ func (rb *RecordBatch) ColumnCount() int {
 func (rb *RecordBatch) Field(i int) ColumnArray {
 	return rb.Fields[i]
 }
+
+// Slice returns the rows [start, end) of the batch, Arrow arrays share their memory with the slice
+func (rb *RecordBatch) Slice(start, end int) (*RecordBatch, error) {
+	fields := make([]ColumnArray, len(rb.Fields))
+	for i, field := range rb.Fields {
+		switch f := field.(type) {
+		case *ArrowFieldArray:
+			fields[i] = NewArrowFieldArray(array.NewSlice(f.fieldArray, int64(start), int64(end)))
+		case LiteralValueArray:
+			fields[i] = NewLiteralValueArray(f.arrowType, f.value, end-start)
+		default:
+			builder := NewArrowArrayBuilder(memory.NewGoAllocator(), field.GetType())
+			for row := start; row < end; row++ {
+				if err := builder.Append(field.GetValue(row)); err != nil {
+					return nil, err
+				}
+			}
+			fields[i] = builder.Build()
+		}
+	}
+	return NewRecordBatch(rb.Schema, fields), nil
+}
+
+// ToArrowRecord converts the batch into an Arrow record, columns which aren't backed by an
+// Arrow array (like literals) are materialized
+func (rb *RecordBatch) ToArrowRecord() (arrow.Record, error) {
+	columns := make([]arrow.Array, len(rb.Fields))
+	for i, field := range rb.Fields {
+		if arr, ok := field.(*ArrowFieldArray); ok {
+			columns[i] = arr.fieldArray
+			continue
+		}
+
+		builder := NewArrowArrayBuilder(memory.NewGoAllocator(), field.GetType())
+		for row := range field.Size() {
+			if err := builder.Append(field.GetValue(row)); err != nil {
+				return nil, err
+			}
+		}
+		columns[i] = builder.builder.NewArray()
+	}
+	return array.NewRecord(&rb.Schema.Schema, columns, int64(rb.RowCount())), nil
+}
+
+// MemorySize estimates the number of bytes held by the columns of the batch, only the buffers
+// of Arrow arrays are counted as other arrays don't store a value per row
+func (rb *RecordBatch) MemorySize() int64 {
+	var size int64
+	for _, field := range rb.Fields {
+		arr, ok := field.(*ArrowFieldArray)
+		if !ok {
+			continue
+		}
+		for _, buf := range arr.fieldArray.Data().Buffers() {
+			if buf != nil {
+				size += int64(buf.Len())
+			}
+		}
+	}
+	return size
+}
@@ -0,0 +1,54 @@
+package datatypes_test
+
+import (
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordBatchArrowRoundTrip(t *testing.T) {
+	schema := *datatypes.NewSchema([]arrow.Field{
+		{Name: "name", Type: datatypes.StringType},
+		{Name: "one", Type: datatypes.Int64Type},
+	})
+	builder := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.StringType)
+	require.NoError(t, builder.AppendValues("a", nil, "c"))
+	rb := datatypes.NewRecordBatch(schema, []datatypes.ColumnArray{
+		builder.Build(),
+		datatypes.NewLiteralValueArray(datatypes.Int64Type, int64(1), 3),
+	})
+	require.Positive(t, rb.MemorySize())
+
+	// the literal column is materialized into an Arrow array
+	rec, err := rb.ToArrowRecord()
+	require.NoError(t, err)
+	converted := datatypes.NewRecordBatchFromArrow(rec)
+	require.Equal(t, 3, converted.RowCount())
+	for row := range 3 {
+		require.Equal(t, rb.Field(0).GetValue(row), converted.Field(0).GetValue(row))
+		require.Equal(t, int64(1), converted.Field(1).GetValue(row))
+	}
+}
+
+func TestRecordBatchSlice(t *testing.T) {
+	schema := *datatypes.NewSchema([]arrow.Field{
+		{Name: "id", Type: datatypes.Int64Type},
+		{Name: "one", Type: datatypes.Int64Type},
+	})
+	builder := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.Int64Type)
+	require.NoError(t, builder.AppendValues(int64(1), nil, int64(3), int64(4)))
+	rb := datatypes.NewRecordBatch(schema, []datatypes.ColumnArray{
+		builder.Build(),
+		datatypes.NewLiteralValueArray(datatypes.Int64Type, int64(1), 4),
+	})
+
+	slice, err := rb.Slice(1, 3)
+	require.NoError(t, err)
+	require.Equal(t, 2, slice.RowCount())
+	require.Nil(t, slice.Field(0).GetValue(0))
+	require.Equal(t, int64(3), slice.Field(0).GetValue(1))
+	require.Equal(t, 2, slice.Field(1).Size())
+}
@@ -0,0 +1,110 @@
+package datatypes
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/apache/arrow-go/v18/arrow"
+)
+
+// type tags prefixed to every encoded value so that values of different types never
+// produce the same bytes (eg. nil vs the string "<nil>" or int32(1) vs int64(1))
+const (
+	keyTagNull byte = iota
+	keyTagBool
+	keyTagInt8
+	keyTagInt16
+	keyTagInt32
+	keyTagInt64
+	keyTagUint8
+	keyTagUint16
+	keyTagUint32
+	keyTagUint64
+	keyTagFloat32
+	keyTagFloat64
+	keyTagString
+	keyTagDate32
+	keyTagTimestamp
+)
+
+// AppendRowKey appends a binary encoding of the values at `row` in every column to dst and
+// returns the extended buffer. Two rows produce the same key only if all their values are equal.
+//
+// The key is meant to be used as a hash map key via string(key), callers should reuse
+// dst across rows (eg. `buf, err = AppendRowKey(buf[:0], cols, i)`) to avoid allocating per row
+func AppendRowKey(dst []byte, columns []ColumnArray, row int) ([]byte, error) {
+	for _, col := range columns {
+		var err error
+		if dst, err = AppendKeyValue(dst, col.GetValue(row)); err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
+
+// AppendKeyValue appends a type tagged encoding of a single value to dst. Fixed width values
+// are written big endian and strings are length prefixed, so concatenated values can't collide.
+// It fails with ErrUnsupportedValue for values of a type arrays can't hold
+func AppendKeyValue(dst []byte, value any) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(dst, keyTagNull), nil
+	case bool:
+		b := byte(0)
+		if v {
+			b = 1
+		}
+		return append(dst, keyTagBool, b), nil
+	case int8:
+		return append(dst, keyTagInt8, byte(v)), nil
+	case int16:
+		return binary.BigEndian.AppendUint16(append(dst, keyTagInt16), uint16(v)), nil
+	case int32:
+		return binary.BigEndian.AppendUint32(append(dst, keyTagInt32), uint32(v)), nil
+	case int64:
+		return binary.BigEndian.AppendUint64(append(dst, keyTagInt64), uint64(v)), nil
+	case uint8:
+		return append(dst, keyTagUint8, v), nil
+	case uint16:
+		return binary.BigEndian.AppendUint16(append(dst, keyTagUint16), v), nil
+	case uint32:
+		return binary.BigEndian.AppendUint32(append(dst, keyTagUint32), v), nil
+	case uint64:
+		return binary.BigEndian.AppendUint64(append(dst, keyTagUint64), v), nil
+	case float32:
+		return binary.BigEndian.AppendUint32(append(dst, keyTagFloat32), math.Float32bits(normalizeFloat32(v))), nil
+	case float64:
+		return binary.BigEndian.AppendUint64(append(dst, keyTagFloat64), math.Float64bits(normalizeFloat64(v))), nil
+	case string:
+		dst = binary.AppendUvarint(append(dst, keyTagString), uint64(len(v)))
+		return append(dst, v...), nil
+	case arrow.Date32:
+		return binary.BigEndian.AppendUint32(append(dst, keyTagDate32), uint32(v)), nil
+	case arrow.Timestamp:
+		return binary.BigEndian.AppendUint64(append(dst, keyTagTimestamp), uint64(v)), nil
+	default:
+		return nil, fmt.Errorf("%w: %T value in a row key", ErrUnsupportedValue, value)
+	}
+}
+
+// -0 and +0 compare equal, and every NaN is treated as the same group
+func normalizeFloat32(v float32) float32 {
+	if v == 0 {
+		return 0
+	}
+	if v != v {
+		return float32(math.NaN())
+	}
+	return v
+}
+
+func normalizeFloat64(v float64) float64 {
+	if v == 0 {
+		return 0
+	}
+	if math.IsNaN(v) {
+		return math.NaN()
+	}
+	return v
+}
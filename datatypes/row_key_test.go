@@ -0,0 +1,63 @@
+package datatypes_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/stretchr/testify/require"
+)
+
+func rowKey(t *testing.T, values ...any) string {
+	var key []byte
+	for _, v := range values {
+		var err error
+		key, err = datatypes.AppendKeyValue(key, v)
+		require.NoError(t, err)
+	}
+	return string(key)
+}
+
+func TestRowKeyNoCollisions(t *testing.T) {
+	require.NotEqual(t, rowKey(t, "1", "23"), rowKey(t, "12", "3"))
+	require.NotEqual(t, rowKey(t, nil), rowKey(t, "<nil>"))
+	require.NotEqual(t, rowKey(t, nil, "a"), rowKey(t, "", "a"))
+	require.NotEqual(t, rowKey(t, int32(1)), rowKey(t, int64(1)))
+	require.NotEqual(t, rowKey(t, true), rowKey(t, uint8(1)))
+
+	require.Equal(t, rowKey(t, "a", int64(7), nil), rowKey(t, "a", int64(7), nil))
+	require.Equal(t, rowKey(t, 0.0), rowKey(t, math.Copysign(0, -1)))
+	require.Equal(t, rowKey(t, math.NaN()), rowKey(t, math.NaN()))
+}
+
+func TestAppendRowKey(t *testing.T) {
+	first := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.StringType)
+	require.NoError(t, first.AppendValues("1", "12", "1"))
+	second := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.StringType)
+	require.NoError(t, second.AppendValues("23", "3", "23"))
+	cols := []datatypes.ColumnArray{first.Build(), second.Build()}
+
+	var buf []byte
+	buf, err := datatypes.AppendRowKey(buf[:0], cols, 0)
+	require.NoError(t, err)
+	key0 := string(buf)
+	buf, err = datatypes.AppendRowKey(buf[:0], cols, 1)
+	require.NoError(t, err)
+	key1 := string(buf)
+	buf, err = datatypes.AppendRowKey(buf[:0], cols, 2)
+	require.NoError(t, err)
+	key2 := string(buf)
+
+	require.NotEqual(t, key0, key1)
+	require.Equal(t, key0, key2)
+}
+
+func TestRowKeyUnsupportedValue(t *testing.T) {
+	_, err := datatypes.AppendKeyValue(nil, []int{1})
+	require.ErrorIs(t, err, datatypes.ErrUnsupportedValue)
+
+	cols := []datatypes.ColumnArray{datatypes.NewLiteralValueArray(datatypes.StringType, struct{}{}, 1)}
+	_, err = datatypes.AppendRowKey(nil, cols, 0)
+	require.ErrorIs(t, err, datatypes.ErrUnsupportedValue)
+}
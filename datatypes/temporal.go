@@ -0,0 +1,61 @@
+package datatypes
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+)
+
+const dateLayout = "2006-01-02"
+
+// DefaultDateLayouts are the layouts accepted by ParseDate
+var DefaultDateLayouts = []string{dateLayout}
+
+// DefaultTimestampLayouts are tried in order by ParseTimestamp, a value without a time is at midnight
+var DefaultTimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	dateLayout,
+}
+
+// ParseDate parses a `YYYY-MM-DD` date into the number of days since the epoch
+func ParseDate(s string) (arrow.Date32, error) {
+	return ParseDateLayouts(s, DefaultDateLayouts)
+}
+
+// ParseDateLayouts parses a date in the first of the time.Parse layouts it matches
+func ParseDateLayouts(s string, layouts []string) (arrow.Date32, error) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return arrow.Date32FromTime(t), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid date %q", s)
+}
+
+// ParseTimestamp parses an ISO 8601 timestamp, with a `T` or a space between the date and the
+// time, into microseconds since the epoch. Timestamps with an offset are converted to UTC
+func ParseTimestamp(s string) (arrow.Timestamp, error) {
+	return ParseTimestampLayouts(s, DefaultTimestampLayouts)
+}
+
+// ParseTimestampLayouts parses a timestamp in the first of the time.Parse layouts it matches
+func ParseTimestampLayouts(s string, layouts []string) (arrow.Timestamp, error) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return arrow.Timestamp(t.UnixMicro()), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid timestamp %q", s)
+}
+
+func FormatDate(d arrow.Date32) string {
+	return d.ToTime().Format(dateLayout)
+}
+
+func FormatTimestamp(ts arrow.Timestamp) string {
+	return time.UnixMicro(int64(ts)).UTC().Format("2006-01-02 15:04:05.999999")
+}
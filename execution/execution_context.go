@@ -1,24 +1,161 @@
 package execution
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+
 	"github.com/fastbyt3/query-engine/datasources"
+	"github.com/fastbyt3/query-engine/datatypes"
 	"github.com/fastbyt3/query-engine/logicalplans"
+	"github.com/fastbyt3/query-engine/optimizer"
+	"github.com/fastbyt3/query-engine/physicalplan"
+	"github.com/fastbyt3/query-engine/planner"
+	"github.com/fastbyt3/query-engine/sql"
 )
 
+// ErrTableNotFound is returned when a query references a table which isn't registered
+var ErrTableNotFound = errors.New("table not found")
+
 type ExecutionContext struct {
 	BatchSize int
+
+	// QueryTimeout is the deadline applied to every query once its execution starts,
+	// zero means queries only stop when the caller's context is done
+	QueryTimeout time.Duration
+
+	// SortMemoryLimit is the number of bytes a sort buffers before it spills sorted runs to
+	// temporary files in SpillDir (the default directory for temporary files when empty),
+	// zero keeps sorts in memory
+	SortMemoryLimit int64
+	SpillDir        string
+
+	optimizerRules []optimizer.OptimizerRule
+
+	// tables registered by name, used to resolve the tables of SQL queries
+	tables map[string]logicalplans.Dataframe
+}
+
+// CSV returns a dataframe reading the CSV file, datasources.DefaultCSVOptions reads comma
+// separated files with a header row
+func (e *ExecutionContext) CSV(filename string, options datasources.CSVOptions) (logicalplans.Dataframe, error) {
+	ds, err := datasources.NewCSVDatasource(filename, e.BatchSize, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return logicalplans.NewDefaultDataframe(logicalplans.NewScan(filename, ds, []string{})), nil
+}
+
+// RegisterCSV makes the CSV file available to SQL queries as the table name
+func (e *ExecutionContext) RegisterCSV(name string, filename string, options datasources.CSVOptions) error {
+	df, err := e.CSV(filename, options)
+	if err != nil {
+		return err
+	}
+
+	e.registerTable(name, df)
+	return nil
+}
+
+// RegisterDataSource makes ds available to SQL queries as the table name
+func (e *ExecutionContext) RegisterDataSource(name string, ds datasources.DataSource) {
+	e.registerTable(name, logicalplans.NewDefaultDataframe(logicalplans.NewScan(name, ds, []string{})))
+}
+
+func (e *ExecutionContext) registerTable(name string, df logicalplans.Dataframe) {
+	if e.tables == nil {
+		e.tables = make(map[string]logicalplans.Dataframe)
+	}
+	e.tables[name] = df
+}
+
+// Table returns a dataframe reading the registered table name
+func (e *ExecutionContext) Table(name string) (logicalplans.Dataframe, error) {
+	df, ok := e.tables[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrTableNotFound, name)
+	}
+	return df, nil
+}
+
+// SQL parses a SELECT statement and returns the dataframe producing its result, the tables
+// of the query must be registered first
+func (e *ExecutionContext) SQL(query string) (logicalplans.Dataframe, error) {
+	stmt, err := sql.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	return sql.CreateLogicalPlan(stmt, e)
+}
+
+// Execute plans the dataframe and lazily runs the query, record batches are produced
+// as the returned iterator is consumed.
+//
+// A planning or execution error is yielded as the last element of the iterator. Cancelling ctx
+// or exceeding QueryTimeout stops the query and yields context.Canceled or context.DeadlineExceeded.
+// The query updates the metrics carried by ctx, see metrics.NewContext
+func (e *ExecutionContext) Execute(ctx context.Context, df logicalplans.Dataframe) iter.Seq2[datatypes.RecordBatch, error] {
+	plan, err := e.createPhysicalPlan(df)
+	return func(yield func(datatypes.RecordBatch, error) bool) {
+		if err != nil {
+			yield(datatypes.RecordBatch{}, err)
+			return
+		}
+
+		ctx, cancel := e.queryContext(ctx)
+		defer cancel()
+
+		for rb, err := range plan.Execute(ctx) {
+			if !yield(rb, err) {
+				return
+			}
+		}
+	}
 }
 
-func (e *ExecutionContext) CSV(filename string) logicalplans.Dataframe {
-	return logicalplans.NewDefaultDataframe(logicalplans.NewScan(
-		filename,
-		datasources.NewCSVDatasource(filename, 1024),
-		[]string{},
-	))
+// Collect plans and runs the query to completion and returns all the produced record batches
+func (e *ExecutionContext) Collect(ctx context.Context, df logicalplans.Dataframe) ([]datatypes.RecordBatch, error) {
+	var batches []datatypes.RecordBatch
+	for rb, err := range e.Execute(ctx, df) {
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, rb)
+	}
+	return batches, nil
+}
+
+func (e *ExecutionContext) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.QueryTimeout > 0 {
+		return context.WithTimeout(ctx, e.QueryTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// RegisterOptimizerRule adds a rule that is applied to the logical plan of every query
+// after the default optimizer rules
+func (e *ExecutionContext) RegisterOptimizerRule(rule optimizer.OptimizerRule) {
+	e.optimizerRules = append(e.optimizerRules, rule)
+}
+
+func (e *ExecutionContext) createPhysicalPlan(df logicalplans.Dataframe) (physicalplan.PhysicalPlan, error) {
+	queryPlanner := planner.NewQueryPlanner(e.BatchSize)
+	queryPlanner.SortMemoryLimit = e.SortMemoryLimit
+	queryPlanner.SpillDir = e.SpillDir
+	for _, rule := range e.optimizerRules {
+		queryPlanner.RegisterRule(rule)
+	}
+	return queryPlanner.CreatePhysicalPlan(df.LogicalPlan())
 }
 
 func NewExecutionContext(batchSize int) *ExecutionContext {
 	return &ExecutionContext{
 		BatchSize: batchSize,
+		tables:    make(map[string]logicalplans.Dataframe),
 	}
 }
+
+var _ sql.Catalog = (*ExecutionContext)(nil)
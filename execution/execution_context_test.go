@@ -0,0 +1,436 @@
+package execution_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/fastbyt3/query-engine/datasources"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/execution"
+	"github.com/fastbyt3/query-engine/logicalplans"
+	"github.com/fastbyt3/query-engine/metrics"
+	"github.com/fastbyt3/query-engine/physicalplan"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	SAMPLE_CSV_FILE   = "../test-data/sample.csv"
+	EMPLOYEE_CSV_FILE = "../test-data/employee.csv"
+)
+
+func TestExecuteHonorsBatchSize(t *testing.T) {
+	ctx := execution.NewExecutionContext(10)
+	df, err := ctx.CSV(SAMPLE_CSV_FILE, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+
+	var sizes []int
+	for rb, err := range ctx.Execute(context.Background(), df) {
+		require.NoError(t, err)
+		sizes = append(sizes, rb.RowCount())
+	}
+	require.Equal(t, []int{10, 10, 10, 2}, sizes)
+}
+
+func TestCollect(t *testing.T) {
+	ctx := execution.NewExecutionContext(1024)
+	df, err := ctx.CSV(EMPLOYEE_CSV_FILE, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+	df = df.
+		Filter(logicalplans.NewEqExpr(logicalplans.NewColumn("state"), logicalplans.NewLiteralString("CO"))).
+		Project([]logicalplans.LogicalExpr{
+			logicalplans.NewColumn("last_name"),
+			logicalplans.NewColumn("salary"),
+		})
+
+	batches, err := ctx.Collect(context.Background(), df)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+
+	rb := batches[0]
+	require.Equal(t, 2, rb.ColumnCount())
+	require.Equal(t, 2, rb.RowCount())
+	require.Equal(t, "Langford", rb.Field(0).GetValue(0))
+	require.Equal(t, int64(11500), rb.Field(1).GetValue(1))
+}
+
+func TestCollectPlanningError(t *testing.T) {
+	ctx := execution.NewExecutionContext(1024)
+	df, err := ctx.CSV(EMPLOYEE_CSV_FILE, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+	df = df.Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("country")})
+
+	_, err = ctx.Collect(context.Background(), df)
+	require.ErrorIs(t, err, logicalplans.ErrColumnNotFound)
+}
+
+func TestCollectExecutionError(t *testing.T) {
+	ctx := execution.NewExecutionContext(1024)
+	df, err := ctx.CSV(EMPLOYEE_CSV_FILE, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+	df = df.Filter(logicalplans.NewEqExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralString("10000")))
+
+	_, err = ctx.Collect(context.Background(), df)
+	require.ErrorIs(t, err, physicalplan.ErrTypeMismatch)
+
+	// the failed query doesn't affect other queries
+	df, err = ctx.CSV(EMPLOYEE_CSV_FILE, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+	batches, err := ctx.Collect(context.Background(), df)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+}
+
+func TestExecuteCancelled(t *testing.T) {
+	ctx := execution.NewExecutionContext(1024)
+	df, err := ctx.CSV(EMPLOYEE_CSV_FILE, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ctx.Collect(cancelled, df)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestExecuteQueryTimeout(t *testing.T) {
+	ctx := execution.NewExecutionContext(1024)
+	ctx.QueryTimeout = time.Nanosecond
+	df, err := ctx.CSV(EMPLOYEE_CSV_FILE, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+	df = df.Aggregate(
+		[]logicalplans.LogicalExpr{logicalplans.NewColumn("state")},
+		[]logicalplans.AggregateExpr{logicalplans.NewAggregateCountExpr(logicalplans.NewColumn("id"))},
+	)
+
+	_, err = ctx.Collect(context.Background(), df)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSQL(t *testing.T) {
+	ctx := execution.NewExecutionContext(1024)
+	require.NoError(t, ctx.RegisterCSV("employee", EMPLOYEE_CSV_FILE, datasources.DefaultCSVOptions()))
+
+	df, err := ctx.SQL(`
+		SELECT state, MAX(salary), COUNT(*)
+		FROM employee
+		WHERE job_title != 'Driver'
+		GROUP BY state
+		HAVING COUNT(id) = 1`)
+	require.NoError(t, err)
+
+	batches, err := ctx.Collect(context.Background(), df)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+
+	rb := batches[0]
+	var rows [][]any
+	for row := range rb.RowCount() {
+		rows = append(rows, []any{rb.Field(0).GetValue(row), rb.Field(1).GetValue(row), rb.Field(2).GetValue(row)})
+	}
+	require.Equal(t, [][]any{
+		{"CA", int64(12000), int64(1)},
+		{"CO", int64(11500), int64(1)},
+		{nil, int64(11500), int64(1)},
+	}, rows)
+}
+
+func TestSQLOrderBy(t *testing.T) {
+	ctx := execution.NewExecutionContext(1024)
+	require.NoError(t, ctx.RegisterCSV("employee", EMPLOYEE_CSV_FILE, datasources.DefaultCSVOptions()))
+
+	df, err := ctx.SQL("SELECT first_name FROM employee ORDER BY salary DESC, last_name")
+	require.NoError(t, err)
+
+	batches, err := ctx.Collect(context.Background(), df)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+
+	var names []any
+	for row := range batches[0].RowCount() {
+		names = append(names, batches[0].Field(0).GetValue(row))
+	}
+	require.Equal(t, []any{"Bill", "Von", "John", "Gregg"}, names)
+}
+
+func TestSQLOrderBySpillsToDisk(t *testing.T) {
+	ctx := execution.NewExecutionContext(1)
+	ctx.SortMemoryLimit = 1
+	ctx.SpillDir = t.TempDir()
+	require.NoError(t, ctx.RegisterCSV("employee", EMPLOYEE_CSV_FILE, datasources.DefaultCSVOptions()))
+
+	df, err := ctx.SQL("SELECT first_name FROM employee ORDER BY salary DESC, last_name")
+	require.NoError(t, err)
+
+	var names []any
+	for rb, err := range ctx.Execute(context.Background(), df) {
+		require.NoError(t, err)
+		for row := range rb.RowCount() {
+			names = append(names, rb.Field(0).GetValue(row))
+		}
+	}
+	require.Equal(t, []any{"Bill", "Von", "John", "Gregg"}, names)
+
+	files, err := os.ReadDir(ctx.SpillDir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestSQLLimit(t *testing.T) {
+	ctx := execution.NewExecutionContext(10)
+	require.NoError(t, ctx.RegisterCSV("cars", SAMPLE_CSV_FILE, datasources.DefaultCSVOptions()))
+
+	df, err := ctx.SQL("SELECT model FROM cars LIMIT 3 OFFSET 9")
+	require.NoError(t, err)
+
+	var models []any
+	var batchSizes []int
+	for rb, err := range ctx.Execute(context.Background(), df) {
+		require.NoError(t, err)
+		batchSizes = append(batchSizes, rb.RowCount())
+		for row := range rb.RowCount() {
+			models = append(models, rb.Field(0).GetValue(row))
+		}
+	}
+	require.Equal(t, []any{"Merc 280", "Merc 280C", "Merc 450SE"}, models)
+	require.Equal(t, []int{1, 2}, batchSizes)
+}
+
+func TestSQLDistinct(t *testing.T) {
+	ctx := execution.NewExecutionContext(2)
+	require.NoError(t, ctx.RegisterCSV("employee", EMPLOYEE_CSV_FILE, datasources.DefaultCSVOptions()))
+
+	df, err := ctx.SQL("SELECT DISTINCT salary FROM employee ORDER BY salary DESC")
+	require.NoError(t, err)
+
+	var salaries []any
+	for rb, err := range ctx.Execute(context.Background(), df) {
+		require.NoError(t, err)
+		for row := range rb.RowCount() {
+			salaries = append(salaries, rb.Field(0).GetValue(row))
+		}
+	}
+	require.Equal(t, []any{int64(12000), int64(11500), int64(10000)}, salaries)
+
+	df, err = ctx.SQL("SELECT COUNT(DISTINCT salary), COUNT(salary) FROM employee")
+	require.NoError(t, err)
+	batches, err := ctx.Collect(context.Background(), df)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), batches[0].Field(0).GetValue(0))
+	require.Equal(t, int64(4), batches[0].Field(1).GetValue(0))
+}
+
+func TestSQLAlias(t *testing.T) {
+	ctx := execution.NewExecutionContext(1024)
+	require.NoError(t, ctx.RegisterCSV("employee", EMPLOYEE_CSV_FILE, datasources.DefaultCSVOptions()))
+
+	df, err := ctx.SQL("SELECT first_name AS name, state = 'CO' AS in_co, state = 'CA' FROM employee ORDER BY name")
+	require.NoError(t, err)
+
+	batches, err := ctx.Collect(context.Background(), df)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+
+	rb := batches[0]
+	var names []string
+	for _, f := range rb.Schema.Fields() {
+		names = append(names, f.Name)
+	}
+	require.Equal(t, []string{"name", "in_co", "state = 'CA'"}, names)
+
+	var rows [][]any
+	for row := range rb.RowCount() {
+		rows = append(rows, []any{rb.Field(0).GetValue(row), rb.Field(1).GetValue(row), rb.Field(2).GetValue(row)})
+	}
+	require.Equal(t, [][]any{
+		{"Bill", false, true},
+		{"Gregg", true, false},
+		{"John", true, false},
+		{"Von", nil, nil},
+	}, rows)
+}
+
+func TestSQLCast(t *testing.T) {
+	ctx := execution.NewExecutionContext(1024)
+	require.NoError(t, ctx.RegisterCSV("employee", EMPLOYEE_CSV_FILE, datasources.DefaultCSVOptions()))
+
+	df, err := ctx.SQL("SELECT first_name, CAST(salary AS DOUBLE) * 1.5 AS raised, TRY_CAST(state AS INT) FROM employee WHERE CAST(id AS BIGINT) > 2")
+	require.NoError(t, err)
+
+	batches, err := ctx.Collect(context.Background(), df)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+
+	rb := batches[0]
+	var rows [][]any
+	for row := range rb.RowCount() {
+		rows = append(rows, []any{rb.Field(0).GetValue(row), rb.Field(1).GetValue(row), rb.Field(2).GetValue(row)})
+	}
+	require.Equal(t, [][]any{{"John", 17250.0, nil}, {"Von", 17250.0, nil}}, rows)
+
+	// a strict cast fails the query on the first value it can't convert
+	df, err = ctx.SQL("SELECT CAST(last_name AS INT) FROM employee")
+	require.NoError(t, err)
+	_, err = ctx.Collect(context.Background(), df)
+	require.ErrorIs(t, err, physicalplan.ErrInvalidCast)
+}
+
+func TestSQLWidensNumericOperands(t *testing.T) {
+	ctx := execution.NewExecutionContext(1024)
+	require.NoError(t, ctx.RegisterCSV("employee", EMPLOYEE_CSV_FILE, datasources.DefaultCSVOptions()))
+
+	df, err := ctx.SQL("SELECT id, CAST(salary AS DOUBLE) / 3, salary + 0.5 FROM employee WHERE salary > 11000.5")
+	require.NoError(t, err)
+
+	batches, err := ctx.Collect(context.Background(), df)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+
+	rb := batches[0]
+	var rows [][]any
+	for row := range rb.RowCount() {
+		rows = append(rows, []any{rb.Field(0).GetValue(row), rb.Field(1).GetValue(row), rb.Field(2).GetValue(row)})
+	}
+	require.Equal(t, [][]any{
+		{int64(1), 4000.0, 12000.5},
+		{int64(3), 11500.0 / 3, 11500.5},
+		{int64(4), 11500.0 / 3, 11500.5},
+	}, rows)
+}
+
+func TestSQLUnknownTable(t *testing.T) {
+	ctx := execution.NewExecutionContext(1024)
+	_, err := ctx.SQL("SELECT * FROM employee")
+	require.ErrorIs(t, err, execution.ErrTableNotFound)
+}
+
+func TestSQLSubqueries(t *testing.T) {
+	ctx := execution.NewExecutionContext(1024)
+	require.NoError(t, ctx.RegisterCSV("employee", EMPLOYEE_CSV_FILE, datasources.DefaultCSVOptions()))
+
+	states := *datatypes.NewSchema([]arrow.Field{
+		{Name: "code", Type: datatypes.StringType},
+		{Name: "max_salary", Type: datatypes.Int64Type},
+	})
+	codes := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.StringType)
+	require.NoError(t, codes.AppendValues("CA", "CO"))
+	maxSalaries := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.Int64Type)
+	require.NoError(t, maxSalaries.AppendValues(int64(20000), int64(11000)))
+	ctx.RegisterDataSource("states", datasources.NewMemoryDatasource(states, []datatypes.RecordBatch{
+		*datatypes.NewRecordBatch(states, []datatypes.ColumnArray{codes.Build(), maxSalaries.Build()}),
+	}))
+
+	offices := *datatypes.NewSchema([]arrow.Field{
+		{Name: "state", Type: datatypes.StringType},
+		{Name: "city", Type: datatypes.StringType},
+	})
+	officeStates := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.StringType)
+	require.NoError(t, officeStates.AppendValues("CA", "CO"))
+	cities := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.StringType)
+	require.NoError(t, cities.AppendValues("Oakland", "Denver"))
+	ctx.RegisterDataSource("offices", datasources.NewMemoryDatasource(offices, []datatypes.RecordBatch{
+		*datatypes.NewRecordBatch(offices, []datatypes.ColumnArray{officeStates.Build(), cities.Build()}),
+	}))
+
+	tests := []struct {
+		query    string
+		expected []any
+	}{
+		{"SELECT last_name FROM employee WHERE state IN (SELECT code FROM states)", []any{"Hopkins", "Langford", "Travis"}},
+		// the state of Mill is null, which is neither in nor not in the states
+		{"SELECT last_name FROM employee WHERE state NOT IN (SELECT code FROM states)", nil},
+		{
+			"SELECT last_name FROM employee WHERE EXISTS (SELECT * FROM states WHERE code = state AND salary > max_salary)",
+			[]any{"Travis"},
+		},
+		{
+			"SELECT last_name FROM employee WHERE NOT EXISTS (SELECT code FROM states WHERE state = code)",
+			[]any{"Mill"},
+		},
+		// both tables have a state column, the qualified names tell the correlation apart
+		{
+			"SELECT last_name FROM employee WHERE EXISTS (SELECT * FROM offices WHERE offices.state = employee.state)",
+			[]any{"Hopkins", "Langford", "Travis"},
+		},
+		{
+			"SELECT last_name FROM employee WHERE NOT EXISTS (SELECT * FROM offices WHERE employee.state = state AND city = 'Denver')",
+			[]any{"Hopkins", "Mill"},
+		},
+	}
+
+	for _, tt := range tests {
+		df, err := ctx.SQL(tt.query)
+		require.NoError(t, err, tt.query)
+
+		batches, err := ctx.Collect(context.Background(), df)
+		require.NoError(t, err, tt.query)
+
+		var names []any
+		for _, rb := range batches {
+			for row := range rb.RowCount() {
+				names = append(names, rb.Field(0).GetValue(row))
+			}
+		}
+		require.Equal(t, tt.expected, names, tt.query)
+	}
+}
+
+func TestSQLBadRecordMetrics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "employee.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id,salary\n1,100\n2,abc\n3\n4,300\n"), 0o644))
+
+	ctx := execution.NewExecutionContext(1024)
+	options := datasources.DefaultCSVOptions()
+	options.InferRows = 1
+	options.BadRecords = datasources.BadRecordSkip
+	require.NoError(t, ctx.RegisterCSV("employee", path, options))
+
+	df, err := ctx.SQL("SELECT SUM(salary) FROM employee")
+	require.NoError(t, err)
+
+	m := metrics.New()
+	batches, err := ctx.Collect(metrics.NewContext(context.Background(), m), df)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	require.Equal(t, int64(400), batches[0].Field(0).GetValue(0))
+	require.Equal(t, map[string]int64{datasources.MetricCSVRejectedRows: 2}, m.Counters())
+}
+
+func TestSQLNullableBooleanLogic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "t.csv")
+	require.NoError(t, os.WriteFile(path, []byte("a,b,name\n1,,x\n2,5,y\n1,2,z\n"), 0o644))
+
+	ctx := execution.NewExecutionContext(1024)
+	require.NoError(t, ctx.RegisterCSV("t", path, datasources.DefaultCSVOptions()))
+
+	tests := []struct {
+		query    string
+		expected []any
+	}{
+		// TRUE OR NULL is TRUE
+		{"SELECT name FROM t WHERE a = 1 OR b = 2", []any{"x", "z"}},
+		// FALSE AND NULL is FALSE
+		{"SELECT name FROM t WHERE NOT (a = 2 AND b = 7)", []any{"x", "y", "z"}},
+		{"SELECT name FROM t WHERE NOT (a = 1 AND b = 7)", []any{"y", "z"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			df, err := ctx.SQL(test.query)
+			require.NoError(t, err)
+
+			batches, err := ctx.Collect(context.Background(), df)
+			require.NoError(t, err)
+			var names []any
+			for _, rb := range batches {
+				for row := range rb.RowCount() {
+					names = append(names, rb.Field(0).GetValue(row))
+				}
+			}
+			require.Equal(t, test.expected, names)
+		})
+	}
+}
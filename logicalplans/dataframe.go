@@ -14,8 +14,20 @@ type Dataframe interface {
 	// Aggregate
 	Aggregate(groupBy []LogicalExpr, aggregateExpr []AggregateExpr) Dataframe
 
+	// Join with another dataframe on equal keys, filter is optional and may be nil
+	Join(right Dataframe, joinType JoinType, keys []JoinKey, filter LogicalExpr) Dataframe
+
+	// Sort rows by the given expressions, the first one has the highest priority
+	Sort(exprs ...SortExpr) Dataframe
+
+	// Skip the first skip rows and keep at most fetch rows, a negative fetch keeps every row
+	Limit(skip, fetch int) Dataframe
+
+	// Remove duplicate rows
+	Distinct() Dataframe
+
 	// Schema of data produced by this Dataframe
-	Schema() datatypes.Schema
+	Schema() (datatypes.Schema, error)
 
 	// Get logical plan for dataframe
 	LogicalPlan() LogicalPlan
@@ -33,10 +45,22 @@ func (d DefaultDataframe) Aggregate(groupBy []LogicalExpr, aggregateExpr []Aggre
 	return DefaultDataframe{NewAggregate(d.plan, groupBy, aggregateExpr)}
 }
 
+func (d DefaultDataframe) Distinct() Dataframe {
+	return DefaultDataframe{NewDistinct(d.plan)}
+}
+
 func (d DefaultDataframe) Filter(expr LogicalExpr) Dataframe {
 	return DefaultDataframe{NewSelection(d.plan, expr)}
 }
 
+func (d DefaultDataframe) Join(right Dataframe, joinType JoinType, keys []JoinKey, filter LogicalExpr) Dataframe {
+	return DefaultDataframe{NewJoin(d.plan, right.LogicalPlan(), joinType, keys, filter)}
+}
+
+func (d DefaultDataframe) Limit(skip, fetch int) Dataframe {
+	return DefaultDataframe{NewLimit(d.plan, skip, fetch)}
+}
+
 func (d DefaultDataframe) LogicalPlan() LogicalPlan {
 	return d.plan
 }
@@ -45,7 +69,11 @@ func (d DefaultDataframe) Project(expr []LogicalExpr) Dataframe {
 	return DefaultDataframe{NewProjection(d.plan, expr)}
 }
 
-func (d DefaultDataframe) Schema() datatypes.Schema {
+func (d DefaultDataframe) Sort(exprs ...SortExpr) Dataframe {
+	return DefaultDataframe{NewSort(d.plan, exprs)}
+}
+
+func (d DefaultDataframe) Schema() (datatypes.Schema, error) {
 	return d.plan.Schema()
 }
 
@@ -1,14 +1,26 @@
 package logicalplans
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/fastbyt3/query-engine/datatypes"
 )
 
+var (
+	// ErrColumnNotFound is returned when a column reference doesn't match any field of the input schema
+	ErrColumnNotFound = errors.New("column not found")
+
+	// ErrDuplicateColumn is returned when two expressions of a projection produce columns with the
+	// same name, and when a column reference is ambiguous because several fields of the input have
+	// its name, like the fields both inputs of a join have
+	ErrDuplicateColumn = errors.New("duplicate column name")
+)
+
 type LogicalExpr interface {
-	ToField(input LogicalPlan) arrow.Field
+	ToField(input LogicalPlan) (arrow.Field, error)
 	String() string
 }
 
@@ -24,15 +36,31 @@ func NewColumn(name string) Column {
 	return Column{name}
 }
 
-func (c Column) ToField(input LogicalPlan) arrow.Field {
-	schema := input.Schema()
-	for _, f := range schema.Fields() {
-		if f.Name == c.Name {
-			return f
-		}
+func (c Column) ToField(input LogicalPlan) (arrow.Field, error) {
+	schema, err := input.Schema()
+	if err != nil {
+		return arrow.Field{}, err
 	}
 
-	panic(fmt.Sprintf("failed to find a column: %s in logicPlan", c.Name))
+	index, err := c.Index(schema, input)
+	if err != nil {
+		return arrow.Field{}, err
+	}
+	return schema.Field(index), nil
+}
+
+// Index returns the position of the field the column references in the schema of input, the
+// column must match exactly one field
+func (c Column) Index(schema datatypes.Schema, input LogicalPlan) (int, error) {
+	indices := schema.FieldIndices(c.Name)
+	switch len(indices) {
+	case 0:
+		return 0, fmt.Errorf("%w: %q in %s", ErrColumnNotFound, c.Name, input)
+	case 1:
+		return indices[0], nil
+	default:
+		return 0, fmt.Errorf("%w: %q is ambiguous in %s, one of its fields has to be renamed", ErrDuplicateColumn, c.Name, input)
+	}
 }
 
 func (c Column) String() string {
@@ -50,11 +78,11 @@ func NewLiteralString(s string) LiteralString {
 	return LiteralString{Str: s}
 }
 
-func (e LiteralString) ToField(input LogicalPlan) arrow.Field {
+func (e LiteralString) ToField(input LogicalPlan) (arrow.Field, error) {
 	return arrow.Field{
-		Name: e.Str,
+		Name: e.String(),
 		Type: datatypes.StringType,
-	}
+	}, nil
 }
 
 func (e LiteralString) String() string {
@@ -69,11 +97,11 @@ func NewLiteralLong(n int64) LiteralLong {
 	return LiteralLong{n}
 }
 
-func (e LiteralLong) ToField(input LogicalPlan) arrow.Field {
+func (e LiteralLong) ToField(input LogicalPlan) (arrow.Field, error) {
 	return arrow.Field{
 		Name: fmt.Sprintf("%d", e.N),
 		Type: datatypes.Int64Type,
-	}
+	}, nil
 }
 
 func (e LiteralLong) String() string {
@@ -88,28 +116,281 @@ func NewLiteralFloat(n float32) LiteralFloat {
 	return LiteralFloat{n}
 }
 
-func (e LiteralFloat) ToField(input LogicalPlan) arrow.Field {
+func (e LiteralFloat) ToField(input LogicalPlan) (arrow.Field, error) {
 	return arrow.Field{
 		Name: fmt.Sprintf("%g", e.N),
 		Type: datatypes.FloatType,
-	}
+	}, nil
 }
 
 func (e LiteralFloat) String() string {
 	return fmt.Sprintf("%g", e.N)
 }
 
+type LiteralDouble struct {
+	N float64
+}
+
+func NewLiteralDouble(n float64) LiteralDouble {
+	return LiteralDouble{n}
+}
+
+func (e LiteralDouble) ToField(input LogicalPlan) (arrow.Field, error) {
+	return arrow.Field{
+		Name: e.String(),
+		Type: datatypes.DoubleType,
+	}, nil
+}
+
+func (e LiteralDouble) String() string {
+	return fmt.Sprintf("%g", e.N)
+}
+
+type LiteralBoolean struct {
+	B bool
+}
+
+func NewLiteralBoolean(b bool) LiteralBoolean {
+	return LiteralBoolean{b}
+}
+
+func (e LiteralBoolean) ToField(input LogicalPlan) (arrow.Field, error) {
+	return arrow.Field{
+		Name: e.String(),
+		Type: datatypes.BooleanType,
+	}, nil
+}
+
+func (e LiteralBoolean) String() string {
+	return fmt.Sprintf("%t", e.B)
+}
+
+// ================== Unary Expressions
+
+// Not negates a boolean expression
+type Not struct {
+	Expr LogicalExpr
+}
+
+func NewNot(expr LogicalExpr) Not {
+	return Not{expr}
+}
+
+func (e Not) ToField(input LogicalPlan) (arrow.Field, error) {
+	return arrow.Field{
+		Name: e.String(),
+		Type: datatypes.BooleanType,
+	}, nil
+}
+
+func (e Not) String() string {
+	// NOT binds looser than comparisons but tighter than AND and OR, `NOT (a AND b)`
+	if op, ok := exprOperator(e.Expr); ok && binaryPrecedence[op] < binaryPrecedence["NOT"] {
+		return fmt.Sprintf("NOT (%s)", e.Expr)
+	}
+	return fmt.Sprintf("NOT %s", e.Expr)
+}
+
+var _ LogicalExpr = (*Not)(nil)
+
+// Alias names the output column of an expression, `salary * 2 AS double_salary`
+type Alias struct {
+	Expr  LogicalExpr
+	Alias string
+}
+
+func NewAlias(expr LogicalExpr, alias string) Alias {
+	return Alias{expr, alias}
+}
+
+func (e Alias) ToField(input LogicalPlan) (arrow.Field, error) {
+	field, err := e.Expr.ToField(input)
+	if err != nil {
+		return arrow.Field{}, err
+	}
+	field.Name = e.Alias
+	return field, nil
+}
+
+func (e Alias) String() string {
+	return fmt.Sprintf("%s AS %s", e.Expr, e.Alias)
+}
+
+var _ LogicalExpr = (*Alias)(nil)
+
+// Cast converts the value of an expression to another type, `CAST(salary AS double)`. A value
+// which can't be converted fails the query unless the cast is lenient, which produces a null
+type Cast struct {
+	Expr    LogicalExpr
+	Type    arrow.DataType
+	Lenient bool
+}
+
+func NewCast(expr LogicalExpr, dataType arrow.DataType) Cast {
+	return Cast{expr, dataType, false}
+}
+
+func NewTryCast(expr LogicalExpr, dataType arrow.DataType) Cast {
+	return Cast{expr, dataType, true}
+}
+
+func (e Cast) ToField(input LogicalPlan) (arrow.Field, error) {
+	field, err := e.Expr.ToField(input)
+	if err != nil {
+		return arrow.Field{}, err
+	}
+	return arrow.Field{
+		Name:     e.String(),
+		Type:     e.Type,
+		Nullable: field.Nullable || e.Lenient,
+	}, nil
+}
+
+func (e Cast) String() string {
+	if e.Lenient {
+		return fmt.Sprintf("TRY_CAST(%s AS %s)", e.Expr, e.Type)
+	}
+	return fmt.Sprintf("CAST(%s AS %s)", e.Expr, e.Type)
+}
+
+var _ LogicalExpr = (*Cast)(nil)
+
+// ================== Subquery Expressions
+
+// InSubquery is true when Expr is equal to a value of the single column produced by Subquery,
+// `Expr IN (SELECT ...)`. Subqueries are rewritten into joins by the optimizer, they can only be
+// used as a conjunct of a filter
+type InSubquery struct {
+	Expr     LogicalExpr
+	Subquery LogicalPlan
+	Negated  bool
+}
+
+func NewInSubquery(expr LogicalExpr, subquery LogicalPlan, negated bool) InSubquery {
+	return InSubquery{expr, subquery, negated}
+}
+
+func (e InSubquery) ToField(input LogicalPlan) (arrow.Field, error) {
+	return arrow.Field{
+		Name: "in",
+		Type: datatypes.BooleanType,
+	}, nil
+}
+
+func (e InSubquery) String() string {
+	if e.Negated {
+		return fmt.Sprintf("%s NOT IN (%s)", e.Expr, e.Subquery)
+	}
+	return fmt.Sprintf("%s IN (%s)", e.Expr, e.Subquery)
+}
+
+var _ LogicalExpr = (*InSubquery)(nil)
+
+// Exists is true when Subquery produces at least one row, `EXISTS (SELECT ...)`. The subquery
+// may filter on columns of the outer query
+type Exists struct {
+	Subquery LogicalPlan
+	Negated  bool
+}
+
+func NewExists(subquery LogicalPlan, negated bool) Exists {
+	return Exists{subquery, negated}
+}
+
+func (e Exists) ToField(input LogicalPlan) (arrow.Field, error) {
+	return arrow.Field{
+		Name: "exists",
+		Type: datatypes.BooleanType,
+	}, nil
+}
+
+func (e Exists) String() string {
+	if e.Negated {
+		return fmt.Sprintf("NOT EXISTS (%s)", e.Subquery)
+	}
+	return fmt.Sprintf("EXISTS (%s)", e.Subquery)
+}
+
+var _ LogicalExpr = (*Exists)(nil)
+
+// OuterColumn references a column of the outer query from the filter of a correlated subquery,
+// `t.a` in `SELECT * FROM t WHERE EXISTS (SELECT * FROM u WHERE u.a = t.a)`. A Column of the
+// subquery which the subquery doesn't have is an outer reference too, OuterColumn is needed when
+// both queries have a column with the name
+type OuterColumn struct {
+	Name string
+}
+
+func NewOuterColumn(name string) OuterColumn {
+	return OuterColumn{name}
+}
+
+// ToField fails as the outer query isn't known to the subquery, outer columns are replaced by
+// columns when the optimizer rewrites the subquery into a join
+func (c OuterColumn) ToField(input LogicalPlan) (arrow.Field, error) {
+	return arrow.Field{}, fmt.Errorf("%w: outer column %s in %s", ErrColumnNotFound, c, input)
+}
+
+func (c OuterColumn) String() string {
+	return fmt.Sprintf("outer_ref(%s)", c.Name)
+}
+
+var _ LogicalExpr = (*OuterColumn)(nil)
+
 // ================== Binary Expressions
 
+// binaryPrecedence is how tightly each operator binds, it decides which operands are parenthesized
+var binaryPrecedence = map[string]int{
+	"OR":  1,
+	"&":   2,
+	"NOT": 3,
+	"=":   4, "!=": 4, "<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+// BinaryExpr is the operator and operands shared by boolean and math expressions, the output
+// column of a binary expression is named after its text, `salary * 2`
 type BinaryExpr struct {
-	name string
-	op   string
-	l    LogicalExpr
-	r    LogicalExpr
+	Name string
+	Op   string
+	L    LogicalExpr
+	R    LogicalExpr
 }
 
 func (e BinaryExpr) String() string {
-	return fmt.Sprintf("%s %s %s", e.l, e.op, e.r)
+	return fmt.Sprintf("%s %s %s", e.operandString(e.L, false), e.Op, e.operandString(e.R, true))
+}
+
+// operandString parenthesizes the operands which would otherwise be read with a different
+// grouping, `(a + b) * c` and `a - (b - c)`
+func (e BinaryExpr) operandString(operand LogicalExpr, right bool) string {
+	op, ok := exprOperator(operand)
+	if !ok {
+		return operand.String()
+	}
+
+	outer, nested := binaryPrecedence[e.Op], binaryPrecedence[op]
+	associative := op == e.Op && slices.Contains([]string{"OR", "&", "+", "*"}, e.Op)
+	if nested < outer || (right && nested == outer && !associative) {
+		return fmt.Sprintf("(%s)", operand)
+	}
+	return operand.String()
+}
+
+// exprOperator returns the operator of binary and NOT expressions, the text of other
+// expressions is never regrouped by the operators around it
+func exprOperator(expr LogicalExpr) (string, bool) {
+	switch e := expr.(type) {
+	case BooleanBinaryExpr:
+		return e.Op, true
+	case MathExpr:
+		return e.Op, true
+	case Not:
+		return "NOT", true
+	default:
+		return "", false
+	}
 }
 
 type BooleanBinaryExpr struct {
@@ -118,11 +399,11 @@ type BooleanBinaryExpr struct {
 
 var _ LogicalExpr = (*BooleanBinaryExpr)(nil)
 
-func (e BooleanBinaryExpr) ToField(lp LogicalPlan) arrow.Field {
+func (e BooleanBinaryExpr) ToField(lp LogicalPlan) (arrow.Field, error) {
 	return arrow.Field{
-		Name: e.name,
+		Name: e.String(),
 		Type: datatypes.BooleanType,
-	}
+	}, nil
 }
 
 func NewEqExpr(l, r LogicalExpr) BooleanBinaryExpr {
@@ -142,7 +423,7 @@ func NewLtExpr(l, r LogicalExpr) BooleanBinaryExpr {
 }
 
 func NewGtEqExpr(l, r LogicalExpr) BooleanBinaryExpr {
-	return BooleanBinaryExpr{BinaryExpr{"gt", ">=", l, r}}
+	return BooleanBinaryExpr{BinaryExpr{"gteq", ">=", l, r}}
 }
 
 func NewLtEqExpr(l, r LogicalExpr) BooleanBinaryExpr {
@@ -163,11 +444,27 @@ type MathExpr struct {
 	BinaryExpr
 }
 
-func (m MathExpr) ToField(input LogicalPlan) arrow.Field {
-	return arrow.Field{
-		Name: m.name,
-		Type: m.l.ToField(input).Type,
+// ToField has the type of the left operand, or the type both operands are widened to when they
+// are numbers of different types, see datatypes.WidenNumeric
+func (m MathExpr) ToField(input LogicalPlan) (arrow.Field, error) {
+	l, err := m.L.ToField(input)
+	if err != nil {
+		return arrow.Field{}, err
+	}
+
+	r, err := m.R.ToField(input)
+	if err != nil {
+		return arrow.Field{}, err
 	}
+
+	dt := l.Type
+	if widened, ok := datatypes.WidenNumeric(l.Type, r.Type); ok {
+		dt = widened
+	}
+	return arrow.Field{
+		Name: m.String(),
+		Type: dt,
+	}, nil
 }
 
 var _ LogicalExpr = (*MathExpr)(nil)
@@ -195,37 +492,58 @@ func NewMod(l, r LogicalExpr) MathExpr {
 // ================= Aggregate expressions
 
 type AggregateExpr struct {
-	name string
-	expr LogicalExpr
+	Name string
+	Expr LogicalExpr
+
+	// Distinct aggregates only take each distinct non null value of Expr into account once
+	Distinct bool
 }
 
-func (a AggregateExpr) ToField(input LogicalPlan) arrow.Field {
-	return arrow.Field{
-		Name: a.name,
-		Type: a.expr.ToField(input).Type,
+// The field is named after the aggregate and its input, `MAX(salary)`, so several aggregates
+// can be referenced by name from the plans above.
+//
+// COUNT always produces an int64 and AVG always a float64, every other aggregate
+// keeps the type of its input expression
+func (a AggregateExpr) ToField(input LogicalPlan) (arrow.Field, error) {
+	field := arrow.Field{Name: a.String()}
+	switch a.Name {
+	case "COUNT":
+		field.Type = datatypes.Int64Type
+	case "AVG":
+		field.Type = datatypes.DoubleType
+	default:
+		inputField, err := a.Expr.ToField(input)
+		if err != nil {
+			return arrow.Field{}, err
+		}
+		field.Type = inputField.Type
 	}
+	return field, nil
 }
 
 func (a AggregateExpr) String() string {
-	return fmt.Sprintf("%s(%s)", a.name, a.expr)
+	if a.Distinct {
+		return fmt.Sprintf("%s(DISTINCT %s)", a.Name, a.Expr)
+	}
+	return fmt.Sprintf("%s(%s)", a.Name, a.Expr)
 }
 
 var _ LogicalExpr = (*AggregateExpr)(nil)
 
 func NewSumExpr(input LogicalExpr) AggregateExpr {
-	return AggregateExpr{name: "SUM", expr: input}
+	return AggregateExpr{Name: "SUM", Expr: input}
 }
 
 func NewMinExpr(input LogicalExpr) AggregateExpr {
-	return AggregateExpr{name: "MIN", expr: input}
+	return AggregateExpr{Name: "MIN", Expr: input}
 }
 
 func NewMaxExpr(input LogicalExpr) AggregateExpr {
-	return AggregateExpr{name: "MAX", expr: input}
+	return AggregateExpr{Name: "MAX", Expr: input}
 }
 
 func NewAvgExpr(input LogicalExpr) AggregateExpr {
-	return AggregateExpr{name: "AVG", expr: input}
+	return AggregateExpr{Name: "AVG", Expr: input}
 }
 
 type AggregateCountExpr struct {
@@ -233,16 +551,21 @@ type AggregateCountExpr struct {
 }
 
 // COUNT expression always returns an int64 datatype field
-func (a AggregateCountExpr) ToField(_ LogicalPlan) arrow.Field {
-	return arrow.Field{Name: "COUNT", Type: datatypes.Int64Type}
+func (a AggregateCountExpr) ToField(_ LogicalPlan) (arrow.Field, error) {
+	return arrow.Field{Name: "COUNT", Type: datatypes.Int64Type}, nil
 }
 
 func (a AggregateCountExpr) String() string {
-	return fmt.Sprintf("COUNT(%s)", a.expr)
+	return fmt.Sprintf("COUNT(%s)", a.Expr)
 }
 
 var _ LogicalExpr = (*AggregateCountExpr)(nil)
 
 func NewAggregateCountExpr(input LogicalExpr) AggregateExpr {
-	return AggregateExpr{"COUNT", input}
+	return AggregateExpr{Name: "COUNT", Expr: input}
+}
+
+// NewAggregateCountDistinctExpr counts the distinct non null values of input, `COUNT(DISTINCT input)`
+func NewAggregateCountDistinctExpr(input LogicalExpr) AggregateExpr {
+	return AggregateExpr{Name: "COUNT", Expr: input, Distinct: true}
 }
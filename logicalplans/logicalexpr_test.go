@@ -0,0 +1,31 @@
+package logicalplans_test
+
+import (
+	"testing"
+
+	"github.com/fastbyt3/query-engine/logicalplans"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogicalExprString(t *testing.T) {
+	a, b, c := logicalplans.NewColumn("a"), logicalplans.NewColumn("b"), logicalplans.NewColumn("c")
+	tests := []struct {
+		expr     logicalplans.LogicalExpr
+		expected string
+	}{
+		{logicalplans.NewMult(logicalplans.NewAdd(a, b), c), "(a + b) * c"},
+		{logicalplans.NewSub(a, logicalplans.NewSub(b, c)), "a - (b - c)"},
+		{logicalplans.NewAdd(a, logicalplans.NewAdd(b, c)), "a + b + c"},
+		{logicalplans.NewNot(logicalplans.NewGtExpr(a, b)), "NOT a > b"},
+		{logicalplans.NewNot(logicalplans.NewAndExpr(a, b)), "NOT (a & b)"},
+		{logicalplans.NewNot(logicalplans.NewOrExpr(a, b)), "NOT (a OR b)"},
+		{logicalplans.NewNot(logicalplans.NewNot(a)), "NOT NOT a"},
+		{logicalplans.NewAndExpr(logicalplans.NewNot(a), b), "NOT a & b"},
+		{logicalplans.NewEqExpr(logicalplans.NewNot(a), b), "(NOT a) = b"},
+		{logicalplans.NewEqExpr(a, logicalplans.NewNot(b)), "a = (NOT b)"},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, test.expr.String())
+	}
+}
@@ -8,7 +8,8 @@ import (
 
 // LogicalPlan specifies what to do with data
 type LogicalPlan interface {
-	Schema() datatypes.Schema
+	// Schema fails when expressions of the plan can't be resolved against the schema of its input
+	Schema() (datatypes.Schema, error)
 	Children() []LogicalPlan
 	String() string
 }
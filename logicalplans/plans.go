@@ -2,6 +2,7 @@ package logicalplans
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/apache/arrow-go/v18/arrow"
@@ -17,17 +18,26 @@ type Scan struct {
 	Path        string
 	Datasource  datasources.DataSource
 	Projections []string
+
+	// Filters are applied by the datasource while scanning, only set when the
+	// datasource is a datasources.FilterableDataSource
+	Filters []datasources.Filter
 }
 
 func NewScan(path string, datasource datasources.DataSource, projections []string) Scan {
-	return Scan{path, datasource, projections}
+	return Scan{Path: path, Datasource: datasource, Projections: projections}
 }
 
 func (s Scan) String() string {
-	if len(s.Projections) == 0 {
-		return fmt.Sprintf("Scan: %s; path=None", s.Path)
+	str := fmt.Sprintf("Scan: %s; path=None", s.Path)
+	if len(s.Projections) > 0 {
+		str = fmt.Sprintf("Scan: %s; path=%s", s.Path, s.Projections)
+	}
+
+	if len(s.Filters) > 0 {
+		str += fmt.Sprintf("; filters=%v", s.Filters)
 	}
-	return fmt.Sprintf("Scan: %s; path=%s", s.Path, s.Projections)
+	return str
 }
 
 func (s *Scan) deriveSchema() datatypes.Schema {
@@ -40,12 +50,36 @@ func (s Scan) Children() []LogicalPlan {
 	return []LogicalPlan{}
 }
 
-func (s Scan) Schema() datatypes.Schema {
-	return s.deriveSchema()
+func (s Scan) Schema() (datatypes.Schema, error) {
+	return s.deriveSchema(), nil
 }
 
 var _ LogicalPlan = (*Scan)(nil)
 
+// EmptyRelation produces no rows, it replaces sub plans which are known to never produce any
+// row, for example a selection whose predicate is always false
+type EmptyRelation struct {
+	schema datatypes.Schema
+}
+
+func NewEmptyRelation(schema datatypes.Schema) EmptyRelation {
+	return EmptyRelation{schema}
+}
+
+func (e EmptyRelation) Children() []LogicalPlan {
+	return []LogicalPlan{}
+}
+
+func (e EmptyRelation) Schema() (datatypes.Schema, error) {
+	return e.schema, nil
+}
+
+func (e EmptyRelation) String() string {
+	return "EmptyRelation"
+}
+
+var _ LogicalPlan = (*EmptyRelation)(nil)
+
 // Projection applies a series of logical expressions to its input.
 //
 // Examples: `SELECT a, b, c FROM foo`
@@ -64,12 +98,21 @@ func (p Projection) Children() []LogicalPlan {
 	return []LogicalPlan{p.Input}
 }
 
-func (p Projection) Schema() datatypes.Schema {
+// Schema fails with ErrDuplicateColumn when two expressions produce the same column name, one
+// of them has to be renamed with an Alias
+func (p Projection) Schema() (datatypes.Schema, error) {
 	var fields []arrow.Field
 	for _, e := range p.Exprs {
-		fields = append(fields, e.ToField(p.Input))
+		field, err := e.ToField(p.Input)
+		if err != nil {
+			return datatypes.Schema{}, err
+		}
+		if slices.ContainsFunc(fields, func(f arrow.Field) bool { return f.Name == field.Name }) {
+			return datatypes.Schema{}, fmt.Errorf("%w: %q in %s", ErrDuplicateColumn, field.Name, p)
+		}
+		fields = append(fields, field)
 	}
-	return *datatypes.NewSchema(fields)
+	return *datatypes.NewSchema(fields), nil
 }
 
 // String implements LogicalPlan.
@@ -99,7 +142,7 @@ func (s Selection) Children() []LogicalPlan {
 	return []LogicalPlan{s.Input}
 }
 
-func (s Selection) Schema() datatypes.Schema {
+func (s Selection) Schema() (datatypes.Schema, error) {
 	return s.Input.Schema()
 }
 
@@ -126,20 +169,23 @@ func (a Aggregate) Children() []LogicalPlan {
 	return []LogicalPlan{a.Input}
 }
 
-func (a Aggregate) Schema() datatypes.Schema {
+func (a Aggregate) Schema() (datatypes.Schema, error) {
 	fields := make([]arrow.Field, len(a.AggregateExprs)+len(a.GroupExprs))
 
+	var err error
 	for i, e := range a.GroupExprs {
-		fields[i] = e.ToField(a.Input)
+		if fields[i], err = e.ToField(a.Input); err != nil {
+			return datatypes.Schema{}, err
+		}
 	}
 
-	j := 0
-	for i := len(a.GroupExprs); i < len(a.AggregateExprs); i++ {
-		fields[i] = a.AggregateExprs[j].ToField(a.Input)
-		j++
+	for i, e := range a.AggregateExprs {
+		if fields[len(a.GroupExprs)+i], err = e.ToField(a.Input); err != nil {
+			return datatypes.Schema{}, err
+		}
 	}
 
-	return *datatypes.NewSchema(fields)
+	return *datatypes.NewSchema(fields), nil
 }
 
 func (a Aggregate) String() string {
@@ -147,3 +193,248 @@ func (a Aggregate) String() string {
 }
 
 var _ LogicalPlan = (*Aggregate)(nil)
+
+type JoinType int
+
+const (
+	InnerJoin JoinType = iota
+	// LeftJoin keeps every row of the left input, rows without a match are padded with nulls
+	LeftJoin
+	// RightJoin keeps every row of the right input, rows without a match are padded with nulls
+	RightJoin
+	// FullJoin keeps every row of both inputs
+	FullJoin
+	// LeftSemiJoin keeps the rows of the left input which have a match, only the left
+	// fields are produced
+	LeftSemiJoin
+	// LeftAntiJoin keeps the rows of the left input which have no match, only the left
+	// fields are produced
+	LeftAntiJoin
+)
+
+func (j JoinType) String() string {
+	switch j {
+	case InnerJoin:
+		return "INNER"
+	case LeftJoin:
+		return "LEFT"
+	case RightJoin:
+		return "RIGHT"
+	case FullJoin:
+		return "FULL"
+	case LeftSemiJoin:
+		return "LEFT SEMI"
+	case LeftAntiJoin:
+		return "LEFT ANTI"
+	default:
+		return fmt.Sprintf("JoinType(%d)", int(j))
+	}
+}
+
+// JoinKey is an equality condition of a join, Left is evaluated against the left input and
+// Right against the right input
+type JoinKey struct {
+	Left  LogicalExpr
+	Right LogicalExpr
+}
+
+func (k JoinKey) String() string {
+	return fmt.Sprintf("%s = %s", k.Left, k.Right)
+}
+
+// Join combines the rows of two inputs whose join keys are equal and for which the optional
+// Filter is true, a null key never matches
+//
+// The Filter is evaluated against the fields of the left input followed by the fields of the
+// right input, which is also the output of the join except for semi and anti joins that only
+// produce the left fields.
+//
+// Fields of both inputs keep their name, a field name both inputs have can't be referenced by the
+// filter or above the join (see ErrDuplicateColumn) unless one of the inputs renames it first
+type Join struct {
+	Left     LogicalPlan
+	Right    LogicalPlan
+	JoinType JoinType
+	Keys     []JoinKey
+	Filter   LogicalExpr
+
+	// NullAware anti joins follow the semantics of `NOT IN`: no row is kept when the right
+	// input has a null key and left rows with a null key are only kept when the right input is empty
+	NullAware bool
+}
+
+func NewJoin(left, right LogicalPlan, joinType JoinType, keys []JoinKey, filter LogicalExpr) *Join {
+	return &Join{Left: left, Right: right, JoinType: joinType, Keys: keys, Filter: filter}
+}
+
+func (j Join) Children() []LogicalPlan {
+	return []LogicalPlan{j.Left, j.Right}
+}
+
+func (j Join) Schema() (datatypes.Schema, error) {
+	left, err := j.Left.Schema()
+	if err != nil {
+		return datatypes.Schema{}, err
+	}
+
+	if j.JoinType == LeftSemiJoin || j.JoinType == LeftAntiJoin {
+		return left, nil
+	}
+
+	right, err := j.Right.Schema()
+	if err != nil {
+		return datatypes.Schema{}, err
+	}
+
+	// the side which can be padded with nulls becomes nullable
+	fields := append(
+		joinFields(left, j.JoinType == RightJoin || j.JoinType == FullJoin),
+		joinFields(right, j.JoinType == LeftJoin || j.JoinType == FullJoin)...,
+	)
+	return *datatypes.NewSchema(fields), nil
+}
+
+func joinFields(schema datatypes.Schema, nullable bool) []arrow.Field {
+	fields := slices.Clone(schema.Fields())
+	if nullable {
+		for i := range fields {
+			fields[i].Nullable = true
+		}
+	}
+	return fields
+}
+
+// JoinedRows returns a join producing the fields of both inputs, that's the plan the Filter is
+// evaluated against
+func (j Join) JoinedRows() *Join {
+	return NewJoin(j.Left, j.Right, InnerJoin, j.Keys, nil)
+}
+
+func (j Join) String() string {
+	joinType := j.JoinType.String()
+	if j.NullAware {
+		joinType += " NULL AWARE"
+	}
+
+	if j.Filter != nil {
+		return fmt.Sprintf("Join: type=%s, on=%v, filter=%s", joinType, j.Keys, j.Filter)
+	}
+	return fmt.Sprintf("Join: type=%s, on=%v", joinType, j.Keys)
+}
+
+var _ LogicalPlan = (*Join)(nil)
+
+// SortExpr orders rows by the value of Expr, nulls are placed before or after every other value
+// depending on NullsFirst whatever the direction
+type SortExpr struct {
+	Expr       LogicalExpr
+	Asc        bool
+	NullsFirst bool
+}
+
+// NewSortExpr orders by expr with nulls sorting like the largest value, that is last in
+// ascending order and first in descending order
+func NewSortExpr(expr LogicalExpr, asc bool) SortExpr {
+	return SortExpr{Expr: expr, Asc: asc, NullsFirst: !asc}
+}
+
+func (s SortExpr) String() string {
+	direction, nulls := "ASC", "NULLS LAST"
+	if !s.Asc {
+		direction = "DESC"
+	}
+	if s.NullsFirst {
+		nulls = "NULLS FIRST"
+	}
+	return fmt.Sprintf("%s %s %s", s.Expr, direction, nulls)
+}
+
+// Sort orders the rows of its input by the first sort expression, ties are broken by the
+// following ones
+//
+// In SQL, this is the `ORDER BY` clause
+type Sort struct {
+	Input LogicalPlan
+	Exprs []SortExpr
+
+	// Fetch is the number of sorted rows which are produced, the rest are dropped. A negative
+	// Fetch, which NewSort sets, produces every row
+	Fetch int
+}
+
+func NewSort(input LogicalPlan, exprs []SortExpr) *Sort {
+	return &Sort{Input: input, Exprs: exprs, Fetch: -1}
+}
+
+func (s Sort) Children() []LogicalPlan {
+	return []LogicalPlan{s.Input}
+}
+
+func (s Sort) Schema() (datatypes.Schema, error) {
+	return s.Input.Schema()
+}
+
+func (s Sort) String() string {
+	if s.Fetch >= 0 {
+		return fmt.Sprintf("Sort: %v, fetch=%d", s.Exprs, s.Fetch)
+	}
+	return fmt.Sprintf("Sort: %v", s.Exprs)
+}
+
+var _ LogicalPlan = (*Sort)(nil)
+
+// Limit skips the first Skip rows of its input and produces at most Fetch of the following rows,
+// a negative Fetch produces all of them
+//
+// In SQL, this is the `LIMIT` and `OFFSET` clauses
+type Limit struct {
+	Input LogicalPlan
+	Skip  int
+	Fetch int
+}
+
+func NewLimit(input LogicalPlan, skip, fetch int) *Limit {
+	return &Limit{input, skip, fetch}
+}
+
+func (l Limit) Children() []LogicalPlan {
+	return []LogicalPlan{l.Input}
+}
+
+func (l Limit) Schema() (datatypes.Schema, error) {
+	return l.Input.Schema()
+}
+
+func (l Limit) String() string {
+	if l.Fetch < 0 {
+		return fmt.Sprintf("Limit: skip=%d, fetch=None", l.Skip)
+	}
+	return fmt.Sprintf("Limit: skip=%d, fetch=%d", l.Skip, l.Fetch)
+}
+
+var _ LogicalPlan = (*Limit)(nil)
+
+// Distinct removes duplicate rows of its input, the first occurrence of every row is kept
+//
+// In SQL, this is `SELECT DISTINCT`
+type Distinct struct {
+	Input LogicalPlan
+}
+
+func NewDistinct(input LogicalPlan) *Distinct {
+	return &Distinct{input}
+}
+
+func (d Distinct) Children() []LogicalPlan {
+	return []LogicalPlan{d.Input}
+}
+
+func (d Distinct) Schema() (datatypes.Schema, error) {
+	return d.Input.Schema()
+}
+
+func (d Distinct) String() string {
+	return "Distinct"
+}
+
+var _ LogicalPlan = (*Distinct)(nil)
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+// Metrics are named counters updated while a query runs, they are safe for concurrent use.
+//
+// Operators find the metrics of their query in the context they execute with, see NewContext.
+// All the methods accept nil metrics, so queries which don't collect metrics cost nothing
+type Metrics struct {
+	mu       sync.Mutex
+	counters map[string]int64
+}
+
+func New() *Metrics {
+	return &Metrics{counters: make(map[string]int64)}
+}
+
+// Add increases the counter name by delta
+func (m *Metrics) Add(name string, delta int64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name] += delta
+}
+
+// Get returns the value of the counter name, zero when it was never increased
+func (m *Metrics) Get(name string) int64 {
+	if m == nil {
+		return 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters[name]
+}
+
+// Counters returns a copy of all the counters
+func (m *Metrics) Counters() map[string]int64 {
+	if m == nil {
+		return map[string]int64{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return maps.Clone(m.counters)
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying m, the queries executed with it update m
+func NewContext(ctx context.Context, m *Metrics) context.Context {
+	return context.WithValue(ctx, contextKey{}, m)
+}
+
+// FromContext returns the metrics carried by ctx, nil when there are none
+func FromContext(ctx context.Context) *Metrics {
+	m, _ := ctx.Value(contextKey{}).(*Metrics)
+	return m
+}
@@ -0,0 +1,43 @@
+package metrics_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/fastbyt3/query-engine/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				m.Add("rows", 1)
+			}
+		}()
+	}
+	wg.Wait()
+	m.Add("batches", 2)
+
+	require.Equal(t, int64(400), m.Get("rows"))
+	require.Equal(t, int64(0), m.Get("unknown"))
+	require.Equal(t, map[string]int64{"rows": 400, "batches": 2}, m.Counters())
+
+	ctx := metrics.NewContext(context.Background(), m)
+	require.Same(t, m, metrics.FromContext(ctx))
+}
+
+func TestMetricsWithoutContext(t *testing.T) {
+	m := metrics.FromContext(context.Background())
+	require.Nil(t, m)
+
+	// nil metrics ignore the counters
+	m.Add("rows", 1)
+	require.Equal(t, int64(0), m.Get("rows"))
+	require.Empty(t, m.Counters())
+}
@@ -0,0 +1,128 @@
+package optimizer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fastbyt3/query-engine/logicalplans"
+)
+
+// ErrUnsupportedExpr is returned when a rewrite helper doesn't know how to rebuild a logical expression
+var ErrUnsupportedExpr = errors.New("unsupported logical expression")
+
+// ExprTransformFunc rewrites a single expression node and reports whether the returned expression
+// differs from the input
+type ExprTransformFunc func(expr logicalplans.LogicalExpr) (logicalplans.LogicalExpr, bool, error)
+
+// ExprChildren returns the direct sub expressions of expr
+func ExprChildren(expr logicalplans.LogicalExpr) []logicalplans.LogicalExpr {
+	switch e := expr.(type) {
+	case logicalplans.Not:
+		return []logicalplans.LogicalExpr{e.Expr}
+	case logicalplans.Alias:
+		return []logicalplans.LogicalExpr{e.Expr}
+	case logicalplans.Cast:
+		return []logicalplans.LogicalExpr{e.Expr}
+	case logicalplans.InSubquery:
+		return []logicalplans.LogicalExpr{e.Expr}
+	case logicalplans.BooleanBinaryExpr:
+		return []logicalplans.LogicalExpr{e.L, e.R}
+	case logicalplans.MathExpr:
+		return []logicalplans.LogicalExpr{e.L, e.R}
+	case logicalplans.AggregateExpr:
+		return []logicalplans.LogicalExpr{e.Expr}
+	case logicalplans.AggregateCountExpr:
+		return []logicalplans.LogicalExpr{e.Expr}
+	default:
+		return []logicalplans.LogicalExpr{}
+	}
+}
+
+// ExprWithNewChildren returns a copy of expr with its sub expressions replaced by children
+func ExprWithNewChildren(expr logicalplans.LogicalExpr, children []logicalplans.LogicalExpr) (logicalplans.LogicalExpr, error) {
+	if len(children) != len(ExprChildren(expr)) {
+		return nil, fmt.Errorf("%s expects %d children, got %d", expr, len(ExprChildren(expr)), len(children))
+	}
+
+	switch e := expr.(type) {
+	case logicalplans.Column, logicalplans.LiteralString, logicalplans.LiteralLong, logicalplans.LiteralFloat, logicalplans.LiteralDouble,
+		logicalplans.LiteralBoolean, logicalplans.Exists, logicalplans.OuterColumn:
+		return e, nil
+	case logicalplans.Not:
+		e.Expr = children[0]
+		return e, nil
+	case logicalplans.Alias:
+		e.Expr = children[0]
+		return e, nil
+	case logicalplans.Cast:
+		e.Expr = children[0]
+		return e, nil
+	case logicalplans.InSubquery:
+		e.Expr = children[0]
+		return e, nil
+	case logicalplans.BooleanBinaryExpr:
+		e.L, e.R = children[0], children[1]
+		return e, nil
+	case logicalplans.MathExpr:
+		e.L, e.R = children[0], children[1]
+		return e, nil
+	case logicalplans.AggregateExpr:
+		e.Expr = children[0]
+		return e, nil
+	case logicalplans.AggregateCountExpr:
+		e.Expr = children[0]
+		return e, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedExpr, expr)
+	}
+}
+
+// TransformExprUp applies fn to every node of the expression tree, sub expressions first
+func TransformExprUp(expr logicalplans.LogicalExpr, fn ExprTransformFunc) (logicalplans.LogicalExpr, bool, error) {
+	children := ExprChildren(expr)
+	if len(children) > 0 {
+		newChildren := make([]logicalplans.LogicalExpr, len(children))
+		childrenChanged := false
+		for i, child := range children {
+			newChild, changed, err := TransformExprUp(child, fn)
+			if err != nil {
+				return nil, false, err
+			}
+			newChildren[i] = newChild
+			childrenChanged = childrenChanged || changed
+		}
+
+		if childrenChanged {
+			newExpr, err := ExprWithNewChildren(expr, newChildren)
+			if err != nil {
+				return nil, false, err
+			}
+
+			newExpr, _, err = fn(newExpr)
+			if err != nil {
+				return nil, false, err
+			}
+			return newExpr, true, nil
+		}
+	}
+
+	return fn(expr)
+}
+
+// ExprColumns returns the names of all the columns referenced by expr, in order of appearance
+func ExprColumns(expr logicalplans.LogicalExpr) []string {
+	var columns []string
+	collectColumns(expr, &columns)
+	return columns
+}
+
+func collectColumns(expr logicalplans.LogicalExpr, columns *[]string) {
+	if c, ok := expr.(logicalplans.Column); ok {
+		*columns = append(*columns, c.Name)
+		return
+	}
+
+	for _, child := range ExprChildren(expr) {
+		collectColumns(child, columns)
+	}
+}
@@ -0,0 +1,141 @@
+package optimizer
+
+import (
+	"slices"
+
+	"github.com/fastbyt3/query-engine/datasources"
+	"github.com/fastbyt3/query-engine/logicalplans"
+)
+
+// FilterPushdown hands the `column <op> literal` conjuncts of a selection directly above a Scan to
+// its datasource, when the datasource is a datasources.FilterableDataSource.
+//
+// Conjuncts the datasource applies exactly are removed from the selection, inexact ones are kept
+// so they are still evaluated on the scanned rows
+type FilterPushdown struct{}
+
+func (r FilterPushdown) Name() string {
+	return "filter_pushdown"
+}
+
+func (r FilterPushdown) Optimize(plan logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+	return TransformUp(plan, func(p logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+		selection, ok := p.(*logicalplans.Selection)
+		if !ok {
+			return p, false, nil
+		}
+
+		scan, ok := selection.Input.(logicalplans.Scan)
+		if !ok {
+			return p, false, nil
+		}
+
+		ds, ok := scan.Datasource.(datasources.FilterableDataSource)
+		if !ok {
+			return p, false, nil
+		}
+
+		predicates := SplitConjunction(selection.Expr)
+		filters := make([]datasources.Filter, len(predicates))
+		convertible := make([]bool, len(predicates))
+		var candidates []datasources.Filter
+		for i, predicate := range predicates {
+			filters[i], convertible[i] = toFilter(predicate)
+			if convertible[i] {
+				candidates = append(candidates, filters[i])
+			}
+		}
+
+		if len(candidates) == 0 {
+			return p, false, nil
+		}
+
+		support := ds.SupportsFilters(candidates)
+
+		changed := false
+		var kept []logicalplans.LogicalExpr
+		candidateIdx := 0
+		for i, predicate := range predicates {
+			if !convertible[i] {
+				kept = append(kept, predicate)
+				continue
+			}
+
+			filterSupport := support[candidateIdx]
+			candidateIdx++
+
+			if filterSupport != datasources.FilterExact {
+				kept = append(kept, predicate)
+			}
+
+			if filterSupport != datasources.FilterUnsupported && !slices.Contains(scan.Filters, filters[i]) {
+				scan.Filters = append(slices.Clone(scan.Filters), filters[i])
+				changed = true
+			}
+		}
+
+		if len(kept) == 0 {
+			return scan, true, nil
+		}
+
+		if !changed {
+			return p, false, nil
+		}
+
+		return logicalplans.NewSelection(scan, Conjunction(kept)), true, nil
+	})
+}
+
+// mirrored comparison operators, used when the literal is on the left hand side
+var flippedOps = map[string]string{
+	"=":  "=",
+	"!=": "!=",
+	"<":  ">",
+	"<=": ">=",
+	">":  "<",
+	">=": "<=",
+}
+
+// toFilter converts a `column <op> literal` (or `literal <op> column`) comparison into a Filter
+func toFilter(expr logicalplans.LogicalExpr) (datasources.Filter, bool) {
+	comparison, ok := expr.(logicalplans.BooleanBinaryExpr)
+	if !ok {
+		return datasources.Filter{}, false
+	}
+
+	flipped, ok := flippedOps[comparison.Op]
+	if !ok {
+		return datasources.Filter{}, false
+	}
+
+	if column, ok := comparison.L.(logicalplans.Column); ok {
+		if value, ok := literalValue(comparison.R); ok {
+			return datasources.Filter{Column: column.Name, Op: comparison.Op, Value: value}, true
+		}
+	}
+
+	if column, ok := comparison.R.(logicalplans.Column); ok {
+		if value, ok := literalValue(comparison.L); ok {
+			return datasources.Filter{Column: column.Name, Op: flipped, Value: value}, true
+		}
+	}
+
+	return datasources.Filter{}, false
+}
+
+func literalValue(expr logicalplans.LogicalExpr) (any, bool) {
+	switch e := expr.(type) {
+	case logicalplans.LiteralString:
+		return e.Str, true
+	case logicalplans.LiteralLong:
+		return e.N, true
+	case logicalplans.LiteralFloat:
+		return e.N, true
+	case logicalplans.LiteralDouble:
+		return e.N, true
+	case logicalplans.LiteralBoolean:
+		return e.B, true
+	default:
+		return nil, false
+	}
+}
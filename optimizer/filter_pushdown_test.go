@@ -0,0 +1,65 @@
+package optimizer_test
+
+import (
+	"testing"
+
+	"github.com/fastbyt3/query-engine/datasources"
+	"github.com/fastbyt3/query-engine/logicalplans"
+	"github.com/fastbyt3/query-engine/optimizer"
+	"github.com/stretchr/testify/require"
+)
+
+const EMPLOYEE_CSV_FILE = "../test-data/employee.csv"
+
+func employeeCSVScan(t *testing.T) logicalplans.Scan {
+	ds, err := datasources.NewCSVDatasource(EMPLOYEE_CSV_FILE, 10, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+	return logicalplans.NewScan("employee.csv", ds, []string{})
+}
+
+func TestFilterPushdown(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeCSVScan(t)).
+		Filter(logicalplans.NewAndExpr(
+			logicalplans.NewEqExpr(logicalplans.NewLiteralString("CO"), logicalplans.NewColumn("state")),
+			logicalplans.NewGtExpr(logicalplans.NewColumn("id"), logicalplans.NewColumn("salary")),
+		)).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Filter: id > salary\n  Scan: employee.csv; path=None; filters=[state = 'CO']\n",
+		optimizeWith(t, plan, optimizer.FilterPushdown{}),
+	)
+}
+
+func TestFilterPushdownRemovesExactSelection(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeCSVScan(t)).
+		Filter(logicalplans.NewLtEqExpr(logicalplans.NewColumn("last_name"), logicalplans.NewLiteralString("M"))).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Scan: employee.csv; path=None; filters=[last_name <= 'M']\n",
+		optimizeWith(t, plan, optimizer.FilterPushdown{}),
+	)
+}
+
+func TestFilterPushdownKeepsUnsupportedFilters(t *testing.T) {
+	// salary is an int64 column, comparing it with a double is left to the selection
+	plan := logicalplans.NewDefaultDataframe(employeeCSVScan(t)).
+		Filter(logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralDouble(10.5))).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Filter: salary > 10.5\n  Scan: employee.csv; path=None\n",
+		optimizeWith(t, plan, optimizer.FilterPushdown{}),
+	)
+
+	// datasources which can't filter are left untouched
+	plan = logicalplans.NewDefaultDataframe(employeeScan()).
+		Filter(logicalplans.NewEqExpr(logicalplans.NewColumn("state"), logicalplans.NewLiteralString("CO"))).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Filter: state = 'CO'\n  Scan: employee; path=None\n",
+		optimizeWith(t, plan, optimizer.FilterPushdown{}),
+	)
+}
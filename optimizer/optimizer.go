@@ -0,0 +1,76 @@
+package optimizer
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/fastbyt3/query-engine/logicalplans"
+)
+
+// DefaultMaxPasses bounds the number of times the rules are applied when they keep changing the plan
+const DefaultMaxPasses = 16
+
+// OptimizerRule rewrites a logical plan into an equivalent (cheaper to execute) logical plan.
+//
+// Optimize must report whether the returned plan differs from the input, the optimizer keeps
+// re-applying its rules until none of them changes the plan
+type OptimizerRule interface {
+	Name() string
+	Optimize(plan logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error)
+}
+
+// Optimizer applies a list of rules, in order, until the plan reaches a fixed point
+type Optimizer struct {
+	rules     []OptimizerRule
+	MaxPasses int
+}
+
+func NewOptimizer(rules ...OptimizerRule) *Optimizer {
+	return &Optimizer{rules: rules, MaxPasses: DefaultMaxPasses}
+}
+
+// DefaultRules returns the rules applied to every query
+func DefaultRules() []OptimizerRule {
+	return []OptimizerRule{
+		SubqueryToJoin{},
+		SimplifyExpressions{},
+		PredicatePushdown{},
+		FilterPushdown{},
+		ProjectionPushdown{},
+		SortLimitFusion{},
+	}
+}
+
+// AddRule registers a rule which runs after all the previously registered rules
+func (o *Optimizer) AddRule(rule OptimizerRule) {
+	o.rules = append(o.rules, rule)
+}
+
+func (o *Optimizer) Rules() []OptimizerRule {
+	return o.rules
+}
+
+func (o *Optimizer) Optimize(plan logicalplans.LogicalPlan) (logicalplans.LogicalPlan, error) {
+	for pass := range o.MaxPasses {
+		passChanged := false
+		for _, rule := range o.rules {
+			newPlan, changed, err := rule.Optimize(plan)
+			if err != nil {
+				return nil, fmt.Errorf("optimizer rule %s: %w", rule.Name(), err)
+			}
+
+			if changed {
+				slog.Debug("optimizer rule changed plan", "rule", rule.Name(), "pass", pass)
+				plan = newPlan
+				passChanged = true
+			}
+		}
+
+		if !passChanged {
+			return plan, nil
+		}
+	}
+
+	slog.Debug("optimizer stopped before reaching a fixed point", "maxPasses", o.MaxPasses)
+	return plan, nil
+}
@@ -0,0 +1,128 @@
+package optimizer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/fastbyt3/query-engine/datasources"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/logicalplans"
+	"github.com/fastbyt3/query-engine/optimizer"
+	"github.com/stretchr/testify/require"
+)
+
+var employeeSchema = *datatypes.NewSchema([]arrow.Field{
+	{Name: "id", Type: datatypes.Int64Type},
+	{Name: "state", Type: datatypes.StringType},
+	{Name: "salary", Type: datatypes.Int64Type},
+})
+
+func employeeScan() logicalplans.Scan {
+	return logicalplans.NewScan("employee", datasources.NewMemoryDatasource(employeeSchema, nil), []string{})
+}
+
+// removeProjections drops every projection from the plan, one projection per call
+type removeProjections struct {
+	calls int
+}
+
+func (r *removeProjections) Name() string {
+	return "remove_projections"
+}
+
+func (r *removeProjections) Optimize(plan logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+	r.calls++
+	removed := false
+	return optimizer.TransformDown(plan, func(p logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+		if projection, ok := p.(*logicalplans.Projection); ok && !removed {
+			removed = true
+			return projection.Input, true, nil
+		}
+		return p, false, nil
+	})
+}
+
+type failingRule struct{}
+
+func (failingRule) Name() string {
+	return "failing"
+}
+
+func (failingRule) Optimize(plan logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+	return nil, false, errors.New("boom")
+}
+
+func TestOptimizerReachesFixedPoint(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("id"), logicalplans.NewColumn("salary")}).
+		Filter(logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(10))).
+		Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("id")}).
+		LogicalPlan()
+
+	rule := &removeProjections{}
+	optimized, err := optimizer.NewOptimizer(rule).Optimize(plan)
+	require.NoError(t, err)
+	require.Equal(t, "Filter: salary > 10\n  Scan: employee; path=None\n", logicalplans.PprintPlan(optimized, 2))
+	// two passes remove a projection each, the third pass doesn't change the plan
+	require.Equal(t, 3, rule.calls)
+}
+
+func TestOptimizerMaxPasses(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("id")}).
+		Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("id")}).
+		LogicalPlan()
+
+	rule := &removeProjections{}
+	opt := optimizer.NewOptimizer(rule)
+	opt.MaxPasses = 1
+	optimized, err := opt.Optimize(plan)
+	require.NoError(t, err)
+	require.Equal(t, 1, rule.calls)
+	require.IsType(t, &logicalplans.Projection{}, optimized)
+}
+
+func TestOptimizerRuleError(t *testing.T) {
+	_, err := optimizer.NewOptimizer(failingRule{}).Optimize(employeeScan())
+	require.ErrorContains(t, err, "optimizer rule failing: boom")
+}
+
+func TestTransformOrder(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Filter(logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(10))).
+		Aggregate(
+			[]logicalplans.LogicalExpr{logicalplans.NewColumn("state")},
+			[]logicalplans.AggregateExpr{logicalplans.NewMaxExpr(logicalplans.NewColumn("salary"))},
+		).
+		LogicalPlan()
+
+	visit := func(visited *[]string) optimizer.TransformFunc {
+		return func(p logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+			*visited = append(*visited, p.String()[:4])
+			return p, false, nil
+		}
+	}
+
+	var up, down []string
+	_, changed, err := optimizer.TransformUp(plan, visit(&up))
+	require.NoError(t, err)
+	require.False(t, changed)
+	_, _, err = optimizer.TransformDown(plan, visit(&down))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"Scan", "Filt", "Aggr"}, up)
+	require.Equal(t, []string{"Aggr", "Filt", "Scan"}, down)
+}
+
+func TestWithNewChildren(t *testing.T) {
+	selection := logicalplans.NewSelection(employeeScan(), logicalplans.NewEqExpr(logicalplans.NewColumn("state"), logicalplans.NewLiteralString("CO")))
+	other := logicalplans.NewScan("other", datasources.NewMemoryDatasource(employeeSchema, nil), []string{})
+
+	rebuilt, err := optimizer.WithNewChildren(selection, []logicalplans.LogicalPlan{other})
+	require.NoError(t, err)
+	require.Equal(t, "Filter: state = 'CO'\n  Scan: other; path=None\n", logicalplans.PprintPlan(rebuilt, 2))
+
+	_, err = optimizer.WithNewChildren(selection, []logicalplans.LogicalPlan{})
+	require.Error(t, err)
+}
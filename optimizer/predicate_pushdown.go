@@ -0,0 +1,233 @@
+package optimizer
+
+import (
+	"github.com/fastbyt3/query-engine/logicalplans"
+)
+
+// PredicatePushdown moves filters as close to the Scan as possible so fewer rows flow
+// through the operators above it:
+//
+//   - adjacent selections are merged into a single selection
+//   - selections are pushed below projections, references to projected expressions are
+//     replaced by the expressions themselves
+//   - conjuncts of a selection that only read GROUP BY columns are pushed below the aggregate
+//   - selections are pushed below distinct, which only compares whole rows
+//   - selections are pushed below sorts which keep all their rows, so fewer rows are sorted
+//   - conjuncts that only read the columns of one input of a join are pushed into that input
+//     when the join can't pad it with nulls
+type PredicatePushdown struct{}
+
+func (r PredicatePushdown) Name() string {
+	return "predicate_pushdown"
+}
+
+func (r PredicatePushdown) Optimize(plan logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+	return TransformDown(plan, func(p logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+		selection, ok := p.(*logicalplans.Selection)
+		if !ok {
+			return p, false, nil
+		}
+
+		switch input := selection.Input.(type) {
+		case *logicalplans.Selection:
+			predicates := append(SplitConjunction(selection.Expr), SplitConjunction(input.Expr)...)
+			return logicalplans.NewSelection(input.Input, Conjunction(predicates)), true, nil
+
+		case *logicalplans.Projection:
+			return r.pushBelowProjection(selection, input)
+
+		case *logicalplans.Aggregate:
+			return r.pushBelowAggregate(selection, input)
+
+		case *logicalplans.Distinct:
+			return logicalplans.NewDistinct(logicalplans.NewSelection(input.Input, selection.Expr)), true, nil
+
+		case *logicalplans.Sort:
+			// filtering before keeping the first rows of a sort would produce different rows
+			if input.Fetch >= 0 {
+				return p, false, nil
+			}
+			return logicalplans.NewSort(logicalplans.NewSelection(input.Input, selection.Expr), input.Exprs), true, nil
+
+		case *logicalplans.Join:
+			return r.pushBelowJoin(selection, input)
+
+		default:
+			return p, false, nil
+		}
+	})
+}
+
+func (r PredicatePushdown) pushBelowProjection(
+	selection *logicalplans.Selection,
+	projection *logicalplans.Projection,
+) (logicalplans.LogicalPlan, bool, error) {
+	// the first projected expression wins when names are duplicated, same as Column.ToField
+	projected := make(map[string]logicalplans.LogicalExpr, len(projection.Exprs))
+	for _, e := range projection.Exprs {
+		field, err := e.ToField(projection.Input)
+		if err != nil {
+			return nil, false, err
+		}
+		// the alias only names the projected column, the predicate reads the aliased expression
+		if alias, ok := e.(logicalplans.Alias); ok {
+			e = alias.Expr
+		}
+		if _, exists := projected[field.Name]; !exists {
+			projected[field.Name] = e
+		}
+	}
+
+	predicate, _, err := TransformExprUp(selection.Expr, func(e logicalplans.LogicalExpr) (logicalplans.LogicalExpr, bool, error) {
+		if c, ok := e.(logicalplans.Column); ok {
+			if expr, ok := projected[c.Name]; ok {
+				return expr, true, nil
+			}
+		}
+		return e, false, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return logicalplans.NewProjection(logicalplans.NewSelection(projection.Input, predicate), projection.Exprs), true, nil
+}
+
+func (r PredicatePushdown) pushBelowAggregate(
+	selection *logicalplans.Selection,
+	aggregate *logicalplans.Aggregate,
+) (logicalplans.LogicalPlan, bool, error) {
+	groupColumns := make(map[string]bool)
+	for _, e := range aggregate.GroupExprs {
+		if c, ok := e.(logicalplans.Column); ok {
+			groupColumns[c.Name] = true
+		}
+	}
+
+	var pushed, kept []logicalplans.LogicalExpr
+	for _, predicate := range SplitConjunction(selection.Expr) {
+		if onlyReads(predicate, groupColumns) {
+			pushed = append(pushed, predicate)
+		} else {
+			kept = append(kept, predicate)
+		}
+	}
+
+	if len(pushed) == 0 {
+		return selection, false, nil
+	}
+
+	var plan logicalplans.LogicalPlan = logicalplans.NewAggregate(
+		logicalplans.NewSelection(aggregate.Input, Conjunction(pushed)),
+		aggregate.GroupExprs,
+		aggregate.AggregateExprs,
+	)
+	if len(kept) > 0 {
+		plan = logicalplans.NewSelection(plan, Conjunction(kept))
+	}
+	return plan, true, nil
+}
+
+func (r PredicatePushdown) pushBelowJoin(
+	selection *logicalplans.Selection,
+	join *logicalplans.Join,
+) (logicalplans.LogicalPlan, bool, error) {
+	leftColumns, err := joinInputColumns(join.Left)
+	if err != nil {
+		return nil, false, err
+	}
+	rightColumns, err := joinInputColumns(join.Right)
+	if err != nil {
+		return nil, false, err
+	}
+	// a name both inputs have is ambiguous, Column.ToField reports it above the join
+	for name := range leftColumns {
+		if rightColumns[name] {
+			delete(leftColumns, name)
+			delete(rightColumns, name)
+		}
+	}
+
+	// filtering the rows of an input which is padded with nulls would pad more rows instead of
+	// removing them from the output
+	pushLeft := join.JoinType != logicalplans.RightJoin && join.JoinType != logicalplans.FullJoin
+	pushRight := join.JoinType == logicalplans.InnerJoin || join.JoinType == logicalplans.RightJoin
+
+	var left, right, kept []logicalplans.LogicalExpr
+	for _, predicate := range SplitConjunction(selection.Expr) {
+		switch {
+		case pushLeft && onlyReads(predicate, leftColumns):
+			left = append(left, predicate)
+		case pushRight && onlyReads(predicate, rightColumns):
+			right = append(right, predicate)
+		default:
+			kept = append(kept, predicate)
+		}
+	}
+
+	if len(left) == 0 && len(right) == 0 {
+		return selection, false, nil
+	}
+
+	leftInput, rightInput := join.Left, join.Right
+	if len(left) > 0 {
+		leftInput = logicalplans.NewSelection(leftInput, Conjunction(left))
+	}
+	if len(right) > 0 {
+		rightInput = logicalplans.NewSelection(rightInput, Conjunction(right))
+	}
+
+	pushed := logicalplans.NewJoin(leftInput, rightInput, join.JoinType, join.Keys, join.Filter)
+	pushed.NullAware = join.NullAware
+
+	var plan logicalplans.LogicalPlan = pushed
+	if len(kept) > 0 {
+		plan = logicalplans.NewSelection(plan, Conjunction(kept))
+	}
+	return plan, true, nil
+}
+
+func joinInputColumns(input logicalplans.LogicalPlan) (map[string]bool, error) {
+	schema, err := input.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]bool, schema.NumFields())
+	for _, f := range schema.Fields() {
+		columns[f.Name] = true
+	}
+	return columns, nil
+}
+
+// onlyReads reports whether expr references at least one column and all of them are in columns
+func onlyReads(expr logicalplans.LogicalExpr, columns map[string]bool) bool {
+	referenced := ExprColumns(expr)
+	if len(referenced) == 0 {
+		return false
+	}
+
+	for _, name := range referenced {
+		if !columns[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitConjunction flattens nested ANDs into the list of predicates that must all be true
+func SplitConjunction(expr logicalplans.LogicalExpr) []logicalplans.LogicalExpr {
+	if and, ok := expr.(logicalplans.BooleanBinaryExpr); ok && and.Op == "&" {
+		return append(SplitConjunction(and.L), SplitConjunction(and.R)...)
+	}
+	return []logicalplans.LogicalExpr{expr}
+}
+
+// Conjunction combines the predicates with AND, it is the inverse of SplitConjunction
+func Conjunction(predicates []logicalplans.LogicalExpr) logicalplans.LogicalExpr {
+	expr := predicates[0]
+	for _, p := range predicates[1:] {
+		expr = logicalplans.NewAndExpr(expr, p)
+	}
+	return expr
+}
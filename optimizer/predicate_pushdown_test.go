@@ -0,0 +1,189 @@
+package optimizer_test
+
+import (
+	"testing"
+
+	"github.com/fastbyt3/query-engine/logicalplans"
+	"github.com/fastbyt3/query-engine/optimizer"
+	"github.com/stretchr/testify/require"
+)
+
+func optimizeWith(t *testing.T, plan logicalplans.LogicalPlan, rules ...optimizer.OptimizerRule) string {
+	optimized, err := optimizer.NewOptimizer(rules...).Optimize(plan)
+	require.NoError(t, err)
+	return logicalplans.PprintPlan(optimized, 2)
+}
+
+func TestPredicatePushdownMergesSelections(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Filter(logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(10))).
+		Filter(logicalplans.NewEqExpr(logicalplans.NewColumn("state"), logicalplans.NewLiteralString("CO"))).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Filter: state = 'CO' & salary > 10\n  Scan: employee; path=None\n",
+		optimizeWith(t, plan, optimizer.PredicatePushdown{}),
+	)
+}
+
+func TestPredicatePushdownBelowProjection(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Project([]logicalplans.LogicalExpr{
+			logicalplans.NewColumn("id"),
+			logicalplans.NewMult(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(2)),
+		}).
+		Filter(logicalplans.NewGtExpr(logicalplans.NewColumn("salary * 2"), logicalplans.NewLiteralLong(10))).
+		Filter(logicalplans.NewGtExpr(logicalplans.NewColumn("id"), logicalplans.NewLiteralLong(3))).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Projection: id, salary * 2\n  Filter: id > 3 & salary * 2 > 10\n    Scan: employee; path=None\n",
+		optimizeWith(t, plan, optimizer.PredicatePushdown{}),
+	)
+}
+
+func TestPredicatePushdownBelowAlias(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Project([]logicalplans.LogicalExpr{
+			logicalplans.NewAlias(logicalplans.NewMult(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(2)), "double_salary"),
+		}).
+		Filter(logicalplans.NewGtExpr(logicalplans.NewColumn("double_salary"), logicalplans.NewLiteralLong(10))).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Projection: salary * 2 AS double_salary\n  Filter: salary * 2 > 10\n    Scan: employee; path=None\n",
+		optimizeWith(t, plan, optimizer.PredicatePushdown{}),
+	)
+}
+
+func TestPredicatePushdownBelowAggregate(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Aggregate(
+			[]logicalplans.LogicalExpr{logicalplans.NewColumn("state")},
+			[]logicalplans.AggregateExpr{logicalplans.NewMaxExpr(logicalplans.NewColumn("salary"))},
+		).
+		Filter(logicalplans.NewAndExpr(
+			logicalplans.NewEqExpr(logicalplans.NewColumn("state"), logicalplans.NewLiteralString("CO")),
+			logicalplans.NewGtExpr(logicalplans.NewColumn("MAX(salary)"), logicalplans.NewLiteralLong(10)),
+		)).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Filter: MAX(salary) > 10\n"+
+			"  Aggregate: groupExpr=[state], aggregateExprs=[MAX(salary)]\n"+
+			"    Filter: state = 'CO'\n"+
+			"      Scan: employee; path=None\n",
+		optimizeWith(t, plan, optimizer.PredicatePushdown{}),
+	)
+}
+
+func TestPredicatePushdownWithProjectionPushdown(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("id"), logicalplans.NewColumn("state")}).
+		Filter(logicalplans.NewEqExpr(logicalplans.NewColumn("state"), logicalplans.NewLiteralString("CO"))).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Projection: id, state\n  Filter: state = 'CO'\n    Scan: employee; path=[id state]\n",
+		optimizeWith(t, plan, optimizer.DefaultRules()...),
+	)
+}
+
+func TestPredicatePushdownBelowSort(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Sort(logicalplans.NewSortExpr(logicalplans.NewColumn("salary"), false)).
+		Filter(logicalplans.NewEqExpr(logicalplans.NewColumn("state"), logicalplans.NewLiteralString("CO"))).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Sort: [salary DESC NULLS FIRST]\n  Filter: state = 'CO'\n    Scan: employee; path=None\n",
+		optimizeWith(t, plan, optimizer.PredicatePushdown{}),
+	)
+}
+
+func TestPredicatePushdownBelowDistinct(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Distinct().
+		Filter(logicalplans.NewEqExpr(logicalplans.NewColumn("state"), logicalplans.NewLiteralString("CO"))).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Distinct\n  Filter: state = 'CO'\n    Scan: employee; path=None\n",
+		optimizeWith(t, plan, optimizer.PredicatePushdown{}),
+	)
+}
+
+func TestPredicatePushdownBelowJoin(t *testing.T) {
+	predicate := logicalplans.NewAndExpr(
+		logicalplans.NewAndExpr(
+			logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(10)),
+			logicalplans.NewGtExpr(logicalplans.NewColumn("min_salary"), logicalplans.NewLiteralLong(5)),
+		),
+		logicalplans.NewLtExpr(logicalplans.NewColumn("salary"), logicalplans.NewColumn("min_salary")),
+	)
+	keys := []logicalplans.JoinKey{{Left: logicalplans.NewColumn("state"), Right: logicalplans.NewColumn("code")}}
+
+	tests := []struct {
+		joinType logicalplans.JoinType
+		expected string
+	}{
+		{
+			joinType: logicalplans.InnerJoin,
+			expected: "Filter: salary < min_salary\n" +
+				"  Join: type=INNER, on=[state = code]\n" +
+				"    Filter: salary > 10\n" +
+				"      Scan: employee; path=None\n" +
+				"    Filter: min_salary > 5\n" +
+				"      Scan: states; path=None\n",
+		},
+		{
+			joinType: logicalplans.LeftJoin,
+			expected: "Filter: min_salary > 5 & salary < min_salary\n" +
+				"  Join: type=LEFT, on=[state = code]\n" +
+				"    Filter: salary > 10\n" +
+				"      Scan: employee; path=None\n" +
+				"    Scan: states; path=None\n",
+		},
+		{
+			joinType: logicalplans.RightJoin,
+			expected: "Filter: salary > 10 & salary < min_salary\n" +
+				"  Join: type=RIGHT, on=[state = code]\n" +
+				"    Scan: employee; path=None\n" +
+				"    Filter: min_salary > 5\n" +
+				"      Scan: states; path=None\n",
+		},
+		{
+			joinType: logicalplans.FullJoin,
+			expected: "Filter: salary > 10 & min_salary > 5 & salary < min_salary\n" +
+				"  Join: type=FULL, on=[state = code]\n" +
+				"    Scan: employee; path=None\n" +
+				"    Scan: states; path=None\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.joinType.String(), func(t *testing.T) {
+			plan := logicalplans.NewSelection(
+				logicalplans.NewJoin(employeeScan(), stateScan(), test.joinType, keys, nil),
+				predicate,
+			)
+			require.Equal(t, test.expected, optimizeWith(t, plan, optimizer.PredicatePushdown{}))
+		})
+	}
+}
+
+func TestPredicatePushdownBelowSemiJoin(t *testing.T) {
+	keys := []logicalplans.JoinKey{{Left: logicalplans.NewColumn("state"), Right: logicalplans.NewColumn("code")}}
+	plan := logicalplans.NewSelection(
+		logicalplans.NewJoin(employeeScan(), stateScan(), logicalplans.LeftSemiJoin, keys, nil),
+		logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(10)),
+	)
+
+	require.Equal(t,
+		"Join: type=LEFT SEMI, on=[state = code]\n"+
+			"  Filter: salary > 10\n"+
+			"    Scan: employee; path=None\n"+
+			"  Scan: states; path=None\n",
+		optimizeWith(t, plan, optimizer.PredicatePushdown{}),
+	)
+}
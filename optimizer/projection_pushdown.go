@@ -0,0 +1,141 @@
+package optimizer
+
+import (
+	"github.com/fastbyt3/query-engine/logicalplans"
+)
+
+// ProjectionPushdown rewrites every Scan to only read the columns referenced by the
+// projections, filters, sorts and aggregates above it.
+//
+// Column references stay name based, so the physical planner resolves them against the narrower
+// scan schema and picks up the new column indices on its own
+type ProjectionPushdown struct{}
+
+func (r ProjectionPushdown) Name() string {
+	return "projection_pushdown"
+}
+
+func (r ProjectionPushdown) Optimize(plan logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+	// every column produced by the root is part of the query result
+	return r.pushDown(plan, nil)
+}
+
+// pushDown rewrites plan knowing its parent only reads the `required` columns, a nil set
+// means every column of plan is needed
+func (r ProjectionPushdown) pushDown(plan logicalplans.LogicalPlan, required map[string]bool) (logicalplans.LogicalPlan, bool, error) {
+	switch p := plan.(type) {
+	case logicalplans.Scan:
+		return r.pushDownScan(p, required)
+
+	case *logicalplans.Projection:
+		return r.pushDownChildren(p, referencedColumns(p.Exprs...))
+
+	case *logicalplans.Selection:
+		if required == nil {
+			return r.pushDownChildren(p, nil)
+		}
+		inputRequired := referencedColumns(p.Expr)
+		for name := range required {
+			inputRequired[name] = true
+		}
+		return r.pushDownChildren(p, inputRequired)
+
+	case *logicalplans.Sort:
+		if required == nil {
+			return r.pushDownChildren(p, nil)
+		}
+		inputRequired := make(map[string]bool)
+		for _, e := range p.Exprs {
+			for _, name := range ExprColumns(e.Expr) {
+				inputRequired[name] = true
+			}
+		}
+		for name := range required {
+			inputRequired[name] = true
+		}
+		return r.pushDownChildren(p, inputRequired)
+
+	case *logicalplans.Limit:
+		// a limit doesn't read any column
+		return r.pushDownChildren(p, required)
+
+	case *logicalplans.Aggregate:
+		inputRequired := referencedColumns(p.GroupExprs...)
+		for _, e := range p.AggregateExprs {
+			for _, name := range ExprColumns(e) {
+				inputRequired[name] = true
+			}
+		}
+		return r.pushDownChildren(p, inputRequired)
+
+	case *logicalplans.Join:
+		if required == nil {
+			return r.pushDownChildren(p, nil)
+		}
+		// both inputs are handed the columns of either side, a scan only keeps the ones it has
+		inputRequired := referencedColumns(p.Filter)
+		for _, key := range p.Keys {
+			for _, name := range ExprColumns(key.Left) {
+				inputRequired[name] = true
+			}
+			for _, name := range ExprColumns(key.Right) {
+				inputRequired[name] = true
+			}
+		}
+		for name := range required {
+			inputRequired[name] = true
+		}
+		return r.pushDownChildren(p, inputRequired)
+
+	default:
+		// unknown plans might read any column of their inputs
+		return r.pushDownChildren(p, nil)
+	}
+}
+
+func (r ProjectionPushdown) pushDownChildren(plan logicalplans.LogicalPlan, required map[string]bool) (logicalplans.LogicalPlan, bool, error) {
+	return transformChildren(plan, func(child logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+		return r.pushDown(child, required)
+	})
+}
+
+func (r ProjectionPushdown) pushDownScan(scan logicalplans.Scan, required map[string]bool) (logicalplans.LogicalPlan, bool, error) {
+	if required == nil {
+		return scan, false, nil
+	}
+
+	schema, err := scan.Schema()
+	if err != nil {
+		return nil, false, err
+	}
+
+	// keep the column order of the datasource so scans are deterministic
+	var projection []string
+	for _, f := range schema.Fields() {
+		if required[f.Name] {
+			projection = append(projection, f.Name)
+		}
+	}
+
+	// a scan must produce at least one column to carry the number of rows
+	if len(projection) == 0 && schema.NumFields() > 0 {
+		projection = append(projection, schema.Field(0).Name)
+	}
+
+	if len(projection) == schema.NumFields() {
+		return scan, false, nil
+	}
+
+	scan.Projections = projection
+	return scan, true, nil
+}
+
+func referencedColumns(exprs ...logicalplans.LogicalExpr) map[string]bool {
+	columns := make(map[string]bool)
+	for _, e := range exprs {
+		for _, name := range ExprColumns(e) {
+			columns[name] = true
+		}
+	}
+	return columns
+}
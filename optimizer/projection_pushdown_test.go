@@ -0,0 +1,62 @@
+package optimizer_test
+
+import (
+	"testing"
+
+	"github.com/fastbyt3/query-engine/logicalplans"
+	"github.com/fastbyt3/query-engine/optimizer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectionPushdown(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Filter(logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(10))).
+		Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("id")}).
+		LogicalPlan()
+
+	optimized, changed, err := optimizer.ProjectionPushdown{}.Optimize(plan)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t,
+		"Projection: id\n  Filter: salary > 10\n    Scan: employee; path=[id salary]\n",
+		logicalplans.PprintPlan(optimized, 2),
+	)
+
+	// applying the rule again doesn't change the plan
+	_, changed, err = optimizer.ProjectionPushdown{}.Optimize(optimized)
+	require.NoError(t, err)
+	require.False(t, changed)
+}
+
+func TestProjectionPushdownAggregate(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Aggregate(
+			[]logicalplans.LogicalExpr{logicalplans.NewColumn("state")},
+			[]logicalplans.AggregateExpr{logicalplans.NewMaxExpr(logicalplans.NewColumn("salary"))},
+		).
+		LogicalPlan()
+
+	optimized, _, err := optimizer.ProjectionPushdown{}.Optimize(plan)
+	require.NoError(t, err)
+	require.Equal(t, "Scan: employee; path=[state salary]", optimized.Children()[0].String())
+}
+
+func TestProjectionPushdownKeepsAllColumns(t *testing.T) {
+	// a filter at the root needs every column of its input
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Filter(logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(10))).
+		LogicalPlan()
+
+	_, changed, err := optimizer.ProjectionPushdown{}.Optimize(plan)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	// a projection of literals still scans a single column to know the number of rows
+	plan = logicalplans.NewDefaultDataframe(employeeScan()).
+		Project([]logicalplans.LogicalExpr{logicalplans.NewLiteralLong(1)}).
+		LogicalPlan()
+
+	optimized, _, err := optimizer.ProjectionPushdown{}.Optimize(plan)
+	require.NoError(t, err)
+	require.Equal(t, "Scan: employee; path=[id]", optimized.Children()[0].String())
+}
@@ -0,0 +1,312 @@
+package optimizer
+
+import (
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/fastbyt3/query-engine/logicalplans"
+	"github.com/fastbyt3/query-engine/physicalplan/exprs"
+)
+
+// SimplifyExpressions rewrites the expressions of every plan so less work is done per row:
+//
+//   - sub expressions which only read literals are evaluated once, at planning time
+//   - boolean identities are applied, `x AND true` becomes `x`, `x OR true` becomes `true`,
+//     `NOT NOT x` becomes `x` and `x = x` becomes `true` when x is a non nullable column
+//   - selections which are always true are removed, selections which are always false are
+//     replaced by an EmptyRelation
+//
+// Projected and grouping expressions keep the name of their output column, a simplified
+// expression whose text changed is aliased to its original name
+type SimplifyExpressions struct{}
+
+func (r SimplifyExpressions) Name() string {
+	return "simplify_expressions"
+}
+
+func (r SimplifyExpressions) Optimize(plan logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+	return TransformUp(plan, func(p logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+		switch p := p.(type) {
+		case *logicalplans.Projection:
+			projected, changed, err := r.simplifyNamed(p.Exprs, p.Input)
+			if err != nil || !changed {
+				return p, false, err
+			}
+			return logicalplans.NewProjection(p.Input, projected), true, nil
+
+		case *logicalplans.Selection:
+			return r.simplifySelection(p)
+
+		case *logicalplans.Aggregate:
+			return r.simplifyAggregate(p)
+
+		default:
+			return p, false, nil
+		}
+	})
+}
+
+func (r SimplifyExpressions) simplifySelection(selection *logicalplans.Selection) (logicalplans.LogicalPlan, bool, error) {
+	predicate, changed, err := SimplifyExpr(selection.Expr, selection.Input)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if literal, ok := predicate.(logicalplans.LiteralBoolean); ok {
+		if literal.B {
+			return selection.Input, true, nil
+		}
+
+		schema, err := selection.Schema()
+		if err != nil {
+			return nil, false, err
+		}
+		return logicalplans.NewEmptyRelation(schema), true, nil
+	}
+
+	if !changed {
+		return selection, false, nil
+	}
+	return logicalplans.NewSelection(selection.Input, predicate), true, nil
+}
+
+func (r SimplifyExpressions) simplifyAggregate(aggregate *logicalplans.Aggregate) (logicalplans.LogicalPlan, bool, error) {
+	groupExprs, changed, err := r.simplifyNamed(aggregate.GroupExprs, aggregate.Input)
+	if err != nil {
+		return nil, false, err
+	}
+
+	aggregateExprs := make([]logicalplans.AggregateExpr, len(aggregate.AggregateExprs))
+	for i, e := range aggregate.AggregateExprs {
+		input, inputChanged, err := SimplifyExpr(e.Expr, aggregate.Input)
+		if err != nil {
+			return nil, false, err
+		}
+		e.Expr = input
+		aggregateExprs[i] = e
+		changed = changed || inputChanged
+	}
+
+	if !changed {
+		return aggregate, false, nil
+	}
+	return logicalplans.NewAggregate(aggregate.Input, groupExprs, aggregateExprs), true, nil
+}
+
+// simplifyNamed simplifies expressions producing output columns without renaming the columns
+func (r SimplifyExpressions) simplifyNamed(
+	exprList []logicalplans.LogicalExpr,
+	input logicalplans.LogicalPlan,
+) ([]logicalplans.LogicalExpr, bool, error) {
+	simplified := make([]logicalplans.LogicalExpr, len(exprList))
+	changed := false
+	for i, e := range exprList {
+		newExpr, exprChanged, err := SimplifyExpr(e, input)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if exprChanged {
+			before, err := e.ToField(input)
+			if err != nil {
+				return nil, false, err
+			}
+			after, err := newExpr.ToField(input)
+			if err != nil {
+				return nil, false, err
+			}
+
+			if before.Name != after.Name {
+				// the simplified expression keeps the name of the column it produces
+				newExpr = logicalplans.NewAlias(newExpr, before.Name)
+			}
+		}
+
+		simplified[i] = newExpr
+		changed = changed || exprChanged
+	}
+	return simplified, changed, nil
+}
+
+// SimplifyExpr folds the literal only sub expressions of expr and applies boolean identities,
+// input is the plan expr is evaluated against
+func SimplifyExpr(expr logicalplans.LogicalExpr, input logicalplans.LogicalPlan) (logicalplans.LogicalExpr, bool, error) {
+	return TransformExprUp(expr, func(e logicalplans.LogicalExpr) (logicalplans.LogicalExpr, bool, error) {
+		switch e := e.(type) {
+		case logicalplans.Not:
+			return simplifyNot(e)
+		case logicalplans.BooleanBinaryExpr:
+			return simplifyBoolean(e, input)
+		case logicalplans.MathExpr:
+			return foldBinary(e, e.BinaryExpr, input)
+		case logicalplans.Cast:
+			return foldCast(e)
+		default:
+			return e, false, nil
+		}
+	})
+}
+
+func simplifyNot(not logicalplans.Not) (logicalplans.LogicalExpr, bool, error) {
+	switch inner := not.Expr.(type) {
+	case logicalplans.LiteralBoolean:
+		return logicalplans.NewLiteralBoolean(!inner.B), true, nil
+	case logicalplans.Not:
+		return inner.Expr, true, nil
+	default:
+		return not, false, nil
+	}
+}
+
+func simplifyBoolean(e logicalplans.BooleanBinaryExpr, input logicalplans.LogicalPlan) (logicalplans.LogicalExpr, bool, error) {
+	l, lIsLiteral := e.L.(logicalplans.LiteralBoolean)
+	r, rIsLiteral := e.R.(logicalplans.LiteralBoolean)
+
+	switch e.Op {
+	case "&":
+		// false wins over a null operand as in SQL, so `x AND false` is false whatever x is
+		switch {
+		case lIsLiteral && !l.B, rIsLiteral && !r.B:
+			return logicalplans.NewLiteralBoolean(false), true, nil
+		case lIsLiteral:
+			return e.R, true, nil
+		case rIsLiteral:
+			return e.L, true, nil
+		}
+
+	case "OR":
+		switch {
+		case lIsLiteral && l.B, rIsLiteral && r.B:
+			return logicalplans.NewLiteralBoolean(true), true, nil
+		case lIsLiteral:
+			return e.R, true, nil
+		case rIsLiteral:
+			return e.L, true, nil
+		}
+
+	case "=", "<=", ">=", "!=", "<", ">":
+		equal, err := sameNonNullColumn(e.L, e.R, input)
+		if err != nil {
+			return nil, false, err
+		}
+		if equal {
+			return logicalplans.NewLiteralBoolean(e.Op == "=" || e.Op == "<=" || e.Op == ">="), true, nil
+		}
+	}
+
+	return foldBinary(e, e.BinaryExpr, input)
+}
+
+// sameNonNullColumn reports whether l and r read the same non nullable column, NaN isn't equal
+// to itself so floating point columns never are
+func sameNonNullColumn(l, r logicalplans.LogicalExpr, input logicalplans.LogicalPlan) (bool, error) {
+	lc, ok := l.(logicalplans.Column)
+	if !ok {
+		return false, nil
+	}
+	if rc, ok := r.(logicalplans.Column); !ok || rc.Name != lc.Name {
+		return false, nil
+	}
+
+	field, err := lc.ToField(input)
+	if err != nil {
+		return false, err
+	}
+
+	switch field.Type.ID() {
+	case arrow.FLOAT16, arrow.FLOAT32, arrow.FLOAT64:
+		return false, nil
+	default:
+		return !field.Nullable, nil
+	}
+}
+
+// the evaluation of the physical expressions, reused to fold literals
+var foldFuncs = map[string]exprs.BinaryExprEvalFunc{
+	"=":  exprs.EqEvalFunc,
+	"!=": exprs.NeqEvalFunc,
+	"<":  exprs.LtEvalFunc,
+	"<=": exprs.LtEqEvalFunc,
+	">":  exprs.GtEvalFunc,
+	">=": exprs.GtEqEvalFunc,
+	"&":  exprs.AndEvalFunc,
+	"OR": exprs.OrEvalFunc,
+	"+":  exprs.AddEvalFunc,
+	"-":  exprs.SubtractEvalFunc,
+	"*":  exprs.MultiplyEvalFunc,
+	"/":  exprs.DivideEvalFunc,
+	"%":  exprs.ModuloEvalFunc,
+}
+
+// foldBinary replaces a binary expression of two literals by its result, expressions which
+// would fail at runtime (mismatched types, division by zero) are left as is so they still do
+func foldBinary(expr logicalplans.LogicalExpr, e logicalplans.BinaryExpr, input logicalplans.LogicalPlan) (logicalplans.LogicalExpr, bool, error) {
+	evalFunc, ok := foldFuncs[e.Op]
+	if !ok {
+		return expr, false, nil
+	}
+
+	l, lok := literalValue(e.L)
+	r, rok := literalValue(e.R)
+	if !lok || !rok {
+		return expr, false, nil
+	}
+
+	lField, err := e.L.ToField(input)
+	if err != nil {
+		return nil, false, err
+	}
+	rField, err := e.R.ToField(input)
+	if err != nil {
+		return nil, false, err
+	}
+	if !arrow.TypeEqual(lField.Type, rField.Type) {
+		return expr, false, nil
+	}
+
+	value, err := evalFunc(l, r, lField.Type)
+	if err != nil {
+		return expr, false, nil
+	}
+
+	folded, ok := newLiteral(value)
+	if !ok {
+		return expr, false, nil
+	}
+	return folded, true, nil
+}
+
+// foldCast replaces the cast of a literal by the converted literal, a value which can't be
+// converted is left as is so the cast still fails, or produces a null, at runtime
+func foldCast(cast logicalplans.Cast) (logicalplans.LogicalExpr, bool, error) {
+	v, ok := literalValue(cast.Expr)
+	if !ok {
+		return cast, false, nil
+	}
+
+	value, err := exprs.CastValue(v, cast.Type)
+	if err != nil {
+		return cast, false, nil
+	}
+
+	folded, ok := newLiteral(value)
+	if !ok {
+		return cast, false, nil
+	}
+	return folded, true, nil
+}
+
+func newLiteral(value any) (logicalplans.LogicalExpr, bool) {
+	switch v := value.(type) {
+	case bool:
+		return logicalplans.NewLiteralBoolean(v), true
+	case int64:
+		return logicalplans.NewLiteralLong(v), true
+	case float32:
+		return logicalplans.NewLiteralFloat(v), true
+	case float64:
+		return logicalplans.NewLiteralDouble(v), true
+	case string:
+		return logicalplans.NewLiteralString(v), true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,116 @@
+package optimizer_test
+
+import (
+	"testing"
+
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/logicalplans"
+	"github.com/fastbyt3/query-engine/optimizer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimplifyExprFoldsLiterals(t *testing.T) {
+	scan := employeeScan()
+
+	tests := []struct {
+		expr     logicalplans.LogicalExpr
+		expected string
+	}{
+		{logicalplans.NewMult(logicalplans.NewLiteralFloat(2.0), logicalplans.NewLiteralFloat(3.0)), "6"},
+		{logicalplans.NewAdd(logicalplans.NewColumn("salary"), logicalplans.NewMult(logicalplans.NewLiteralLong(2), logicalplans.NewLiteralLong(3))), "salary + 6"},
+		{logicalplans.NewGtExpr(logicalplans.NewLiteralLong(3), logicalplans.NewLiteralLong(2)), "true"},
+		{logicalplans.NewEqExpr(logicalplans.NewLiteralString("CO"), logicalplans.NewLiteralString("CA")), "false"},
+		// division by zero is left for the runtime to report
+		{logicalplans.NewDiv(logicalplans.NewLiteralLong(1), logicalplans.NewLiteralLong(0)), "1 / 0"},
+		// mismatched literal types aren't folded either
+		{logicalplans.NewAdd(logicalplans.NewLiteralLong(1), logicalplans.NewLiteralFloat(1)), "1 + 1"},
+		{logicalplans.NewAdd(logicalplans.NewCast(logicalplans.NewLiteralString("2"), datatypes.Int64Type), logicalplans.NewLiteralLong(1)), "3"},
+		// the cast of a value which can't be converted still fails at runtime
+		{logicalplans.NewCast(logicalplans.NewLiteralString("two"), datatypes.Int64Type), "CAST('two' AS int64)"},
+	}
+
+	for _, tt := range tests {
+		simplified, _, err := optimizer.SimplifyExpr(tt.expr, scan)
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, simplified.String(), "simplifying %s", tt.expr)
+	}
+}
+
+func TestSimplifyExprBooleanIdentities(t *testing.T) {
+	scan := employeeScan()
+	salaryGt := logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(10))
+
+	tests := []struct {
+		expr     logicalplans.LogicalExpr
+		expected string
+	}{
+		{logicalplans.NewAndExpr(salaryGt, logicalplans.NewLiteralBoolean(true)), "salary > 10"},
+		{logicalplans.NewAndExpr(salaryGt, logicalplans.NewLiteralBoolean(false)), "false"},
+		{logicalplans.NewOrExpr(logicalplans.NewLiteralBoolean(false), salaryGt), "salary > 10"},
+		{logicalplans.NewOrExpr(salaryGt, logicalplans.NewLiteralBoolean(true)), "true"},
+		{logicalplans.NewNot(logicalplans.NewNot(salaryGt)), "salary > 10"},
+		{logicalplans.NewNot(logicalplans.NewLiteralBoolean(true)), "false"},
+		{logicalplans.NewEqExpr(logicalplans.NewColumn("state"), logicalplans.NewColumn("state")), "true"},
+		{logicalplans.NewLtExpr(logicalplans.NewColumn("id"), logicalplans.NewColumn("id")), "false"},
+		{logicalplans.NewEqExpr(logicalplans.NewColumn("id"), logicalplans.NewColumn("salary")), "id = salary"},
+	}
+
+	for _, tt := range tests {
+		simplified, _, err := optimizer.SimplifyExpr(tt.expr, scan)
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, simplified.String(), "simplifying %s", tt.expr)
+	}
+}
+
+func TestSimplifyExpressionsRemovesAlwaysTrueSelection(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Filter(logicalplans.NewOrExpr(
+			logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(10)),
+			logicalplans.NewEqExpr(logicalplans.NewLiteralLong(1), logicalplans.NewLiteralLong(1)),
+		)).
+		Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("id")}).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Projection: id\n  Scan: employee; path=None\n",
+		optimizeWith(t, plan, optimizer.SimplifyExpressions{}),
+	)
+}
+
+func TestSimplifyExpressionsReplacesAlwaysFalseSelection(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Filter(logicalplans.NewAndExpr(
+			logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(10)),
+			logicalplans.NewLiteralBoolean(false),
+		)).
+		LogicalPlan()
+
+	optimized, err := optimizer.NewOptimizer(optimizer.SimplifyExpressions{}).Optimize(plan)
+	require.NoError(t, err)
+	require.IsType(t, logicalplans.EmptyRelation{}, optimized)
+
+	schema, err := optimized.Schema()
+	require.NoError(t, err)
+	require.True(t, schema.Equal(&employeeSchema.Schema))
+}
+
+func TestSimplifyExpressionsKeepsProjectedNames(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Project([]logicalplans.LogicalExpr{
+			logicalplans.NewMult(logicalplans.NewColumn("salary"), logicalplans.NewAdd(logicalplans.NewLiteralLong(1), logicalplans.NewLiteralLong(1))),
+			logicalplans.NewAndExpr(
+				logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(10)),
+				logicalplans.NewLiteralBoolean(true),
+			),
+		}).
+		LogicalPlan()
+
+	optimized, err := optimizer.NewOptimizer(optimizer.SimplifyExpressions{}).Optimize(plan)
+	require.NoError(t, err)
+	require.Equal(t, "Projection: salary * 2 AS salary * (1 + 1), salary > 10 AS salary > 10 & true\n  Scan: employee; path=None\n", logicalplans.PprintPlan(optimized, 2))
+
+	schema, err := optimized.Schema()
+	require.NoError(t, err)
+	require.Equal(t, "salary * (1 + 1)", schema.Field(0).Name)
+	require.Equal(t, "salary > 10 & true", schema.Field(1).Name)
+}
@@ -0,0 +1,69 @@
+package optimizer
+
+import (
+	"github.com/fastbyt3/query-engine/logicalplans"
+)
+
+// SortLimitFusion turns a sort followed by a limit into a sort which only keeps the first rows
+// (a top-k), so the physical plan holds at most skip + fetch rows instead of the whole input:
+//
+//   - limits are moved below projections, which don't change the number or order of rows,
+//     until they reach the sort
+//   - the fetch of the sort becomes skip + fetch, the limit is dropped when it doesn't skip rows
+type SortLimitFusion struct{}
+
+func (r SortLimitFusion) Name() string {
+	return "sort_limit_fusion"
+}
+
+func (r SortLimitFusion) Optimize(plan logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+	return TransformDown(plan, func(p logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+		limit, ok := p.(*logicalplans.Limit)
+		// a limit without fetch keeps every row after the skipped ones, a fetch of zero
+		// doesn't need to read its input at all
+		if !ok || limit.Fetch <= 0 {
+			return p, false, nil
+		}
+
+		switch input := limit.Input.(type) {
+		case *logicalplans.Projection:
+			if _, ok := projectedSort(input); !ok {
+				return p, false, nil
+			}
+			pushed := logicalplans.NewLimit(input.Input, limit.Skip, limit.Fetch)
+			return logicalplans.NewProjection(pushed, input.Exprs), true, nil
+
+		case *logicalplans.Sort:
+			fetch := limit.Skip + limit.Fetch
+			if input.Fetch >= 0 && input.Fetch <= fetch {
+				if limit.Skip == 0 {
+					// the sort already produces at most fetch rows
+					return input, true, nil
+				}
+				return p, false, nil
+			}
+
+			sort := logicalplans.NewSort(input.Input, input.Exprs)
+			sort.Fetch = fetch
+			if limit.Skip == 0 {
+				return sort, true, nil
+			}
+			return logicalplans.NewLimit(sort, limit.Skip, limit.Fetch), true, nil
+
+		default:
+			return p, false, nil
+		}
+	})
+}
+
+// projectedSort returns the sort found below a chain of projections
+func projectedSort(projection *logicalplans.Projection) (*logicalplans.Sort, bool) {
+	switch input := projection.Input.(type) {
+	case *logicalplans.Sort:
+		return input, true
+	case *logicalplans.Projection:
+		return projectedSort(input)
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,70 @@
+package optimizer_test
+
+import (
+	"testing"
+
+	"github.com/fastbyt3/query-engine/logicalplans"
+	"github.com/fastbyt3/query-engine/optimizer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortLimitFusion(t *testing.T) {
+	sorted := logicalplans.NewDefaultDataframe(employeeScan()).
+		Sort(logicalplans.NewSortExpr(logicalplans.NewColumn("salary"), false))
+
+	tests := []struct {
+		name     string
+		df       logicalplans.Dataframe
+		expected string
+	}{
+		{
+			"limit below projection",
+			sorted.Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("id")}).Limit(0, 3),
+			"Projection: id\n" +
+				"  Sort: [salary DESC NULLS FIRST], fetch=3\n" +
+				"    Scan: employee; path=None\n",
+		},
+		{
+			"skipped rows are sorted too",
+			sorted.Limit(2, 3),
+			"Limit: skip=2, fetch=3\n" +
+				"  Sort: [salary DESC NULLS FIRST], fetch=5\n" +
+				"    Scan: employee; path=None\n",
+		},
+		{
+			"offset only",
+			sorted.Limit(2, -1),
+			"Limit: skip=2, fetch=None\n" +
+				"  Sort: [salary DESC NULLS FIRST]\n" +
+				"    Scan: employee; path=None\n",
+		},
+		{
+			"smallest limit wins",
+			sorted.Limit(0, 3).Limit(0, 5),
+			"Sort: [salary DESC NULLS FIRST], fetch=3\n" +
+				"  Scan: employee; path=None\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, optimizeWith(t, tt.df.LogicalPlan(), optimizer.SortLimitFusion{}))
+		})
+	}
+}
+
+func TestSortLimitFusionKeepsFiltersAbove(t *testing.T) {
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Sort(logicalplans.NewSortExpr(logicalplans.NewColumn("salary"), false)).
+		Limit(0, 3).
+		Filter(logicalplans.NewEqExpr(logicalplans.NewColumn("state"), logicalplans.NewLiteralString("CO"))).
+		LogicalPlan()
+
+	// the filter applies to the top 3 rows, not before picking them
+	require.Equal(t,
+		"Filter: state = 'CO'\n"+
+			"  Sort: [salary DESC NULLS FIRST], fetch=3\n"+
+			"    Scan: employee; path=None\n",
+		optimizeWith(t, plan, optimizer.SortLimitFusion{}, optimizer.PredicatePushdown{}),
+	)
+}
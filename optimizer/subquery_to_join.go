@@ -0,0 +1,234 @@
+package optimizer
+
+import (
+	"github.com/fastbyt3/query-engine/logicalplans"
+)
+
+// SubqueryToJoin rewrites the IN and EXISTS conjuncts of a selection into semi and anti joins
+// against the subquery:
+//
+//   - `x IN (SELECT y ...)` becomes a left semi join on x = y
+//   - `x NOT IN (SELECT y ...)` becomes a null aware left anti join on x = y
+//   - `[NOT] EXISTS (SELECT ... WHERE ...)` becomes a left semi (anti) join, the conditions of
+//     the subquery which read columns of the outer query become the keys (equalities between an
+//     inner and an outer expression) and the filter of the join. Outer columns are OuterColumn
+//     references and the columns the subquery doesn't have
+//
+// Subqueries nested in other expressions, like an OR, are left as is
+type SubqueryToJoin struct{}
+
+func (r SubqueryToJoin) Name() string {
+	return "subquery_to_join"
+}
+
+func (r SubqueryToJoin) Optimize(plan logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+	return TransformUp(plan, func(p logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+		selection, ok := p.(*logicalplans.Selection)
+		if !ok {
+			return p, false, nil
+		}
+
+		input := selection.Input
+		var kept []logicalplans.LogicalExpr
+		changed := false
+		for _, predicate := range SplitConjunction(selection.Expr) {
+			join, err := r.toJoin(input, predicate)
+			if err != nil {
+				return nil, false, err
+			}
+
+			if join == nil {
+				kept = append(kept, predicate)
+				continue
+			}
+			input = join
+			changed = true
+		}
+
+		if !changed {
+			return p, false, nil
+		}
+		if len(kept) == 0 {
+			return input, true, nil
+		}
+		return logicalplans.NewSelection(input, Conjunction(kept)), true, nil
+	})
+}
+
+// toJoin returns the join of input equivalent to filtering it with predicate, or nil when
+// predicate isn't a subquery
+func (r SubqueryToJoin) toJoin(input logicalplans.LogicalPlan, predicate logicalplans.LogicalExpr) (*logicalplans.Join, error) {
+	negated := false
+	if not, ok := predicate.(logicalplans.Not); ok {
+		negated = true
+		predicate = not.Expr
+	}
+
+	switch e := predicate.(type) {
+	case logicalplans.InSubquery:
+		return r.inToJoin(input, e, e.Negated != negated)
+
+	case logicalplans.Exists:
+		return r.existsToJoin(input, e, e.Negated != negated)
+
+	default:
+		return nil, nil
+	}
+}
+
+func (r SubqueryToJoin) inToJoin(input logicalplans.LogicalPlan, in logicalplans.InSubquery, negated bool) (*logicalplans.Join, error) {
+	schema, err := in.Subquery.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	// only a subquery producing a single column can be compared to a value
+	if schema.NumFields() != 1 {
+		return nil, nil
+	}
+
+	keys := []logicalplans.JoinKey{{Left: in.Expr, Right: logicalplans.NewColumn(schema.Field(0).Name)}}
+	if !negated {
+		return logicalplans.NewJoin(input, in.Subquery, logicalplans.LeftSemiJoin, keys, nil), nil
+	}
+
+	join := logicalplans.NewJoin(input, in.Subquery, logicalplans.LeftAntiJoin, keys, nil)
+	join.NullAware = true
+	return join, nil
+}
+
+func (r SubqueryToJoin) existsToJoin(input logicalplans.LogicalPlan, exists logicalplans.Exists, negated bool) (*logicalplans.Join, error) {
+	joinType := logicalplans.LeftSemiJoin
+	if negated {
+		joinType = logicalplans.LeftAntiJoin
+	}
+
+	subquery := exists.Subquery
+	// the select list of an EXISTS subquery doesn't matter
+	if projection, ok := subquery.(*logicalplans.Projection); ok {
+		subquery = projection.Input
+	}
+
+	selection, ok := subquery.(*logicalplans.Selection)
+	if !ok {
+		// uncorrelated, without keys every left row matches every subquery row
+		return logicalplans.NewJoin(input, exists.Subquery, joinType, nil, nil), nil
+	}
+
+	innerSchema, err := selection.Input.Schema()
+	if err != nil {
+		return nil, err
+	}
+	innerColumns := make(map[string]bool, innerSchema.NumFields())
+	for _, f := range innerSchema.Fields() {
+		innerColumns[f.Name] = true
+	}
+
+	var inner, conditions []logicalplans.LogicalExpr
+	var keys []logicalplans.JoinKey
+	for _, predicate := range SplitConjunction(selection.Expr) {
+		predicate, err := markOuterColumns(predicate, innerColumns)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(outerColumns(predicate)) == 0 {
+			inner = append(inner, predicate)
+			continue
+		}
+
+		key, ok, err := correlationKey(predicate)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			keys = append(keys, key)
+			continue
+		}
+
+		// the filter of the join reads the fields of both sides
+		condition, err := unmarkOuterColumns(predicate)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	var right logicalplans.LogicalPlan = selection.Input
+	if len(inner) > 0 {
+		right = logicalplans.NewSelection(selection.Input, Conjunction(inner))
+	}
+
+	var filter logicalplans.LogicalExpr
+	if len(conditions) > 0 {
+		filter = Conjunction(conditions)
+	}
+	return logicalplans.NewJoin(input, right, joinType, keys, filter), nil
+}
+
+// correlationKey turns `outer = inner` (or `inner = outer`) into a join key, the outer expression
+// must only read outer columns and the inner expression must only read inner columns
+func correlationKey(predicate logicalplans.LogicalExpr) (logicalplans.JoinKey, bool, error) {
+	eq, ok := predicate.(logicalplans.BooleanBinaryExpr)
+	if !ok || eq.Op != "=" {
+		return logicalplans.JoinKey{}, false, nil
+	}
+
+	outer, inner := eq.L, eq.R
+	if !readsOnlyOuter(outer) || !readsOnlyInner(inner) {
+		outer, inner = eq.R, eq.L
+	}
+	if !readsOnlyOuter(outer) || !readsOnlyInner(inner) {
+		return logicalplans.JoinKey{}, false, nil
+	}
+
+	left, err := unmarkOuterColumns(outer)
+	if err != nil {
+		return logicalplans.JoinKey{}, false, err
+	}
+	return logicalplans.JoinKey{Left: left, Right: inner}, true, nil
+}
+
+func readsOnlyOuter(expr logicalplans.LogicalExpr) bool {
+	return len(outerColumns(expr)) > 0 && len(ExprColumns(expr)) == 0
+}
+
+func readsOnlyInner(expr logicalplans.LogicalExpr) bool {
+	return len(ExprColumns(expr)) > 0 && len(outerColumns(expr)) == 0
+}
+
+// markOuterColumns replaces the columns the subquery doesn't have with outer columns
+func markOuterColumns(expr logicalplans.LogicalExpr, innerColumns map[string]bool) (logicalplans.LogicalExpr, error) {
+	marked, _, err := TransformExprUp(expr, func(e logicalplans.LogicalExpr) (logicalplans.LogicalExpr, bool, error) {
+		if c, ok := e.(logicalplans.Column); ok && !innerColumns[c.Name] {
+			return logicalplans.NewOuterColumn(c.Name), true, nil
+		}
+		return e, false, nil
+	})
+	return marked, err
+}
+
+// unmarkOuterColumns replaces the outer columns with columns, for expressions evaluated against
+// the outer query or the rows of the join
+func unmarkOuterColumns(expr logicalplans.LogicalExpr) (logicalplans.LogicalExpr, error) {
+	unmarked, _, err := TransformExprUp(expr, func(e logicalplans.LogicalExpr) (logicalplans.LogicalExpr, bool, error) {
+		if c, ok := e.(logicalplans.OuterColumn); ok {
+			return logicalplans.NewColumn(c.Name), true, nil
+		}
+		return e, false, nil
+	})
+	return unmarked, err
+}
+
+// outerColumns returns the names of the outer columns referenced by expr
+func outerColumns(expr logicalplans.LogicalExpr) []string {
+	if c, ok := expr.(logicalplans.OuterColumn); ok {
+		return []string{c.Name}
+	}
+
+	var columns []string
+	for _, child := range ExprChildren(expr) {
+		columns = append(columns, outerColumns(child)...)
+	}
+	return columns
+}
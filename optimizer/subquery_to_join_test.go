@@ -0,0 +1,114 @@
+package optimizer_test
+
+import (
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/fastbyt3/query-engine/datasources"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/logicalplans"
+	"github.com/fastbyt3/query-engine/optimizer"
+	"github.com/stretchr/testify/require"
+)
+
+func stateScan() logicalplans.Scan {
+	schema := *datatypes.NewSchema([]arrow.Field{
+		{Name: "code", Type: datatypes.StringType},
+		{Name: "min_salary", Type: datatypes.Int64Type},
+	})
+	return logicalplans.NewScan("states", datasources.NewMemoryDatasource(schema, nil), []string{})
+}
+
+func TestSubqueryToJoinIn(t *testing.T) {
+	codes := logicalplans.NewProjection(stateScan(), []logicalplans.LogicalExpr{logicalplans.NewColumn("code")})
+
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Filter(logicalplans.NewAndExpr(
+			logicalplans.NewInSubquery(logicalplans.NewColumn("state"), codes, false),
+			logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(10)),
+		)).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Filter: salary > 10\n"+
+			"  Join: type=LEFT SEMI, on=[state = code]\n"+
+			"    Scan: employee; path=None\n"+
+			"    Projection: code\n"+
+			"      Scan: states; path=None\n",
+		optimizeWith(t, plan, optimizer.SubqueryToJoin{}),
+	)
+
+	plan = logicalplans.NewDefaultDataframe(employeeScan()).
+		Filter(logicalplans.NewNot(logicalplans.NewInSubquery(logicalplans.NewColumn("state"), codes, false))).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Join: type=LEFT ANTI NULL AWARE, on=[state = code]\n"+
+			"  Scan: employee; path=None\n"+
+			"  Projection: code\n"+
+			"    Scan: states; path=None\n",
+		optimizeWith(t, plan, optimizer.SubqueryToJoin{}),
+	)
+}
+
+func TestSubqueryToJoinCorrelatedExists(t *testing.T) {
+	subquery := logicalplans.NewDefaultDataframe(stateScan()).
+		Filter(logicalplans.NewAndExpr(
+			logicalplans.NewAndExpr(
+				logicalplans.NewEqExpr(logicalplans.NewColumn("state"), logicalplans.NewColumn("code")),
+				logicalplans.NewGtExpr(logicalplans.NewColumn("min_salary"), logicalplans.NewLiteralLong(5)),
+			),
+			logicalplans.NewLtExpr(logicalplans.NewColumn("salary"), logicalplans.NewColumn("min_salary")),
+		)).
+		Project([]logicalplans.LogicalExpr{logicalplans.NewLiteralLong(1)}).
+		LogicalPlan()
+
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Filter(logicalplans.NewExists(subquery, true)).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Join: type=LEFT ANTI, on=[state = code], filter=salary < min_salary\n"+
+			"  Scan: employee; path=None\n"+
+			"  Filter: min_salary > 5\n"+
+			"    Scan: states; path=None\n",
+		optimizeWith(t, plan, optimizer.SubqueryToJoin{}),
+	)
+}
+
+func TestSubqueryToJoinOuterColumn(t *testing.T) {
+	// the subquery reads employees too, salary is both an inner and an outer column
+	subquery := logicalplans.NewDefaultDataframe(employeeScan()).
+		Filter(logicalplans.NewAndExpr(
+			logicalplans.NewEqExpr(logicalplans.NewColumn("state"), logicalplans.NewOuterColumn("state")),
+			logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(5)),
+		)).
+		LogicalPlan()
+
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Filter(logicalplans.NewExists(subquery, false)).
+		LogicalPlan()
+
+	require.Equal(t,
+		"Join: type=LEFT SEMI, on=[state = state]\n"+
+			"  Scan: employee; path=None\n"+
+			"  Filter: salary > 5\n"+
+			"    Scan: employee; path=None\n",
+		optimizeWith(t, plan, optimizer.SubqueryToJoin{}),
+	)
+}
+
+func TestSubqueryToJoinKeepsNestedSubqueries(t *testing.T) {
+	codes := logicalplans.NewProjection(stateScan(), []logicalplans.LogicalExpr{logicalplans.NewColumn("code")})
+
+	plan := logicalplans.NewDefaultDataframe(employeeScan()).
+		Filter(logicalplans.NewOrExpr(
+			logicalplans.NewInSubquery(logicalplans.NewColumn("state"), codes, false),
+			logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(10)),
+		)).
+		LogicalPlan()
+
+	optimized, err := optimizer.NewOptimizer(optimizer.SubqueryToJoin{}).Optimize(plan)
+	require.NoError(t, err)
+	require.Equal(t, plan, optimized)
+}
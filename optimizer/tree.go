@@ -0,0 +1,111 @@
+package optimizer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fastbyt3/query-engine/logicalplans"
+)
+
+// ErrUnsupportedPlan is returned when a rewrite helper doesn't know how to rebuild a logical plan
+var ErrUnsupportedPlan = errors.New("unsupported logical plan")
+
+// TransformFunc rewrites a single plan node and reports whether the returned plan differs from the input
+type TransformFunc func(plan logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error)
+
+// WithNewChildren returns a copy of plan with its inputs replaced by children, every other
+// attribute of the plan is kept as is
+func WithNewChildren(plan logicalplans.LogicalPlan, children []logicalplans.LogicalPlan) (logicalplans.LogicalPlan, error) {
+	if len(children) != len(plan.Children()) {
+		return nil, fmt.Errorf("%s expects %d children, got %d", plan, len(plan.Children()), len(children))
+	}
+
+	switch p := plan.(type) {
+	case logicalplans.Scan, logicalplans.EmptyRelation:
+		return p, nil
+	case *logicalplans.Projection:
+		return logicalplans.NewProjection(children[0], p.Exprs), nil
+	case *logicalplans.Selection:
+		return logicalplans.NewSelection(children[0], p.Expr), nil
+	case *logicalplans.Aggregate:
+		return logicalplans.NewAggregate(children[0], p.GroupExprs, p.AggregateExprs), nil
+	case *logicalplans.Sort:
+		sort := logicalplans.NewSort(children[0], p.Exprs)
+		sort.Fetch = p.Fetch
+		return sort, nil
+	case *logicalplans.Distinct:
+		return logicalplans.NewDistinct(children[0]), nil
+	case *logicalplans.Limit:
+		return logicalplans.NewLimit(children[0], p.Skip, p.Fetch), nil
+	case *logicalplans.Join:
+		join := logicalplans.NewJoin(children[0], children[1], p.JoinType, p.Keys, p.Filter)
+		join.NullAware = p.NullAware
+		return join, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedPlan, plan)
+	}
+}
+
+// TransformUp applies fn to every node of the plan, children are rewritten before their parent
+func TransformUp(plan logicalplans.LogicalPlan, fn TransformFunc) (logicalplans.LogicalPlan, bool, error) {
+	plan, childrenChanged, err := transformChildren(plan, func(child logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+		return TransformUp(child, fn)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	plan, changed, err := fn(plan)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return plan, childrenChanged || changed, nil
+}
+
+// TransformDown applies fn to every node of the plan, a parent is rewritten before its children
+// and the children of the rewritten node are visited next
+func TransformDown(plan logicalplans.LogicalPlan, fn TransformFunc) (logicalplans.LogicalPlan, bool, error) {
+	plan, changed, err := fn(plan)
+	if err != nil {
+		return nil, false, err
+	}
+
+	plan, childrenChanged, err := transformChildren(plan, func(child logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+		return TransformDown(child, fn)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return plan, changed || childrenChanged, nil
+}
+
+// transformChildren rewrites the direct children of plan and only rebuilds plan when a child changed
+func transformChildren(plan logicalplans.LogicalPlan, fn TransformFunc) (logicalplans.LogicalPlan, bool, error) {
+	children := plan.Children()
+	if len(children) == 0 {
+		return plan, false, nil
+	}
+
+	newChildren := make([]logicalplans.LogicalPlan, len(children))
+	changed := false
+	for i, child := range children {
+		newChild, childChanged, err := fn(child)
+		if err != nil {
+			return nil, false, err
+		}
+		newChildren[i] = newChild
+		changed = changed || childChanged
+	}
+
+	if !changed {
+		return plan, false, nil
+	}
+
+	newPlan, err := WithNewChildren(plan, newChildren)
+	if err != nil {
+		return nil, false, err
+	}
+	return newPlan, true, nil
+}
@@ -0,0 +1,23 @@
+package physicalplan
+
+import "errors"
+
+var (
+	// ErrTypeMismatch is returned when the operands of an expression have incompatible types or sizes
+	ErrTypeMismatch = errors.New("type mismatch")
+
+	// ErrUnsupportedType is returned when an operation isn't defined for the type of its input
+	ErrUnsupportedType = errors.New("unsupported type")
+
+	// ErrNonBooleanPredicate is returned when a filter predicate doesn't evaluate to a boolean column
+	ErrNonBooleanPredicate = errors.New("predicate is not boolean")
+
+	// ErrDivisionByZero is returned when an integer is divided by zero
+	ErrDivisionByZero = errors.New("division by zero")
+
+	// ErrInvalidCast is returned when a value can't be represented in the type it is cast to
+	ErrInvalidCast = errors.New("invalid cast")
+
+	// ErrColumnIndexOutOfRange is returned when a column expression refers past the columns of its input
+	ErrColumnIndexOutOfRange = errors.New("column index out of range")
+)
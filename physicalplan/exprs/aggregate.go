@@ -3,11 +3,14 @@ package exprs
 import (
 	"fmt"
 
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/fastbyt3/query-engine/datatypes"
 	"github.com/fastbyt3/query-engine/physicalplan"
 )
 
 type Accumulator interface {
-	Accumulate(value any)
+	// Accumulate fails when the aggregation isn't defined for the type of value
+	Accumulate(value any) error
 	FinalValue() any
 }
 
@@ -23,6 +26,10 @@ type MaxExpr struct {
 	expr physicalplan.PhysicalExpression
 }
 
+func NewMaxExpr(expr physicalplan.PhysicalExpression) *MaxExpr {
+	return &MaxExpr{expr}
+}
+
 func (e *MaxExpr) InputExpression() physicalplan.PhysicalExpression {
 	return e.expr
 }
@@ -38,14 +45,14 @@ type MaxAccumulator struct {
 	value any
 }
 
-func (m *MaxAccumulator) Accumulate(value any) {
+func (m *MaxAccumulator) Accumulate(value any) error {
 	if value == nil {
-		return
+		return nil
 	}
 
 	if m.value == nil {
 		m.value = value
-		return
+		return nil
 	}
 
 	isMax := false
@@ -72,13 +79,18 @@ func (m *MaxAccumulator) Accumulate(value any) {
 		isMax = value.(float64) > v
 	case string:
 		isMax = value.(string) > v
+	case arrow.Date32:
+		isMax = value.(arrow.Date32) > v
+	case arrow.Timestamp:
+		isMax = value.(arrow.Timestamp) > v
 	default:
-		panic(fmt.Sprintf("Value passed to accumulator: %v - does not have MAX operation", value))
+		return fmt.Errorf("%w: %T has no MAX operation", physicalplan.ErrUnsupportedType, value)
 	}
 
 	if isMax {
 		m.value = value
 	}
+	return nil
 }
 
 func (m *MaxAccumulator) FinalValue() any {
@@ -90,6 +102,10 @@ type MinExpr struct {
 	expr physicalplan.PhysicalExpression
 }
 
+func NewMinExpr(expr physicalplan.PhysicalExpression) *MinExpr {
+	return &MinExpr{expr}
+}
+
 func (e *MinExpr) InputExpression() physicalplan.PhysicalExpression {
 	return e.expr
 }
@@ -105,14 +121,14 @@ type MinAccumulator struct {
 	value any
 }
 
-func (m *MinAccumulator) Accumulate(value any) {
+func (m *MinAccumulator) Accumulate(value any) error {
 	if value == nil {
-		return
+		return nil
 	}
 
 	if m.value == nil {
 		m.value = value
-		return
+		return nil
 	}
 
 	isMin := false
@@ -139,13 +155,18 @@ func (m *MinAccumulator) Accumulate(value any) {
 		isMin = value.(float64) < v
 	case string:
 		isMin = value.(string) < v
+	case arrow.Date32:
+		isMin = value.(arrow.Date32) < v
+	case arrow.Timestamp:
+		isMin = value.(arrow.Timestamp) < v
 	default:
-		panic(fmt.Sprintf("Value passed to accumulator: %v - does not have MIN operation", value))
+		return fmt.Errorf("%w: %T has no MIN operation", physicalplan.ErrUnsupportedType, value)
 	}
 
 	if isMin {
 		m.value = value
 	}
+	return nil
 }
 
 func (m *MinAccumulator) FinalValue() any {
@@ -157,6 +178,10 @@ type SumExpr struct {
 	expr physicalplan.PhysicalExpression
 }
 
+func NewSumExpr(expr physicalplan.PhysicalExpression) *SumExpr {
+	return &SumExpr{expr}
+}
+
 func (e *SumExpr) InputExpression() physicalplan.PhysicalExpression {
 	return e.expr
 }
@@ -173,14 +198,14 @@ type SumAccumulator struct {
 	value any
 }
 
-func (a *SumAccumulator) Accumulate(value any) {
+func (a *SumAccumulator) Accumulate(value any) error {
 	if value == nil {
-		return
+		return nil
 	}
 
 	if a.value == nil {
 		a.value = value
-		return
+		return nil
 	}
 
 	switch v := a.value.(type) {
@@ -207,10 +232,164 @@ func (a *SumAccumulator) Accumulate(value any) {
 	case string:
 		a.value = value.(string) + v
 	default:
-		panic(fmt.Sprintf("Value passed to accumulator: %v - does not have SUM operation", value))
+		return fmt.Errorf("%w: %T has no SUM operation", physicalplan.ErrUnsupportedType, value)
 	}
+	return nil
 }
 
 func (a *SumAccumulator) FinalValue() any {
 	return a.value
 }
+
+// ---- Aggregate operation: COUNT
+type CountExpr struct {
+	expr physicalplan.PhysicalExpression
+}
+
+func NewCountExpr(expr physicalplan.PhysicalExpression) *CountExpr {
+	return &CountExpr{expr}
+}
+
+func (e *CountExpr) InputExpression() physicalplan.PhysicalExpression {
+	return e.expr
+}
+
+func (e *CountExpr) CreateAccumulator() Accumulator {
+	return &CountAccumulator{}
+}
+
+func (e *CountExpr) String() string {
+	return fmt.Sprintf("COUNT(%s)", e.expr)
+}
+
+// CountAccumulator counts non null values, the final value is always an int64
+type CountAccumulator struct {
+	count int64
+}
+
+func (a *CountAccumulator) Accumulate(value any) error {
+	if value != nil {
+		a.count++
+	}
+	return nil
+}
+
+func (a *CountAccumulator) FinalValue() any {
+	return a.count
+}
+
+// ---- Aggregate operation: COUNT DISTINCT
+type CountDistinctExpr struct {
+	expr physicalplan.PhysicalExpression
+}
+
+func NewCountDistinctExpr(expr physicalplan.PhysicalExpression) *CountDistinctExpr {
+	return &CountDistinctExpr{expr}
+}
+
+func (e *CountDistinctExpr) InputExpression() physicalplan.PhysicalExpression {
+	return e.expr
+}
+
+func (e *CountDistinctExpr) CreateAccumulator() Accumulator {
+	return &CountDistinctAccumulator{seen: make(map[string]struct{})}
+}
+
+func (e *CountDistinctExpr) String() string {
+	return fmt.Sprintf("COUNT(DISTINCT %s)", e.expr)
+}
+
+// CountDistinctAccumulator keeps every distinct non null value it saw, the final value is the
+// number of those values as an int64. Each group has its own accumulator so values are
+// deduplicated across all the batches of the group. Values are keyed by their row key encoding
+// so unhashable values like binary slices can be counted too
+type CountDistinctAccumulator struct {
+	seen map[string]struct{}
+	buf  []byte
+}
+
+func (a *CountDistinctAccumulator) Accumulate(value any) error {
+	if value == nil {
+		return nil
+	}
+
+	buf, err := datatypes.AppendKeyValue(a.buf[:0], value)
+	if err != nil {
+		return err
+	}
+	a.buf = buf
+	a.seen[string(buf)] = struct{}{}
+	return nil
+}
+
+func (a *CountDistinctAccumulator) FinalValue() any {
+	return int64(len(a.seen))
+}
+
+// ---- Aggregate operation: AVG
+type AvgExpr struct {
+	expr physicalplan.PhysicalExpression
+}
+
+func NewAvgExpr(expr physicalplan.PhysicalExpression) *AvgExpr {
+	return &AvgExpr{expr}
+}
+
+func (e *AvgExpr) InputExpression() physicalplan.PhysicalExpression {
+	return e.expr
+}
+
+func (e *AvgExpr) CreateAccumulator() Accumulator {
+	return &AvgAccumulator{}
+}
+
+func (e *AvgExpr) String() string {
+	return fmt.Sprintf("AVG(%s)", e.expr)
+}
+
+// AvgAccumulator keeps a running float64 sum and count of non null values, the final value
+// is nil when no values were accumulated
+type AvgAccumulator struct {
+	sum   float64
+	count int64
+}
+
+func (a *AvgAccumulator) Accumulate(value any) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case int8:
+		a.sum += float64(v)
+	case int16:
+		a.sum += float64(v)
+	case int32:
+		a.sum += float64(v)
+	case int64:
+		a.sum += float64(v)
+	case uint8:
+		a.sum += float64(v)
+	case uint16:
+		a.sum += float64(v)
+	case uint32:
+		a.sum += float64(v)
+	case uint64:
+		a.sum += float64(v)
+	case float32:
+		a.sum += float64(v)
+	case float64:
+		a.sum += v
+	default:
+		return fmt.Errorf("%w: %T has no AVG operation", physicalplan.ErrUnsupportedType, value)
+	}
+	a.count++
+	return nil
+}
+
+func (a *AvgAccumulator) FinalValue() any {
+	if a.count == 0 {
+		return nil
+	}
+	return a.sum / float64(a.count)
+}
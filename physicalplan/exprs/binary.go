@@ -4,10 +4,12 @@ import (
 	"fmt"
 
 	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/fastbyt3/query-engine/datatypes"
 	"github.com/fastbyt3/query-engine/physicalplan"
 )
 
-type BinaryExprEvalFunc = func(l, r any, arrowType arrow.DataType) any
+// BinaryExprEvalFunc is only called with non null operands of the same arrow type
+type BinaryExprEvalFunc = func(l, r any, arrowType arrow.DataType) (any, error)
 
 type BinaryExpr struct {
 	name     string
@@ -19,3 +21,26 @@ type BinaryExpr struct {
 func (b BinaryExpr) String() string {
 	return fmt.Sprintf("%s %s %s", b.l, b.op, b.r)
 }
+
+// evaluateOperands evaluates both sides of the expression and checks they can be combined row by row
+func (b BinaryExpr) evaluateOperands(input datatypes.RecordBatch) (datatypes.ColumnArray, datatypes.ColumnArray, error) {
+	ll, err := b.l.Evaluate(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rr, err := b.r.Evaluate(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if ll.Size() != rr.Size() {
+		return nil, nil, fmt.Errorf("%w: %s has %d rows on the left and %d on the right", physicalplan.ErrTypeMismatch, b, ll.Size(), rr.Size())
+	}
+
+	if !arrow.TypeEqual(ll.GetType(), rr.GetType()) {
+		return nil, nil, fmt.Errorf("%w: %s can't combine %s and %s", physicalplan.ErrTypeMismatch, b, ll.GetType(), rr.GetType())
+	}
+
+	return ll, rr, nil
+}
@@ -13,80 +13,214 @@ type BooleanExpr struct {
 	BinaryExpr
 }
 
-func (b BooleanExpr) Evaluate(input datatypes.RecordBatch) datatypes.ColumnArray {
-	ll := b.l.Evaluate(input)
-	rr := b.r.Evaluate(input)
-	if ll.Size() != rr.Size() || ll.GetType() != rr.GetType() {
-		panic("LHS and RHS of boolean expr aren;t compatible")
+func (b BooleanExpr) Evaluate(input datatypes.RecordBatch) (datatypes.ColumnArray, error) {
+	ll, rr, err := b.evaluateOperands(input)
+	if err != nil {
+		return nil, err
 	}
 	return b.binaryEvaluate(ll, rr)
 }
 
-func (b BooleanExpr) binaryEvaluate(l, r datatypes.ColumnArray) datatypes.ColumnArray {
+func (b BooleanExpr) binaryEvaluate(l, r datatypes.ColumnArray) (datatypes.ColumnArray, error) {
+	if b.op == "AND" || b.op == "OR" {
+		return b.logicalEvaluate(l, r)
+	}
+
 	builder := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.BooleanType)
 	for i := range l.Size() {
-		builder.Append(b.evalFunc(l.GetValue(i), r.GetValue(i), l.GetType()))
+		lv, rv := l.GetValue(i), r.GetValue(i)
+		var res any
+		if lv != nil && rv != nil {
+			var err error
+			if res, err = b.evalFunc(lv, rv, l.GetType()); err != nil {
+				return nil, err
+			}
+		}
+		if err := builder.Append(res); err != nil {
+			return nil, err
+		}
 	}
 
-	return builder.Build()
+	return builder.Build(), nil
 }
 
-func toBool(data any, dt arrow.DataType) bool {
-	switch dt {
-	case datatypes.BooleanType:
-		return data.(bool)
-	case datatypes.Int16Type, datatypes.Int8Type, datatypes.Int32Type, datatypes.Int64Type,
-		datatypes.UInt16Type, datatypes.UInt8Type, datatypes.UInt32Type, datatypes.UInt64Type:
-		return data == 1
+// logicalEvaluate follows the three valued logic of SQL: FALSE AND NULL is FALSE and TRUE OR NULL
+// is TRUE, as one operand decides the result whatever the other one is. Other combinations with
+// a null are null
+func (b BooleanExpr) logicalEvaluate(l, r datatypes.ColumnArray) (datatypes.ColumnArray, error) {
+	// the operand value which decides the result on its own
+	decisive := b.op == "OR"
+
+	builder := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.BooleanType)
+	for i := range l.Size() {
+		lb, lValid, err := toNullableBool(l.GetValue(i), l.GetType())
+		if err != nil {
+			return nil, err
+		}
+		rb, rValid, err := toNullableBool(r.GetValue(i), r.GetType())
+		if err != nil {
+			return nil, err
+		}
+
+		var res any
+		switch {
+		case (lValid && lb == decisive) || (rValid && rb == decisive):
+			res = decisive
+		case !lValid || !rValid:
+			res = nil
+		default:
+			res = !decisive
+		}
+		if err := builder.Append(res); err != nil {
+			return nil, err
+		}
+	}
+
+	return builder.Build(), nil
+}
+
+// toNullableBool is toBool which reports whether the value isn't null
+func toNullableBool(data any, dt arrow.DataType) (value bool, valid bool, err error) {
+	if data == nil {
+		return false, false, nil
+	}
+	value, err = toBool(data, dt)
+	return value, err == nil, err
+}
+
+func toBool(data any, dt arrow.DataType) (bool, error) {
+	switch v := data.(type) {
+	case bool:
+		return v, nil
+	case int8:
+		return v == 1, nil
+	case int16:
+		return v == 1, nil
+	case int32:
+		return v == 1, nil
+	case int64:
+		return v == 1, nil
+	case uint8:
+		return v == 1, nil
+	case uint16:
+		return v == 1, nil
+	case uint32:
+		return v == 1, nil
+	case uint64:
+		return v == 1, nil
 	default:
-		panic(fmt.Sprintf("Unexpected data: %v datatype: %v", data, dt))
+		return false, fmt.Errorf("%w: %s can't be used as a boolean", physicalplan.ErrUnsupportedType, dt)
 	}
 }
 
-var AndEvalFunc = func(l, r any, dt arrow.DataType) any {
-	return toBool(l, dt) && toBool(r, dt)
+// NotExpr negates a boolean expression, a null input stays null
+type NotExpr struct {
+	expr physicalplan.PhysicalExpression
+}
+
+func NewNotExpr(expr physicalplan.PhysicalExpression) NotExpr {
+	return NotExpr{expr}
+}
+
+func (n NotExpr) Evaluate(input datatypes.RecordBatch) (datatypes.ColumnArray, error) {
+	values, err := n.expr.Evaluate(input)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.BooleanType)
+	for i := range values.Size() {
+		var res any
+		if v := values.GetValue(i); v != nil {
+			var err error
+			if res, err = NotEvalFunc(v, values.GetType()); err != nil {
+				return nil, err
+			}
+		}
+		if err := builder.Append(res); err != nil {
+			return nil, err
+		}
+	}
+
+	return builder.Build(), nil
+}
+
+func (n NotExpr) String() string {
+	return fmt.Sprintf("NOT %s", n.expr)
+}
+
+var NotEvalFunc = func(v any, dt arrow.DataType) (any, error) {
+	b, err := toBool(v, dt)
+	if err != nil {
+		return nil, err
+	}
+	return !b, nil
+}
+
+var AndEvalFunc = func(l, r any, dt arrow.DataType) (any, error) {
+	lb, err := toBool(l, dt)
+	if err != nil {
+		return nil, err
+	}
+	rb, err := toBool(r, dt)
+	if err != nil {
+		return nil, err
+	}
+	return lb && rb, nil
 }
 
 func NewAndExpr(l, r physicalplan.PhysicalExpression) BooleanExpr {
 	return BooleanExpr{BinaryExpr{"and", l, r, "AND", AndEvalFunc}}
 }
 
-var OrEvalFunc = func(l, r any, dt arrow.DataType) any {
-	return toBool(l, dt) && toBool(r, dt)
+var OrEvalFunc = func(l, r any, dt arrow.DataType) (any, error) {
+	lb, err := toBool(l, dt)
+	if err != nil {
+		return nil, err
+	}
+	rb, err := toBool(r, dt)
+	if err != nil {
+		return nil, err
+	}
+	return lb || rb, nil
 }
 
 func NewOrExpr(l, r physicalplan.PhysicalExpression) BooleanExpr {
-	return BooleanExpr{BinaryExpr{"or", l, r, "OR", AndEvalFunc}}
+	return BooleanExpr{BinaryExpr{"or", l, r, "OR", OrEvalFunc}}
 }
 
-var EqEvalFunc = func(l, r any, dt arrow.DataType) any {
+var EqEvalFunc = func(l, r any, dt arrow.DataType) (any, error) {
 	switch dt {
 	case datatypes.BooleanType:
-		return l.(bool) == r.(bool)
+		return l.(bool) == r.(bool), nil
 	case datatypes.Int8Type:
-		return l.(int8) == r.(int8)
+		return l.(int8) == r.(int8), nil
 	case datatypes.Int16Type:
-		return l.(int16) == r.(int16)
+		return l.(int16) == r.(int16), nil
 	case datatypes.Int32Type:
-		return l.(int32) == r.(int32)
+		return l.(int32) == r.(int32), nil
 	case datatypes.Int64Type:
-		return l.(int64) == r.(int64)
+		return l.(int64) == r.(int64), nil
 	case datatypes.UInt8Type:
-		return l.(uint8) == r.(uint8)
+		return l.(uint8) == r.(uint8), nil
 	case datatypes.UInt16Type:
-		return l.(uint16) == r.(uint16)
+		return l.(uint16) == r.(uint16), nil
 	case datatypes.UInt32Type:
-		return l.(uint32) == r.(uint32)
+		return l.(uint32) == r.(uint32), nil
 	case datatypes.UInt64Type:
-		return l.(uint64) == r.(uint64)
+		return l.(uint64) == r.(uint64), nil
 	case datatypes.FloatType:
-		return l.(float32) == r.(float32)
+		return l.(float32) == r.(float32), nil
 	case datatypes.DoubleType:
-		return l.(float64) == r.(float64)
+		return l.(float64) == r.(float64), nil
 	case datatypes.StringType:
-		return l.(string) == r.(string)
+		return l.(string) == r.(string), nil
+	case datatypes.Date32Type:
+		return l.(arrow.Date32) == r.(arrow.Date32), nil
+	case datatypes.TimestampType:
+		return l.(arrow.Timestamp) == r.(arrow.Timestamp), nil
 	default:
-		panic(fmt.Sprintf("Unexpected arrow datatype: %v; l = %v; r = %v", dt, l, r))
+		return nil, fmt.Errorf("%w: %s in comparison", physicalplan.ErrUnsupportedType, dt)
 	}
 }
 
@@ -94,34 +228,38 @@ func NewEqExpr(l, r physicalplan.PhysicalExpression) BooleanExpr {
 	return BooleanExpr{BinaryExpr{"eq", l, r, "==", EqEvalFunc}}
 }
 
-var NeqEvalFunc = func(l, r any, dt arrow.DataType) any {
+var NeqEvalFunc = func(l, r any, dt arrow.DataType) (any, error) {
 	switch dt {
 	case datatypes.BooleanType:
-		return l.(bool) != r.(bool)
+		return l.(bool) != r.(bool), nil
 	case datatypes.Int8Type:
-		return l.(int8) != r.(int8)
+		return l.(int8) != r.(int8), nil
 	case datatypes.Int16Type:
-		return l.(int16) != r.(int16)
+		return l.(int16) != r.(int16), nil
 	case datatypes.Int32Type:
-		return l.(int32) != r.(int32)
+		return l.(int32) != r.(int32), nil
 	case datatypes.Int64Type:
-		return l.(int64) != r.(int64)
+		return l.(int64) != r.(int64), nil
 	case datatypes.UInt8Type:
-		return l.(uint8) != r.(uint8)
+		return l.(uint8) != r.(uint8), nil
 	case datatypes.UInt16Type:
-		return l.(uint16) != r.(uint16)
+		return l.(uint16) != r.(uint16), nil
 	case datatypes.UInt32Type:
-		return l.(uint32) != r.(uint32)
+		return l.(uint32) != r.(uint32), nil
 	case datatypes.UInt64Type:
-		return l.(uint64) != r.(uint64)
+		return l.(uint64) != r.(uint64), nil
 	case datatypes.FloatType:
-		return l.(float32) != r.(float32)
+		return l.(float32) != r.(float32), nil
 	case datatypes.DoubleType:
-		return l.(float64) != r.(float64)
+		return l.(float64) != r.(float64), nil
 	case datatypes.StringType:
-		return l.(string) != r.(string)
+		return l.(string) != r.(string), nil
+	case datatypes.Date32Type:
+		return l.(arrow.Date32) != r.(arrow.Date32), nil
+	case datatypes.TimestampType:
+		return l.(arrow.Timestamp) != r.(arrow.Timestamp), nil
 	default:
-		panic(fmt.Sprintf("Unexpected arrow datatype: %v; l = %v; r = %v", dt, l, r))
+		return nil, fmt.Errorf("%w: %s in comparison", physicalplan.ErrUnsupportedType, dt)
 	}
 }
 
@@ -129,32 +267,36 @@ func NewNeqExpr(l, r physicalplan.PhysicalExpression) BooleanExpr {
 	return BooleanExpr{BinaryExpr{"neq", l, r, "!=", NeqEvalFunc}}
 }
 
-var LtEvalFunc = func(l, r any, dt arrow.DataType) any {
+var LtEvalFunc = func(l, r any, dt arrow.DataType) (any, error) {
 	switch dt {
 	case datatypes.Int8Type:
-		return l.(int8) < r.(int8)
+		return l.(int8) < r.(int8), nil
 	case datatypes.Int16Type:
-		return l.(int16) < r.(int16)
+		return l.(int16) < r.(int16), nil
 	case datatypes.Int32Type:
-		return l.(int32) < r.(int32)
+		return l.(int32) < r.(int32), nil
 	case datatypes.Int64Type:
-		return l.(int64) < r.(int64)
+		return l.(int64) < r.(int64), nil
 	case datatypes.UInt8Type:
-		return l.(uint8) < r.(uint8)
+		return l.(uint8) < r.(uint8), nil
 	case datatypes.UInt16Type:
-		return l.(uint16) < r.(uint16)
+		return l.(uint16) < r.(uint16), nil
 	case datatypes.UInt32Type:
-		return l.(uint32) < r.(uint32)
+		return l.(uint32) < r.(uint32), nil
 	case datatypes.UInt64Type:
-		return l.(uint64) < r.(uint64)
+		return l.(uint64) < r.(uint64), nil
 	case datatypes.FloatType:
-		return l.(float32) < r.(float32)
+		return l.(float32) < r.(float32), nil
 	case datatypes.DoubleType:
-		return l.(float64) < r.(float64)
+		return l.(float64) < r.(float64), nil
 	case datatypes.StringType:
-		return l.(string) < r.(string)
+		return l.(string) < r.(string), nil
+	case datatypes.Date32Type:
+		return l.(arrow.Date32) < r.(arrow.Date32), nil
+	case datatypes.TimestampType:
+		return l.(arrow.Timestamp) < r.(arrow.Timestamp), nil
 	default:
-		panic(fmt.Sprintf("Unexpected arrow datatype: %v; l = %v; r = %v", dt, l, r))
+		return nil, fmt.Errorf("%w: %s in comparison", physicalplan.ErrUnsupportedType, dt)
 	}
 }
 
@@ -162,32 +304,36 @@ func NewLtExpr(l, r physicalplan.PhysicalExpression) BooleanExpr {
 	return BooleanExpr{BinaryExpr{"lt", l, r, "<", LtEvalFunc}}
 }
 
-var LtEqEvalFunc = func(l, r any, dt arrow.DataType) any {
+var LtEqEvalFunc = func(l, r any, dt arrow.DataType) (any, error) {
 	switch dt {
 	case datatypes.Int8Type:
-		return l.(int8) <= r.(int8)
+		return l.(int8) <= r.(int8), nil
 	case datatypes.Int16Type:
-		return l.(int16) <= r.(int16)
+		return l.(int16) <= r.(int16), nil
 	case datatypes.Int32Type:
-		return l.(int32) <= r.(int32)
+		return l.(int32) <= r.(int32), nil
 	case datatypes.Int64Type:
-		return l.(int64) <= r.(int64)
+		return l.(int64) <= r.(int64), nil
 	case datatypes.UInt8Type:
-		return l.(uint8) <= r.(uint8)
+		return l.(uint8) <= r.(uint8), nil
 	case datatypes.UInt16Type:
-		return l.(uint16) <= r.(uint16)
+		return l.(uint16) <= r.(uint16), nil
 	case datatypes.UInt32Type:
-		return l.(uint32) <= r.(uint32)
+		return l.(uint32) <= r.(uint32), nil
 	case datatypes.UInt64Type:
-		return l.(uint64) <= r.(uint64)
+		return l.(uint64) <= r.(uint64), nil
 	case datatypes.FloatType:
-		return l.(float32) <= r.(float32)
+		return l.(float32) <= r.(float32), nil
 	case datatypes.DoubleType:
-		return l.(float64) <= r.(float64)
+		return l.(float64) <= r.(float64), nil
 	case datatypes.StringType:
-		return l.(string) <= r.(string)
+		return l.(string) <= r.(string), nil
+	case datatypes.Date32Type:
+		return l.(arrow.Date32) <= r.(arrow.Date32), nil
+	case datatypes.TimestampType:
+		return l.(arrow.Timestamp) <= r.(arrow.Timestamp), nil
 	default:
-		panic(fmt.Sprintf("Unexpected arrow datatype: %v; l = %v; r = %v", dt, l, r))
+		return nil, fmt.Errorf("%w: %s in comparison", physicalplan.ErrUnsupportedType, dt)
 	}
 }
 
@@ -195,32 +341,36 @@ func NewLtEqExpr(l, r physicalplan.PhysicalExpression) BooleanExpr {
 	return BooleanExpr{BinaryExpr{"lteq", l, r, "<=", LtEqEvalFunc}}
 }
 
-var GtEvalFunc = func(l, r any, dt arrow.DataType) any {
+var GtEvalFunc = func(l, r any, dt arrow.DataType) (any, error) {
 	switch dt {
 	case datatypes.Int8Type:
-		return l.(int8) > r.(int8)
+		return l.(int8) > r.(int8), nil
 	case datatypes.Int16Type:
-		return l.(int16) > r.(int16)
+		return l.(int16) > r.(int16), nil
 	case datatypes.Int32Type:
-		return l.(int32) > r.(int32)
+		return l.(int32) > r.(int32), nil
 	case datatypes.Int64Type:
-		return l.(int64) > r.(int64)
+		return l.(int64) > r.(int64), nil
 	case datatypes.UInt8Type:
-		return l.(uint8) > r.(uint8)
+		return l.(uint8) > r.(uint8), nil
 	case datatypes.UInt16Type:
-		return l.(uint16) > r.(uint16)
+		return l.(uint16) > r.(uint16), nil
 	case datatypes.UInt32Type:
-		return l.(uint32) > r.(uint32)
+		return l.(uint32) > r.(uint32), nil
 	case datatypes.UInt64Type:
-		return l.(uint64) > r.(uint64)
+		return l.(uint64) > r.(uint64), nil
 	case datatypes.FloatType:
-		return l.(float32) > r.(float32)
+		return l.(float32) > r.(float32), nil
 	case datatypes.DoubleType:
-		return l.(float64) > r.(float64)
+		return l.(float64) > r.(float64), nil
 	case datatypes.StringType:
-		return l.(string) > r.(string)
+		return l.(string) > r.(string), nil
+	case datatypes.Date32Type:
+		return l.(arrow.Date32) > r.(arrow.Date32), nil
+	case datatypes.TimestampType:
+		return l.(arrow.Timestamp) > r.(arrow.Timestamp), nil
 	default:
-		panic(fmt.Sprintf("Unexpected arrow datatype: %v; l = %v; r = %v", dt, l, r))
+		return nil, fmt.Errorf("%w: %s in comparison", physicalplan.ErrUnsupportedType, dt)
 	}
 }
 
@@ -228,32 +378,36 @@ func NewGtExpr(l, r physicalplan.PhysicalExpression) BooleanExpr {
 	return BooleanExpr{BinaryExpr{"gt", l, r, ">", GtEvalFunc}}
 }
 
-var GtEqEvalFunc = func(l, r any, dt arrow.DataType) any {
+var GtEqEvalFunc = func(l, r any, dt arrow.DataType) (any, error) {
 	switch dt {
 	case datatypes.Int8Type:
-		return l.(int8) >= r.(int8)
+		return l.(int8) >= r.(int8), nil
 	case datatypes.Int16Type:
-		return l.(int16) >= r.(int16)
+		return l.(int16) >= r.(int16), nil
 	case datatypes.Int32Type:
-		return l.(int32) >= r.(int32)
+		return l.(int32) >= r.(int32), nil
 	case datatypes.Int64Type:
-		return l.(int64) >= r.(int64)
+		return l.(int64) >= r.(int64), nil
 	case datatypes.UInt8Type:
-		return l.(uint8) >= r.(uint8)
+		return l.(uint8) >= r.(uint8), nil
 	case datatypes.UInt16Type:
-		return l.(uint16) >= r.(uint16)
+		return l.(uint16) >= r.(uint16), nil
 	case datatypes.UInt32Type:
-		return l.(uint32) >= r.(uint32)
+		return l.(uint32) >= r.(uint32), nil
 	case datatypes.UInt64Type:
-		return l.(uint64) >= r.(uint64)
+		return l.(uint64) >= r.(uint64), nil
 	case datatypes.FloatType:
-		return l.(float32) >= r.(float32)
+		return l.(float32) >= r.(float32), nil
 	case datatypes.DoubleType:
-		return l.(float64) >= r.(float64)
+		return l.(float64) >= r.(float64), nil
 	case datatypes.StringType:
-		return l.(string) >= r.(string)
+		return l.(string) >= r.(string), nil
+	case datatypes.Date32Type:
+		return l.(arrow.Date32) >= r.(arrow.Date32), nil
+	case datatypes.TimestampType:
+		return l.(arrow.Timestamp) >= r.(arrow.Timestamp), nil
 	default:
-		panic(fmt.Sprintf("Unexpected arrow datatype: %v; l = %v; r = %v", dt, l, r))
+		return nil, fmt.Errorf("%w: %s in comparison", physicalplan.ErrUnsupportedType, dt)
 	}
 }
 
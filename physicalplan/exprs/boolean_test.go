@@ -0,0 +1,59 @@
+package exprs_test
+
+import (
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/physicalplan/exprs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBooleanExprThreeValuedLogic(t *testing.T) {
+	schema := *datatypes.NewSchema([]arrow.Field{
+		{Name: "l", Type: datatypes.BooleanType, Nullable: true},
+		{Name: "r", Type: datatypes.BooleanType, Nullable: true},
+	})
+	// every combination of true, false and null
+	values := []any{true, false, nil}
+	l := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.BooleanType)
+	r := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.BooleanType)
+	for _, lv := range values {
+		for _, rv := range values {
+			require.NoError(t, l.Append(lv))
+			require.NoError(t, r.Append(rv))
+		}
+	}
+	input := *datatypes.NewRecordBatch(schema, []datatypes.ColumnArray{l.Build(), r.Build()})
+
+	tests := []struct {
+		name     string
+		expr     exprs.BooleanExpr
+		expected []any
+	}{
+		{
+			name:     "and",
+			expr:     exprs.NewAndExpr(exprs.NewColumnIndexExpr(0), exprs.NewColumnIndexExpr(1)),
+			expected: []any{true, false, nil, false, false, false, nil, false, nil},
+		},
+		{
+			name:     "or",
+			expr:     exprs.NewOrExpr(exprs.NewColumnIndexExpr(0), exprs.NewColumnIndexExpr(1)),
+			expected: []any{true, true, true, true, false, nil, true, nil, nil},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := test.expr.Evaluate(input)
+			require.NoError(t, err)
+
+			actual := make([]any, result.Size())
+			for i := range result.Size() {
+				actual[i] = result.GetValue(i)
+			}
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
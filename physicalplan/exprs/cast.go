@@ -0,0 +1,343 @@
+package exprs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/physicalplan"
+)
+
+// CastExpr converts its input to another type. A value which can't be represented in the target
+// type, like the string "abc" cast to an integer or 300 cast to an int8, fails the evaluation with
+// physicalplan.ErrInvalidCast unless the cast is lenient, which turns the value into a null
+type CastExpr struct {
+	expr    physicalplan.PhysicalExpression
+	to      arrow.DataType
+	lenient bool
+}
+
+func NewCastExpr(expr physicalplan.PhysicalExpression, to arrow.DataType, lenient bool) CastExpr {
+	return CastExpr{expr, to, lenient}
+}
+
+func (c CastExpr) Evaluate(input datatypes.RecordBatch) (datatypes.ColumnArray, error) {
+	values, err := c.expr.Evaluate(input)
+	if err != nil {
+		return nil, err
+	}
+	if arrow.TypeEqual(values.GetType(), c.to) {
+		return values, nil
+	}
+
+	builder := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), c.to)
+	for i := range values.Size() {
+		v, err := CastValue(values.GetValue(i), c.to)
+		if err != nil {
+			if !c.lenient || !errors.Is(err, physicalplan.ErrInvalidCast) {
+				return nil, err
+			}
+			v = nil
+		}
+		if err := builder.Append(v); err != nil {
+			return nil, err
+		}
+	}
+	return builder.Build(), nil
+}
+
+func (c CastExpr) String() string {
+	if c.lenient {
+		return fmt.Sprintf("TRY_CAST(%s AS %s)", c.expr, c.to)
+	}
+	return fmt.Sprintf("CAST(%s AS %s)", c.expr, c.to)
+}
+
+// CastValue converts a value to the Go type holding the values of arrow type to, a null stays null.
+//
+// Strings are parsed, surrounding spaces are ignored. Floating point values are truncated towards
+// zero when cast to an integer and booleans are 1 or 0 as numbers. Dates are `YYYY-MM-DD` strings
+// and a timestamp cast to a date keeps the day, in UTC
+func CastValue(v any, to arrow.DataType) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch to.ID() {
+	case arrow.STRING:
+		return castToString(v, to)
+	case arrow.BOOL:
+		return castToBool(v, to)
+	case arrow.INT8, arrow.INT16, arrow.INT32, arrow.INT64:
+		return castToInt(v, to)
+	case arrow.UINT8, arrow.UINT16, arrow.UINT32, arrow.UINT64:
+		return castToUint(v, to)
+	case arrow.FLOAT32, arrow.FLOAT64:
+		return castToFloat(v, to)
+	case arrow.DATE32:
+		return castToDate(v, to)
+	case arrow.TIMESTAMP:
+		if !arrow.TypeEqual(to, datatypes.TimestampType) {
+			return nil, fmt.Errorf("%w: cast to %s", physicalplan.ErrUnsupportedType, to)
+		}
+		return castToTimestamp(v, to)
+	default:
+		return nil, fmt.Errorf("%w: cast to %s", physicalplan.ErrUnsupportedType, to)
+	}
+}
+
+func invalidCast(v any, to arrow.DataType) error {
+	return fmt.Errorf("%w: %v to %s", physicalplan.ErrInvalidCast, v, to)
+}
+
+func unsupportedCast(v any, to arrow.DataType) error {
+	return fmt.Errorf("%w: cast of %T to %s", physicalplan.ErrUnsupportedType, v, to)
+}
+
+// widen converts the numeric and boolean values to an int64, uint64 or float64
+func widen(v any) (any, bool) {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return int64(1), true
+		}
+		return int64(0), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint8:
+		return uint64(v), true
+	case uint16:
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+func castToString(v any, to arrow.DataType) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case arrow.Date32:
+		return datatypes.FormatDate(v), nil
+	case arrow.Timestamp:
+		return datatypes.FormatTimestamp(v), nil
+	default:
+		return nil, unsupportedCast(v, to)
+	}
+}
+
+func castToBool(v any, to arrow.DataType) (any, error) {
+	if s, ok := v.(string); ok {
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return nil, invalidCast(v, to)
+		}
+		return b, nil
+	}
+
+	wide, _ := widen(v)
+	switch w := wide.(type) {
+	case int64:
+		return w != 0, nil
+	case uint64:
+		return w != 0, nil
+	case float64:
+		return w != 0, nil
+	default:
+		return nil, unsupportedCast(v, to)
+	}
+}
+
+func castToInt(v any, to arrow.DataType) (any, error) {
+	var n int64
+	if s, ok := v.(string); ok {
+		parsed, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			return nil, invalidCast(v, to)
+		}
+		n = parsed
+	} else {
+		wide, _ := widen(v)
+		switch w := wide.(type) {
+		case int64:
+			n = w
+		case uint64:
+			if w > math.MaxInt64 {
+				return nil, invalidCast(v, to)
+			}
+			n = int64(w)
+		case float64:
+			// 2^63 is the first float64 above math.MaxInt64
+			if math.IsNaN(w) || w < math.MinInt64 || w >= math.Exp2(63) {
+				return nil, invalidCast(v, to)
+			}
+			n = int64(w)
+		default:
+			return nil, unsupportedCast(v, to)
+		}
+	}
+
+	switch to.ID() {
+	case arrow.INT8:
+		if n < math.MinInt8 || n > math.MaxInt8 {
+			return nil, invalidCast(v, to)
+		}
+		return int8(n), nil
+	case arrow.INT16:
+		if n < math.MinInt16 || n > math.MaxInt16 {
+			return nil, invalidCast(v, to)
+		}
+		return int16(n), nil
+	case arrow.INT32:
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, invalidCast(v, to)
+		}
+		return int32(n), nil
+	default:
+		return n, nil
+	}
+}
+
+func castToUint(v any, to arrow.DataType) (any, error) {
+	var n uint64
+	if s, ok := v.(string); ok {
+		parsed, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			return nil, invalidCast(v, to)
+		}
+		n = parsed
+	} else {
+		wide, _ := widen(v)
+		switch w := wide.(type) {
+		case int64:
+			if w < 0 {
+				return nil, invalidCast(v, to)
+			}
+			n = uint64(w)
+		case uint64:
+			n = w
+		case float64:
+			// 2^64 is the first float64 above math.MaxUint64
+			if math.IsNaN(w) || w <= -1 || w >= math.Exp2(64) {
+				return nil, invalidCast(v, to)
+			}
+			n = uint64(w)
+		default:
+			return nil, unsupportedCast(v, to)
+		}
+	}
+
+	switch to.ID() {
+	case arrow.UINT8:
+		if n > math.MaxUint8 {
+			return nil, invalidCast(v, to)
+		}
+		return uint8(n), nil
+	case arrow.UINT16:
+		if n > math.MaxUint16 {
+			return nil, invalidCast(v, to)
+		}
+		return uint16(n), nil
+	case arrow.UINT32:
+		if n > math.MaxUint32 {
+			return nil, invalidCast(v, to)
+		}
+		return uint32(n), nil
+	default:
+		return n, nil
+	}
+}
+
+func castToFloat(v any, to arrow.DataType) (any, error) {
+	var f float64
+	if s, ok := v.(string); ok {
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, invalidCast(v, to)
+		}
+		f = parsed
+	} else {
+		wide, _ := widen(v)
+		switch w := wide.(type) {
+		case int64:
+			f = float64(w)
+		case uint64:
+			f = float64(w)
+		case float64:
+			f = w
+		default:
+			return nil, unsupportedCast(v, to)
+		}
+	}
+
+	if to.ID() == arrow.FLOAT32 {
+		// an infinite input stays infinite, a finite one mustn't overflow to infinity
+		if math.Abs(f) > math.MaxFloat32 && !math.IsInf(f, 0) {
+			return nil, invalidCast(v, to)
+		}
+		return float32(f), nil
+	}
+	return f, nil
+}
+
+func castToDate(v any, to arrow.DataType) (any, error) {
+	switch v := v.(type) {
+	case string:
+		d, err := datatypes.ParseDate(strings.TrimSpace(v))
+		if err != nil {
+			return nil, invalidCast(v, to)
+		}
+		return d, nil
+	case arrow.Date32:
+		return v, nil
+	case arrow.Timestamp:
+		return arrow.Date32FromTime(time.UnixMicro(int64(v)).UTC()), nil
+	default:
+		return nil, unsupportedCast(v, to)
+	}
+}
+
+func castToTimestamp(v any, to arrow.DataType) (any, error) {
+	switch v := v.(type) {
+	case string:
+		ts, err := datatypes.ParseTimestamp(strings.TrimSpace(v))
+		if err != nil {
+			return nil, invalidCast(v, to)
+		}
+		return ts, nil
+	case arrow.Date32:
+		return arrow.Timestamp(v.ToTime().UnixMicro()), nil
+	case arrow.Timestamp:
+		return v, nil
+	default:
+		return nil, unsupportedCast(v, to)
+	}
+}
@@ -0,0 +1,107 @@
+package exprs_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/physicalplan"
+	"github.com/fastbyt3/query-engine/physicalplan/exprs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCastValue(t *testing.T) {
+	tests := []struct {
+		value    any
+		to       arrow.DataType
+		expected any
+	}{
+		{" 42 ", datatypes.Int8Type, int8(42)},
+		{"-7", datatypes.Int64Type, int64(-7)},
+		{"7", datatypes.UInt16Type, uint16(7)},
+		{"2.5", datatypes.DoubleType, 2.5},
+		{"2.5", datatypes.FloatType, float32(2.5)},
+		{math.Inf(-1), datatypes.FloatType, float32(math.Inf(-1))},
+		{"TRUE", datatypes.BooleanType, true},
+		{"0", datatypes.BooleanType, false},
+		{int64(300), datatypes.Int16Type, int16(300)},
+		{int32(5), datatypes.UInt64Type, uint64(5)},
+		{uint64(9), datatypes.Int32Type, int32(9)},
+		{-2.9, datatypes.Int32Type, int32(-2)},
+		{float32(3.5), datatypes.UInt8Type, uint8(3)},
+		{int64(3), datatypes.DoubleType, 3.0},
+		{true, datatypes.Int64Type, int64(1)},
+		{int8(0), datatypes.BooleanType, false},
+		{0.5, datatypes.BooleanType, true},
+		{int16(-12), datatypes.StringType, "-12"},
+		{float32(0.1), datatypes.StringType, "0.1"},
+		{false, datatypes.StringType, "false"},
+		{" 2024-02-29", datatypes.Date32Type, arrow.Date32(19782)},
+		{"2024-02-29 13:00:00", datatypes.TimestampType, arrow.Timestamp(1709211600000000)},
+		{arrow.Timestamp(1709211600000000), datatypes.Date32Type, arrow.Date32(19782)},
+		{arrow.Date32(19782), datatypes.TimestampType, arrow.Timestamp(1709164800000000)},
+		{arrow.Date32(19782), datatypes.StringType, "2024-02-29"},
+		{arrow.Timestamp(1709211600000000), datatypes.StringType, "2024-02-29 13:00:00"},
+		{nil, datatypes.Int64Type, nil},
+	}
+
+	for _, tt := range tests {
+		value, err := exprs.CastValue(tt.value, tt.to)
+		require.NoError(t, err, "%v to %s", tt.value, tt.to)
+		require.Equal(t, tt.expected, value, "%v to %s", tt.value, tt.to)
+	}
+}
+
+func TestCastValueInvalid(t *testing.T) {
+	tests := []struct {
+		value any
+		to    arrow.DataType
+	}{
+		{"abc", datatypes.Int64Type},
+		{"1.5", datatypes.Int32Type},
+		{"-1", datatypes.UInt32Type},
+		{"yes please", datatypes.BooleanType},
+		{"", datatypes.DoubleType},
+		{int64(128), datatypes.Int8Type},
+		{int64(-1), datatypes.UInt64Type},
+		{uint64(math.MaxUint64), datatypes.Int64Type},
+		{int64(70000), datatypes.UInt16Type},
+		{math.NaN(), datatypes.Int64Type},
+		{1e19, datatypes.Int64Type},
+		{1e39, datatypes.FloatType},
+		{"-1e39", datatypes.FloatType},
+		{"2024-02-30", datatypes.Date32Type},
+	}
+
+	for _, tt := range tests {
+		_, err := exprs.CastValue(tt.value, tt.to)
+		require.ErrorIs(t, err, physicalplan.ErrInvalidCast, "%v to %s", tt.value, tt.to)
+	}
+}
+
+func TestCastExprLenient(t *testing.T) {
+	schema := *datatypes.NewSchema([]arrow.Field{{Name: "salary", Type: datatypes.StringType, Nullable: true}})
+	builder := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.StringType)
+	for _, v := range []any{"10", "ten", nil, "-3"} {
+		require.NoError(t, builder.Append(v))
+	}
+	input := *datatypes.NewRecordBatch(schema, []datatypes.ColumnArray{builder.Build()})
+
+	_, err := exprs.NewCastExpr(exprs.NewColumnIndexExpr(0), datatypes.Int64Type, false).Evaluate(input)
+	require.ErrorIs(t, err, physicalplan.ErrInvalidCast)
+
+	lenient := exprs.NewCastExpr(exprs.NewColumnIndexExpr(0), datatypes.Int64Type, true)
+	require.Equal(t, "TRY_CAST(#0 AS int64)", lenient.String())
+
+	values, err := lenient.Evaluate(input)
+	require.NoError(t, err)
+	require.True(t, arrow.TypeEqual(datatypes.Int64Type, values.GetType()))
+
+	var got []any
+	for i := range values.Size() {
+		got = append(got, values.GetValue(i))
+	}
+	require.Equal(t, []any{int64(10), nil, nil, int64(-3)}, got)
+}
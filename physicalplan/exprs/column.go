@@ -4,14 +4,18 @@ import (
 	"fmt"
 
 	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/physicalplan"
 )
 
 type ColumnExpr struct {
 	index int
 }
 
-func (e ColumnExpr) Evaluate(input datatypes.RecordBatch) datatypes.ColumnArray {
-	return input.Field(e.index)
+func (e ColumnExpr) Evaluate(input datatypes.RecordBatch) (datatypes.ColumnArray, error) {
+	if e.index < 0 || e.index >= input.ColumnCount() {
+		return nil, fmt.Errorf("%w: %d for record batch with %d columns", physicalplan.ErrColumnIndexOutOfRange, e.index, input.ColumnCount())
+	}
+	return input.Field(e.index), nil
 }
 
 func (e ColumnExpr) String() string {
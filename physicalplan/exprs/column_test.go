@@ -0,0 +1,24 @@
+package exprs_test
+
+import (
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/physicalplan"
+	"github.com/fastbyt3/query-engine/physicalplan/exprs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnExprIndexOutOfRange(t *testing.T) {
+	schema := *datatypes.NewSchema([]arrow.Field{{Name: "a", Type: datatypes.Int64Type}})
+	b := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), datatypes.Int64Type)
+	require.NoError(t, b.Append(int64(1)))
+	input := *datatypes.NewRecordBatch(schema, []datatypes.ColumnArray{b.Build()})
+
+	for _, index := range []int{-1, 1} {
+		_, err := exprs.NewColumnIndexExpr(index).Evaluate(input)
+		require.ErrorIs(t, err, physicalplan.ErrColumnIndexOutOfRange)
+	}
+}
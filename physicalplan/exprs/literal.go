@@ -10,12 +10,12 @@ type LiteralLongExpr struct {
 	val int64
 }
 
-func (e LiteralLongExpr) Evaluate(input datatypes.RecordBatch) datatypes.ColumnArray {
+func (e LiteralLongExpr) Evaluate(input datatypes.RecordBatch) (datatypes.ColumnArray, error) {
 	return datatypes.NewLiteralValueArray(
 		datatypes.Int64Type,
 		e.val,
 		input.RowCount(),
-	)
+	), nil
 }
 
 func (e LiteralLongExpr) String() string {
@@ -26,16 +26,36 @@ func NewLiteralLongExpr(val int64) LiteralLongExpr {
 	return LiteralLongExpr{val}
 }
 
+type LiteralFloatExpr struct {
+	val float32
+}
+
+func (e LiteralFloatExpr) Evaluate(input datatypes.RecordBatch) (datatypes.ColumnArray, error) {
+	return datatypes.NewLiteralValueArray(
+		datatypes.FloatType,
+		e.val,
+		input.RowCount(),
+	), nil
+}
+
+func (e LiteralFloatExpr) String() string {
+	return fmt.Sprint(e.val)
+}
+
+func NewLiteralFloatExpr(v float32) LiteralFloatExpr {
+	return LiteralFloatExpr{v}
+}
+
 type LiteralDoubleExpr struct {
 	val float64
 }
 
-func (e LiteralDoubleExpr) Evaluate(input datatypes.RecordBatch) datatypes.ColumnArray {
+func (e LiteralDoubleExpr) Evaluate(input datatypes.RecordBatch) (datatypes.ColumnArray, error) {
 	return datatypes.NewLiteralValueArray(
-		datatypes.FloatType,
+		datatypes.DoubleType,
 		e.val,
 		input.RowCount(),
-	)
+	), nil
 }
 
 func (e LiteralDoubleExpr) String() string {
@@ -50,12 +70,12 @@ type LiteralStringExpr struct {
 	val string
 }
 
-func (e LiteralStringExpr) Evaluate(input datatypes.RecordBatch) datatypes.ColumnArray {
+func (e LiteralStringExpr) Evaluate(input datatypes.RecordBatch) (datatypes.ColumnArray, error) {
 	return datatypes.NewLiteralValueArray(
 		datatypes.StringType,
 		e.val,
 		input.RowCount(),
-	)
+	), nil
 }
 
 func (e LiteralStringExpr) String() string {
@@ -65,3 +85,23 @@ func (e LiteralStringExpr) String() string {
 func NewLiteralStringExpr(v string) LiteralStringExpr {
 	return LiteralStringExpr{v}
 }
+
+type LiteralBooleanExpr struct {
+	val bool
+}
+
+func (e LiteralBooleanExpr) Evaluate(input datatypes.RecordBatch) (datatypes.ColumnArray, error) {
+	return datatypes.NewLiteralValueArray(
+		datatypes.BooleanType,
+		e.val,
+		input.RowCount(),
+	), nil
+}
+
+func (e LiteralBooleanExpr) String() string {
+	return fmt.Sprint(e.val)
+}
+
+func NewLiteralBooleanExpr(v bool) LiteralBooleanExpr {
+	return LiteralBooleanExpr{v}
+}
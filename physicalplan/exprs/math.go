@@ -2,7 +2,7 @@ package exprs
 
 import (
 	"fmt"
-	"log/slog"
+	"math"
 
 	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/arrow/memory"
@@ -14,55 +14,57 @@ type MathExpr struct {
 	BinaryExpr
 }
 
-func (m MathExpr) Evaluate(input datatypes.RecordBatch) datatypes.ColumnArray {
-	ll := m.l.Evaluate(input)
-	rr := m.r.Evaluate(input)
-	if ll.Size() != rr.Size() || ll.GetType() != rr.GetType() {
-		slog.Error(
-			"LHS and RHS aren't compatible",
-			slog.Int("ll size", ll.Size()),
-			slog.Int("rr size", rr.Size()),
-			slog.String("ll datatype", ll.GetType().Name()),
-			slog.String("rr datatype", rr.GetType().Name()),
-		)
-		panic("LHS and RHS aren't compatible")
+func (m MathExpr) Evaluate(input datatypes.RecordBatch) (datatypes.ColumnArray, error) {
+	ll, rr, err := m.evaluateOperands(input)
+	if err != nil {
+		return nil, err
 	}
 
 	return m.binaryEvaluate(ll, rr)
 }
 
-func (m MathExpr) binaryEvaluate(l, r datatypes.ColumnArray) datatypes.ColumnArray {
+func (m MathExpr) binaryEvaluate(l, r datatypes.ColumnArray) (datatypes.ColumnArray, error) {
 	builder := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), l.GetType())
 	for i := range l.Size() {
-		builder.Append(m.evalFunc(l.GetValue(i), r.GetValue(i), l.GetType()))
+		lv, rv := l.GetValue(i), r.GetValue(i)
+		var res any
+		if lv != nil && rv != nil {
+			var err error
+			if res, err = m.evalFunc(lv, rv, l.GetType()); err != nil {
+				return nil, err
+			}
+		}
+		if err := builder.Append(res); err != nil {
+			return nil, err
+		}
 	}
-	return builder.Build()
+	return builder.Build(), nil
 }
 
-var AddEvalFunc = func(lData, rData any, arrowType arrow.DataType) any {
+var AddEvalFunc = func(lData, rData any, arrowType arrow.DataType) (any, error) {
 	switch arrowType {
 	case datatypes.Int8Type:
-		return lData.(int8) + rData.(int8)
+		return lData.(int8) + rData.(int8), nil
 	case datatypes.Int16Type:
-		return lData.(int16) + rData.(int16)
+		return lData.(int16) + rData.(int16), nil
 	case datatypes.Int32Type:
-		return lData.(int32) + rData.(int32)
+		return lData.(int32) + rData.(int32), nil
 	case datatypes.Int64Type:
-		return lData.(int64) + rData.(int64)
+		return lData.(int64) + rData.(int64), nil
 	case datatypes.UInt8Type:
-		return lData.(uint8) + rData.(uint8)
+		return lData.(uint8) + rData.(uint8), nil
 	case datatypes.UInt16Type:
-		return lData.(uint16) + rData.(uint16)
+		return lData.(uint16) + rData.(uint16), nil
 	case datatypes.UInt32Type:
-		return lData.(uint32) + rData.(uint32)
+		return lData.(uint32) + rData.(uint32), nil
 	case datatypes.UInt64Type:
-		return lData.(uint64) + rData.(uint64)
+		return lData.(uint64) + rData.(uint64), nil
 	case datatypes.FloatType:
-		return lData.(float32) + rData.(float32)
+		return lData.(float32) + rData.(float32), nil
 	case datatypes.DoubleType:
-		return lData.(float64) + rData.(float64)
+		return lData.(float64) + rData.(float64), nil
 	default:
-		panic(fmt.Sprintf("Unsupported data type in math expression: %s", arrowType))
+		return nil, fmt.Errorf("%w: %s in math expression", physicalplan.ErrUnsupportedType, arrowType)
 	}
 }
 
@@ -70,30 +72,30 @@ func NewAddExpr(l, r physicalplan.PhysicalExpression) MathExpr {
 	return MathExpr{BinaryExpr{"add", l, r, "+", AddEvalFunc}}
 }
 
-var SubtractEvalFunc = func(lData, rData any, arrowType arrow.DataType) any {
+var SubtractEvalFunc = func(lData, rData any, arrowType arrow.DataType) (any, error) {
 	switch arrowType {
 	case datatypes.Int8Type:
-		return lData.(int8) - rData.(int8)
+		return lData.(int8) - rData.(int8), nil
 	case datatypes.Int16Type:
-		return lData.(int16) - rData.(int16)
+		return lData.(int16) - rData.(int16), nil
 	case datatypes.Int32Type:
-		return lData.(int32) - rData.(int32)
+		return lData.(int32) - rData.(int32), nil
 	case datatypes.Int64Type:
-		return lData.(int64) - rData.(int64)
+		return lData.(int64) - rData.(int64), nil
 	case datatypes.UInt8Type:
-		return lData.(uint8) - rData.(uint8)
+		return lData.(uint8) - rData.(uint8), nil
 	case datatypes.UInt16Type:
-		return lData.(uint16) - rData.(uint16)
+		return lData.(uint16) - rData.(uint16), nil
 	case datatypes.UInt32Type:
-		return lData.(uint32) - rData.(uint32)
+		return lData.(uint32) - rData.(uint32), nil
 	case datatypes.UInt64Type:
-		return lData.(uint64) - rData.(uint64)
+		return lData.(uint64) - rData.(uint64), nil
 	case datatypes.FloatType:
-		return lData.(float32) - rData.(float32)
+		return lData.(float32) - rData.(float32), nil
 	case datatypes.DoubleType:
-		return lData.(float64) - rData.(float64)
+		return lData.(float64) - rData.(float64), nil
 	default:
-		panic(fmt.Sprintf("Unsupported data type in math expression: %s", arrowType))
+		return nil, fmt.Errorf("%w: %s in math expression", physicalplan.ErrUnsupportedType, arrowType)
 	}
 }
 
@@ -101,30 +103,30 @@ func NewSubtractExpr(l, r physicalplan.PhysicalExpression) MathExpr {
 	return MathExpr{BinaryExpr{"subtract", l, r, "-", SubtractEvalFunc}}
 }
 
-var MultiplyEvalFunc = func(lData, rData any, arrowType arrow.DataType) any {
+var MultiplyEvalFunc = func(lData, rData any, arrowType arrow.DataType) (any, error) {
 	switch arrowType {
 	case datatypes.Int8Type:
-		return lData.(int8) * rData.(int8)
+		return lData.(int8) * rData.(int8), nil
 	case datatypes.Int16Type:
-		return lData.(int16) * rData.(int16)
+		return lData.(int16) * rData.(int16), nil
 	case datatypes.Int32Type:
-		return lData.(int32) * rData.(int32)
+		return lData.(int32) * rData.(int32), nil
 	case datatypes.Int64Type:
-		return lData.(int64) * rData.(int64)
+		return lData.(int64) * rData.(int64), nil
 	case datatypes.UInt8Type:
-		return lData.(uint8) * rData.(uint8)
+		return lData.(uint8) * rData.(uint8), nil
 	case datatypes.UInt16Type:
-		return lData.(uint16) * rData.(uint16)
+		return lData.(uint16) * rData.(uint16), nil
 	case datatypes.UInt32Type:
-		return lData.(uint32) * rData.(uint32)
+		return lData.(uint32) * rData.(uint32), nil
 	case datatypes.UInt64Type:
-		return lData.(uint64) * rData.(uint64)
+		return lData.(uint64) * rData.(uint64), nil
 	case datatypes.FloatType:
-		return lData.(float32) * rData.(float32)
+		return lData.(float32) * rData.(float32), nil
 	case datatypes.DoubleType:
-		return lData.(float64) * rData.(float64)
+		return lData.(float64) * rData.(float64), nil
 	default:
-		panic(fmt.Sprintf("Unsupported data type in math expression: %s", arrowType))
+		return nil, fmt.Errorf("%w: %s in math expression", physicalplan.ErrUnsupportedType, arrowType)
 	}
 }
 
@@ -132,33 +134,112 @@ func NewMultiplyExpr(l, r physicalplan.PhysicalExpression) MathExpr {
 	return MathExpr{BinaryExpr{"multiply", l, r, "*", MultiplyEvalFunc}}
 }
 
-var DivideEvalFunc = func(lData, rData any, arrowType arrow.DataType) any {
+var DivideEvalFunc = func(lData, rData any, arrowType arrow.DataType) (any, error) {
 	switch arrowType {
 	case datatypes.Int8Type:
-		return lData.(int8) / rData.(int8)
+		if rData.(int8) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(int8) / rData.(int8), nil
 	case datatypes.Int16Type:
-		return lData.(int16) / rData.(int16)
+		if rData.(int16) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(int16) / rData.(int16), nil
 	case datatypes.Int32Type:
-		return lData.(int32) / rData.(int32)
+		if rData.(int32) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(int32) / rData.(int32), nil
 	case datatypes.Int64Type:
-		return lData.(int64) / rData.(int64)
+		if rData.(int64) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(int64) / rData.(int64), nil
 	case datatypes.UInt8Type:
-		return lData.(uint8) / rData.(uint8)
+		if rData.(uint8) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(uint8) / rData.(uint8), nil
 	case datatypes.UInt16Type:
-		return lData.(uint16) / rData.(uint16)
+		if rData.(uint16) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(uint16) / rData.(uint16), nil
 	case datatypes.UInt32Type:
-		return lData.(uint32) / rData.(uint32)
+		if rData.(uint32) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(uint32) / rData.(uint32), nil
 	case datatypes.UInt64Type:
-		return lData.(uint64) / rData.(uint64)
+		if rData.(uint64) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(uint64) / rData.(uint64), nil
 	case datatypes.FloatType:
-		return lData.(float32) / rData.(float32)
+		return lData.(float32) / rData.(float32), nil
 	case datatypes.DoubleType:
-		return lData.(float64) / rData.(float64)
+		return lData.(float64) / rData.(float64), nil
 	default:
-		panic(fmt.Sprintf("Unsupported data type in math expression: %s", arrowType))
+		return nil, fmt.Errorf("%w: %s in math expression", physicalplan.ErrUnsupportedType, arrowType)
 	}
 }
 
 func NewDivideExpr(l, r physicalplan.PhysicalExpression) MathExpr {
 	return MathExpr{BinaryExpr{"divide", l, r, "/", DivideEvalFunc}}
 }
+
+var ModuloEvalFunc = func(lData, rData any, arrowType arrow.DataType) (any, error) {
+	switch arrowType {
+	case datatypes.Int8Type:
+		if rData.(int8) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(int8) % rData.(int8), nil
+	case datatypes.Int16Type:
+		if rData.(int16) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(int16) % rData.(int16), nil
+	case datatypes.Int32Type:
+		if rData.(int32) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(int32) % rData.(int32), nil
+	case datatypes.Int64Type:
+		if rData.(int64) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(int64) % rData.(int64), nil
+	case datatypes.UInt8Type:
+		if rData.(uint8) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(uint8) % rData.(uint8), nil
+	case datatypes.UInt16Type:
+		if rData.(uint16) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(uint16) % rData.(uint16), nil
+	case datatypes.UInt32Type:
+		if rData.(uint32) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(uint32) % rData.(uint32), nil
+	case datatypes.UInt64Type:
+		if rData.(uint64) == 0 {
+			return nil, physicalplan.ErrDivisionByZero
+		}
+		return lData.(uint64) % rData.(uint64), nil
+	case datatypes.FloatType:
+		return float32(math.Mod(float64(lData.(float32)), float64(rData.(float32)))), nil
+	case datatypes.DoubleType:
+		return math.Mod(lData.(float64), rData.(float64)), nil
+	default:
+		return nil, fmt.Errorf("%w: %s in math expression", physicalplan.ErrUnsupportedType, arrowType)
+	}
+}
+
+func NewModuloExpr(l, r physicalplan.PhysicalExpression) MathExpr {
+	return MathExpr{BinaryExpr{"modulo", l, r, "%", ModuloEvalFunc}}
+}
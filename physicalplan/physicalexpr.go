@@ -7,5 +7,5 @@ import "github.com/fastbyt3/query-engine/datatypes"
 //
 // Evaluated against record batches and results in columns
 type PhysicalExpression interface {
-	Evaluate(input datatypes.RecordBatch) datatypes.ColumnArray
+	Evaluate(input datatypes.RecordBatch) (datatypes.ColumnArray, error)
 }
@@ -1,11 +1,15 @@
 package physicalplan
 
 import (
+	"context"
 	"iter"
 
 	"github.com/fastbyt3/query-engine/datatypes"
 )
 
+// DefaultBatchSize is the number of rows in a record batch when no batch size is configured
+const DefaultBatchSize = 1024
+
 // Physical plans go into "how" to do the underlying logical plan
 //
 // Logical plans and physical plans have an one to many relationship. For eg. there could be multiple
@@ -15,6 +19,8 @@ import (
 // Logical plan -> Aggregate; Physical plan(s): Group aggregate, Hash aggregate, etc.
 type PhysicalPlan interface {
 	Schema() datatypes.Schema
-	Execute() iter.Seq[datatypes.RecordBatch]
+	// Execute lazily runs the plan, iteration stops after the first non nil error is yielded.
+	// Once ctx is done the plan stops processing and yields the context's error
+	Execute(ctx context.Context) iter.Seq2[datatypes.RecordBatch, error]
 	Children() []PhysicalPlan
 }
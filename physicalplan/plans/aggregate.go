@@ -1,6 +1,7 @@
 package plans
 
 import (
+	"context"
 	"fmt"
 	"iter"
 
@@ -12,7 +13,7 @@ import (
 
 // HashAggregate plan must process all incoming batches and maintain a hash map of accumulators
 // and update the acc for each row being processed. Finally the results of the accumulators are
-// used to create one record batch at end containing results of aggregate query
+// used to create record batches (of at most `batchSize` rows) containing results of aggregate query
 type HashAggregateExec struct {
 	input physicalplan.PhysicalPlan
 
@@ -24,73 +25,170 @@ type HashAggregateExec struct {
 
 	// schema represents group and aggregate expressions
 	schema datatypes.Schema
+
+	// max number of rows in every output record batch
+	batchSize int
+}
+
+func NewHashAggregateExec(
+	input physicalplan.PhysicalPlan,
+	groupExprs []physicalplan.PhysicalExpression,
+	aggregateExprs []exprs.AggregateExpression,
+	schema datatypes.Schema,
+	batchSize int,
+) HashAggregateExec {
+	if batchSize <= 0 {
+		batchSize = physicalplan.DefaultBatchSize
+	}
+	return HashAggregateExec{input, groupExprs, aggregateExprs, schema, batchSize}
 }
 
 func (h HashAggregateExec) Children() []physicalplan.PhysicalPlan {
 	return []physicalplan.PhysicalPlan{h.input}
 }
 
-func (h HashAggregateExec) Execute() iter.Seq[datatypes.RecordBatch] {
-	// hashmap := make(map[[]any][]Accumulator) // this can't be created in go since lists aren't valid keys for a map
-	rowToAccMap := make(map[string][]exprs.Accumulator)
-	rowHashValMap := make(map[string][]any)
+// Execute consumes the entire input before producing any output, which only happens once
+// the returned iterator is consumed. Groups are emitted in the order they were first seen
+func (h HashAggregateExec) Execute(ctx context.Context) iter.Seq2[datatypes.RecordBatch, error] {
+	return func(yield func(datatypes.RecordBatch, error) bool) {
+		// hashmap := make(map[[]any][]Accumulator) // this can't be created in go since lists aren't valid keys for a map
+		rowToAccMap := make(map[string][]exprs.Accumulator)
+		rowHashValMap := make(map[string][]any)
+		var groupOrder []string
+
+		// reused across rows so encoding a group key doesn't allocate
+		var keyBuf []byte
+
+		for rb, err := range h.input.Execute(ctx) {
+			if err == nil {
+				err = ctx.Err()
+			}
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+
+			groupKeys := make([]datatypes.ColumnArray, len(h.groupExprs))
+			for i, groupExpr := range h.groupExprs {
+				if groupKeys[i], err = groupExpr.Evaluate(rb); err != nil {
+					yield(datatypes.RecordBatch{}, err)
+					return
+				}
+			}
 
-	for rb := range h.input.Execute() {
-		groupKeys := make([]datatypes.ColumnArray, len(h.groupExprs))
-		for i, groupExpr := range h.groupExprs {
-			groupKeys[i] = groupExpr.Evaluate(rb)
+			aggrInputValues := make([]datatypes.ColumnArray, len(h.aggregateExprs))
+			for i, aggrExpr := range h.aggregateExprs {
+				if aggrInputValues[i], err = aggrExpr.InputExpression().Evaluate(rb); err != nil {
+					yield(datatypes.RecordBatch{}, err)
+					return
+				}
+			}
+
+			for rowIdx := range rb.RowCount() {
+				select {
+				case <-ctx.Done():
+					yield(datatypes.RecordBatch{}, ctx.Err())
+					return
+				default:
+				}
+
+				// since we can't directly have []any as a key in map, the row's group values are
+				// encoded into a binary key (see datatypes.AppendRowKey)
+				if keyBuf, err = datatypes.AppendRowKey(keyBuf[:0], groupKeys, rowIdx); err != nil {
+					yield(datatypes.RecordBatch{}, err)
+					return
+				}
+
+				rowAccumulators, exists := rowToAccMap[string(keyBuf)]
+				if !exists {
+					rowKeyEnc := string(keyBuf)
+					rowKey := make([]any, len(groupKeys))
+					for j, groupKey := range groupKeys {
+						rowKey[j] = groupKey.GetValue(rowIdx)
+					}
+
+					rowAccumulators = h.createAccumulators()
+					rowToAccMap[rowKeyEnc] = rowAccumulators
+					rowHashValMap[rowKeyEnc] = rowKey
+					groupOrder = append(groupOrder, rowKeyEnc)
+				}
+
+				for i, acc := range rowAccumulators {
+					val := aggrInputValues[i].GetValue(rowIdx)
+					if err := acc.Accumulate(val); err != nil {
+						yield(datatypes.RecordBatch{}, err)
+						return
+					}
+				}
+			}
+		}
+
+		// an aggregate without GROUP BY always produces a single row, even for an empty input
+		if len(h.groupExprs) == 0 && len(groupOrder) == 0 {
+			rowKeyEnc := ""
+			rowToAccMap[rowKeyEnc] = h.createAccumulators()
+			rowHashValMap[rowKeyEnc] = []any{}
+			groupOrder = append(groupOrder, rowKeyEnc)
 		}
 
-		aggrInputValues := make([]datatypes.ColumnArray, len(h.aggregateExprs))
-		for i, aggrExpr := range h.aggregateExprs {
-			aggrInputValues[i] = aggrExpr.InputExpression().Evaluate(rb)
+		// create record batches with final aggregate values
+		builders := make([]datatypes.ArrowArrayBuilder, h.schema.NumFields())
+		for i, field := range h.schema.Fields() {
+			builders[i] = datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), field.Type)
 		}
 
-		for rowIdx := range rb.RowCount() {
-			// create hash key for each row
-			rowKey := make([]any, len(groupKeys))
-			for j, groupKey := range groupKeys {
-				rowKey[j] = groupKey.GetValue(rowIdx)
+		rowsInBatch := 0
+		for _, rowKeyEnc := range groupOrder {
+			if rowsInBatch == 0 {
+				if err := ctx.Err(); err != nil {
+					yield(datatypes.RecordBatch{}, err)
+					return
+				}
 			}
 
-			// since we can't directly have []any as a key in map, we encode it to string of values in slice
-			rowKeyEnc := h.encodeCols(rowKey)
+			for i, col := range rowHashValMap[rowKeyEnc] {
+				if err := builders[i].Append(col); err != nil {
+					yield(datatypes.RecordBatch{}, err)
+					return
+				}
+			}
 
-			rowAccumulators, exists := rowToAccMap[rowKeyEnc]
-			if !exists {
-				rowAccumulators = make([]exprs.Accumulator, len(h.aggregateExprs))
-				for i, aggrExpr := range h.aggregateExprs {
-					rowAccumulators[i] = aggrExpr.CreateAccumulator()
+			for i, acc := range rowToAccMap[rowKeyEnc] {
+				if err := builders[len(h.groupExprs)+i].Append(acc.FinalValue()); err != nil {
+					yield(datatypes.RecordBatch{}, err)
+					return
 				}
-				rowToAccMap[rowKeyEnc] = rowAccumulators
-				rowHashValMap[rowKeyEnc] = rowKey
 			}
+			rowsInBatch++
 
-			for i, acc := range rowAccumulators {
-				val := aggrInputValues[i].GetValue(rowIdx)
-				acc.Accumulate(val)
+			if rowsInBatch == h.batchSize {
+				if !yield(h.createBatch(builders), nil) {
+					return
+				}
+				rowsInBatch = 0
 			}
 		}
-	}
-
-	// create record batch with final aggregate values
-	builders := make([]datatypes.ArrowArrayBuilder, h.schema.NumFields())
-	for i, field := range h.schema.Fields() {
-		builders[i] = datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), field.Type)
-	}
 
-	for rowKeyEnc, rowKey := range rowHashValMap {
-		for i, col := range rowKey {
-			builders[i].Append(col)
+		if rowsInBatch > 0 {
+			yield(h.createBatch(builders), nil)
 		}
+	}
+}
 
-		rowAccumulators, _ := rowToAccMap[rowKeyEnc]
-		for i, acc := range rowAccumulators {
-			builders[len(h.groupExprs)+i].Append(acc.FinalValue())
-		}
+func (h HashAggregateExec) createAccumulators() []exprs.Accumulator {
+	accumulators := make([]exprs.Accumulator, len(h.aggregateExprs))
+	for i, aggrExpr := range h.aggregateExprs {
+		accumulators[i] = aggrExpr.CreateAccumulator()
 	}
+	return accumulators
+}
 
-	panic("unimpl")
+func (h HashAggregateExec) createBatch(builders []datatypes.ArrowArrayBuilder) datatypes.RecordBatch {
+	fields := make([]datatypes.ColumnArray, len(builders))
+	for i := range builders {
+		fields[i] = builders[i].Build()
+	}
+	return *datatypes.NewRecordBatch(h.schema, fields)
 }
 
 func (h HashAggregateExec) Schema() datatypes.Schema {
@@ -100,11 +198,3 @@ func (h HashAggregateExec) Schema() datatypes.Schema {
 func (h HashAggregateExec) String() string {
 	return fmt.Sprintf("HashAggregateExec: groupExpr=%v, aggrExpr=%v", h.groupExprs, h.aggregateExprs)
 }
-
-func (h *HashAggregateExec) encodeCols(cols []any) string {
-	s := ""
-	for _, c := range cols {
-		s += fmt.Sprint(c)
-	}
-	return s
-}
@@ -0,0 +1,242 @@
+package plans_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/fastbyt3/query-engine/datasources"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/physicalplan"
+	"github.com/fastbyt3/query-engine/physicalplan/exprs"
+	"github.com/fastbyt3/query-engine/physicalplan/plans"
+	"github.com/stretchr/testify/require"
+)
+
+var employeeSchema = *datatypes.NewSchema([]arrow.Field{
+	{Name: "state", Type: datatypes.StringType},
+	{Name: "salary", Type: datatypes.Int64Type},
+})
+
+func newBatch(t *testing.T, schema datatypes.Schema, columns ...[]any) datatypes.RecordBatch {
+	fields := make([]datatypes.ColumnArray, len(columns))
+	for i, values := range columns {
+		builder := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), schema.Field(i).Type)
+		require.NoError(t, builder.AppendValues(values...))
+		fields[i] = builder.Build()
+	}
+	return *datatypes.NewRecordBatch(schema, fields)
+}
+
+// unsupportedExpr evaluates to values no array can hold
+type unsupportedExpr struct{}
+
+func (unsupportedExpr) Evaluate(input datatypes.RecordBatch) (datatypes.ColumnArray, error) {
+	return datatypes.NewLiteralValueArray(datatypes.StringType, struct{}{}, input.RowCount()), nil
+}
+
+func newEmployeeScan(batches ...datatypes.RecordBatch) plans.ScanExec {
+	return plans.NewScanExec(datasources.NewMemoryDatasource(employeeSchema, batches), []string{}, nil)
+}
+
+func collectRows(t *testing.T, plan physicalplan.PhysicalPlan) ([][]any, []int) {
+	var rows [][]any
+	var batchSizes []int
+	for rb, err := range plan.Execute(context.Background()) {
+		require.NoError(t, err)
+		batchSizes = append(batchSizes, rb.RowCount())
+		for i := range rb.RowCount() {
+			row := make([]any, rb.ColumnCount())
+			for j := range rb.ColumnCount() {
+				row[j] = rb.Field(j).GetValue(i)
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, batchSizes
+}
+
+func TestHashAggregateGroupBy(t *testing.T) {
+	scan := newEmployeeScan(
+		newBatch(t, employeeSchema, []any{"CO", "CA", "CO"}, []any{int64(10), int64(12), int64(11)}),
+		newBatch(t, employeeSchema, []any{"CA", "NY"}, []any{int64(8), nil}),
+	)
+	schema := *datatypes.NewSchema([]arrow.Field{
+		{Name: "state", Type: datatypes.StringType},
+		{Name: "MAX", Type: datatypes.Int64Type},
+		{Name: "COUNT", Type: datatypes.Int64Type},
+	})
+
+	aggregate := plans.NewHashAggregateExec(
+		scan,
+		[]physicalplan.PhysicalExpression{exprs.NewColumnIndexExpr(0)},
+		[]exprs.AggregateExpression{
+			exprs.NewMaxExpr(exprs.NewColumnIndexExpr(1)),
+			exprs.NewCountExpr(exprs.NewColumnIndexExpr(1)),
+		},
+		schema,
+		2,
+	)
+
+	rows, batchSizes := collectRows(t, aggregate)
+	require.Equal(t, []int{2, 1}, batchSizes)
+	require.Equal(t, [][]any{
+		{"CO", int64(11), int64(2)},
+		{"CA", int64(12), int64(2)},
+		{"NY", nil, int64(0)},
+	}, rows)
+}
+
+func TestHashAggregateWithoutGroupBy(t *testing.T) {
+	schema := *datatypes.NewSchema([]arrow.Field{
+		{Name: "SUM", Type: datatypes.Int64Type},
+		{Name: "COUNT", Type: datatypes.Int64Type},
+	})
+	newAggregate := func(scan plans.ScanExec) plans.HashAggregateExec {
+		return plans.NewHashAggregateExec(
+			scan,
+			[]physicalplan.PhysicalExpression{},
+			[]exprs.AggregateExpression{
+				exprs.NewSumExpr(exprs.NewColumnIndexExpr(1)),
+				exprs.NewCountExpr(exprs.NewColumnIndexExpr(1)),
+			},
+			schema,
+			1024,
+		)
+	}
+
+	rows, _ := collectRows(t, newAggregate(newEmployeeScan(
+		newBatch(t, employeeSchema, []any{"CO", "CA"}, []any{int64(10), int64(12)}),
+	)))
+	require.Equal(t, [][]any{{int64(22), int64(2)}}, rows)
+
+	// global aggregate over an empty input still produces a single row
+	rows, _ = collectRows(t, newAggregate(newEmployeeScan()))
+	require.Equal(t, [][]any{{nil, int64(0)}}, rows)
+}
+
+func TestHashAggregateCountDistinct(t *testing.T) {
+	// duplicates span several batches
+	scan := newEmployeeScan(
+		newBatch(t, employeeSchema, []any{"CO", "CA", "CO"}, []any{int64(10), int64(12), int64(10)}),
+		newBatch(t, employeeSchema, []any{"CO", "CA", "CA"}, []any{int64(11), nil, int64(12)}),
+		newBatch(t, employeeSchema, []any{"CO"}, []any{int64(10)}),
+	)
+	schema := *datatypes.NewSchema([]arrow.Field{
+		{Name: "state", Type: datatypes.StringType},
+		{Name: "COUNT(DISTINCT salary)", Type: datatypes.Int64Type},
+		{Name: "COUNT(salary)", Type: datatypes.Int64Type},
+	})
+
+	aggregate := plans.NewHashAggregateExec(
+		scan,
+		[]physicalplan.PhysicalExpression{exprs.NewColumnIndexExpr(0)},
+		[]exprs.AggregateExpression{
+			exprs.NewCountDistinctExpr(exprs.NewColumnIndexExpr(1)),
+			exprs.NewCountExpr(exprs.NewColumnIndexExpr(1)),
+		},
+		schema,
+		0,
+	)
+
+	rows, _ := collectRows(t, aggregate)
+	require.Equal(t, [][]any{
+		{"CO", int64(2), int64(4)},
+		{"CA", int64(1), int64(2)},
+	}, rows)
+}
+
+func TestHashAggregateCountDistinctUnsupportedValue(t *testing.T) {
+	scan := newEmployeeScan(newBatch(t, employeeSchema, []any{"CO"}, []any{int64(10)}))
+	aggregate := plans.NewHashAggregateExec(
+		scan,
+		[]physicalplan.PhysicalExpression{},
+		[]exprs.AggregateExpression{exprs.NewCountDistinctExpr(unsupportedExpr{})},
+		*datatypes.NewSchema([]arrow.Field{{Name: "COUNT(DISTINCT)", Type: datatypes.Int64Type}}),
+		1024,
+	)
+
+	var err error
+	for _, err = range aggregate.Execute(context.Background()) {
+	}
+	require.ErrorIs(t, err, datatypes.ErrUnsupportedValue)
+}
+
+func TestHashAggregateGroupKeysDontCollide(t *testing.T) {
+	schema := *datatypes.NewSchema([]arrow.Field{
+		{Name: "a", Type: datatypes.StringType},
+		{Name: "b", Type: datatypes.StringType},
+	})
+	scan := plans.NewScanExec(datasources.NewMemoryDatasource(schema, []datatypes.RecordBatch{
+		newBatch(t, schema, []any{"1", "12", nil, "<nil>"}, []any{"23", "3", "x", "x"}),
+	}), []string{}, nil)
+
+	aggregate := plans.NewHashAggregateExec(
+		scan,
+		[]physicalplan.PhysicalExpression{exprs.NewColumnIndexExpr(0), exprs.NewColumnIndexExpr(1)},
+		[]exprs.AggregateExpression{exprs.NewCountExpr(exprs.NewColumnIndexExpr(1))},
+		*datatypes.NewSchema([]arrow.Field{
+			{Name: "a", Type: datatypes.StringType},
+			{Name: "b", Type: datatypes.StringType},
+			{Name: "COUNT", Type: datatypes.Int64Type},
+		}),
+		1024,
+	)
+
+	rows, _ := collectRows(t, aggregate)
+	require.Equal(t, [][]any{
+		{"1", "23", int64(1)},
+		{"12", "3", int64(1)},
+		{nil, "x", int64(1)},
+		{"<nil>", "x", int64(1)},
+	}, rows)
+}
+
+func TestHashAggregateAccumulatorError(t *testing.T) {
+	scan := newEmployeeScan(newBatch(t, employeeSchema, []any{"CO"}, []any{int64(10)}))
+	aggregate := plans.NewHashAggregateExec(
+		scan,
+		[]physicalplan.PhysicalExpression{},
+		[]exprs.AggregateExpression{exprs.NewAvgExpr(exprs.NewColumnIndexExpr(0))},
+		*datatypes.NewSchema([]arrow.Field{{Name: "AVG", Type: datatypes.DoubleType}}),
+		1024,
+	)
+
+	var err error
+	for _, err = range aggregate.Execute(context.Background()) {
+	}
+	require.ErrorIs(t, err, physicalplan.ErrUnsupportedType)
+}
+
+func TestHashAggregateUnsupportedValue(t *testing.T) {
+	scan := newEmployeeScan(newBatch(t, employeeSchema, []any{"CO"}, []any{int64(10)}))
+	tests := []struct {
+		name      string
+		groupExpr physicalplan.PhysicalExpression
+		groupType arrow.DataType
+	}{
+		{"group key", unsupportedExpr{}, datatypes.StringType},
+		{"output type", exprs.NewColumnIndexExpr(0), datatypes.Int64Type},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aggregate := plans.NewHashAggregateExec(
+				scan,
+				[]physicalplan.PhysicalExpression{tt.groupExpr},
+				[]exprs.AggregateExpression{exprs.NewCountExpr(exprs.NewColumnIndexExpr(1))},
+				*datatypes.NewSchema([]arrow.Field{
+					{Name: "state", Type: tt.groupType},
+					{Name: "COUNT", Type: datatypes.Int64Type},
+				}),
+				1024,
+			)
+
+			var err error
+			for _, err = range aggregate.Execute(context.Background()) {
+			}
+			require.ErrorIs(t, err, datatypes.ErrUnsupportedValue)
+		})
+	}
+}
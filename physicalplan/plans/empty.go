@@ -0,0 +1,38 @@
+package plans
+
+import (
+	"context"
+	"iter"
+
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/physicalplan"
+)
+
+// EmptyExec produces no record batch at all, only its schema is known
+type EmptyExec struct {
+	schema datatypes.Schema
+}
+
+func NewEmptyExec(schema datatypes.Schema) EmptyExec {
+	return EmptyExec{schema}
+}
+
+func (e EmptyExec) Children() []physicalplan.PhysicalPlan {
+	return []physicalplan.PhysicalPlan{}
+}
+
+func (e EmptyExec) Execute(ctx context.Context) iter.Seq2[datatypes.RecordBatch, error] {
+	return func(yield func(datatypes.RecordBatch, error) bool) {
+		if err := ctx.Err(); err != nil {
+			yield(datatypes.RecordBatch{}, err)
+		}
+	}
+}
+
+func (e EmptyExec) Schema() datatypes.Schema {
+	return e.schema
+}
+
+func (e EmptyExec) String() string {
+	return "EmptyExec"
+}
@@ -0,0 +1,412 @@
+package plans
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/physicalplan"
+)
+
+type JoinType int
+
+const (
+	InnerJoin JoinType = iota
+	LeftJoin
+	RightJoin
+	FullJoin
+	// LeftSemiJoin produces the left rows which have at least one match
+	LeftSemiJoin
+	// LeftAntiJoin produces the left rows which have no match
+	LeftAntiJoin
+	// NullAwareLeftAntiJoin is a LeftAntiJoin with the semantics of `NOT IN`: nothing is produced
+	// when a right key is null and left rows with a null key are only produced when the right
+	// input is empty
+	NullAwareLeftAntiJoin
+)
+
+func (j JoinType) String() string {
+	switch j {
+	case InnerJoin:
+		return "INNER"
+	case LeftJoin:
+		return "LEFT"
+	case RightJoin:
+		return "RIGHT"
+	case FullJoin:
+		return "FULL"
+	case LeftSemiJoin:
+		return "LEFT SEMI"
+	case LeftAntiJoin:
+		return "LEFT ANTI"
+	case NullAwareLeftAntiJoin:
+		return "LEFT ANTI NULL AWARE"
+	default:
+		return fmt.Sprintf("JoinType(%d)", int(j))
+	}
+}
+
+// HashJoinExec loads the whole right input into a hash table keyed by the right join keys
+// (build side), then streams the left input and looks up every row in the table (probe side).
+//
+// Rows are joined when their keys are equal, a null key never matches, and when the optional
+// filter evaluates to true against the joined row. Unmatched rows of the outer side(s) are
+// padded with nulls, semi and anti joins only produce the left rows
+type HashJoinExec struct {
+	left  physicalplan.PhysicalPlan
+	right physicalplan.PhysicalPlan
+
+	joinType JoinType
+
+	// leftKeys are evaluated against the left input and rightKeys against the right input
+	leftKeys  []physicalplan.PhysicalExpression
+	rightKeys []physicalplan.PhysicalExpression
+
+	// filter is evaluated against the joined rows, nil when the join only compares keys
+	filter physicalplan.PhysicalExpression
+
+	// schema has the fields of the left input followed by the fields of the right input, or only
+	// the left fields for semi and anti joins
+	schema datatypes.Schema
+
+	// joinedSchema always has the fields of both inputs, the filter reads rows of that schema
+	joinedSchema datatypes.Schema
+	leftWidth    int
+
+	// max number of rows in every output record batch
+	batchSize int
+}
+
+func NewHashJoinExec(
+	left, right physicalplan.PhysicalPlan,
+	joinType JoinType,
+	leftKeys, rightKeys []physicalplan.PhysicalExpression,
+	filter physicalplan.PhysicalExpression,
+	schema datatypes.Schema,
+	batchSize int,
+) HashJoinExec {
+	if batchSize <= 0 {
+		batchSize = physicalplan.DefaultBatchSize
+	}
+	leftSchema, rightSchema := left.Schema(), right.Schema()
+	joinedSchema := datatypes.NewSchema(append(slices.Clone(leftSchema.Fields()), rightSchema.Fields()...))
+	return HashJoinExec{
+		left, right, joinType, leftKeys, rightKeys, filter, schema, *joinedSchema, leftSchema.NumFields(), batchSize,
+	}
+}
+
+func (h HashJoinExec) Children() []physicalplan.PhysicalPlan {
+	return []physicalplan.PhysicalPlan{h.left, h.right}
+}
+
+// buildRow locates a row of the build side
+type buildRow struct {
+	batch int
+	row   int
+}
+
+// hashTable holds the build side of the join
+type hashTable struct {
+	batches []datatypes.RecordBatch
+	// rows indexed by their encoded join key, rows with a null key aren't indexed
+	index      map[string][]buildRow
+	rows       int
+	hasNullKey bool
+}
+
+// Execute consumes the entire right input before reading the left input, which only happens
+// once the returned iterator is consumed. Joined rows follow the order of the left input, the
+// unmatched rows of the right input come last
+func (h HashJoinExec) Execute(ctx context.Context) iter.Seq2[datatypes.RecordBatch, error] {
+	return func(yield func(datatypes.RecordBatch, error) bool) {
+		table, err := h.build(ctx)
+		if err != nil {
+			yield(datatypes.RecordBatch{}, err)
+			return
+		}
+
+		// matched[i][j] is set once row j of build batch i joined a probe row
+		var matched [][]bool
+		if h.joinType == RightJoin || h.joinType == FullJoin {
+			matched = make([][]bool, len(table.batches))
+			for i, rb := range table.batches {
+				matched[i] = make([]bool, rb.RowCount())
+			}
+		}
+
+		out := newJoinOutput(h.schema, h.leftWidth, h.batchSize)
+		var keyBuf []byte
+
+		// emit adds a joined row to the output and yields the output once it is full, it reports
+		// whether the join goes on
+		emit := func(left *datatypes.RecordBatch, leftRow int, right *datatypes.RecordBatch, rightRow int) bool {
+			if err := out.append(left, leftRow, right, rightRow); err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return false
+			}
+			return !out.full() || yield(out.flush(), nil)
+		}
+
+		for rb, err := range h.left.Execute(ctx) {
+			if err == nil {
+				err = ctx.Err()
+			}
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+
+			keys, err := evaluateAll(h.leftKeys, rb)
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+
+			// candidates[i] are the build rows with the same key as probe row i
+			candidates := make([][]buildRow, rb.RowCount())
+			for row := range rb.RowCount() {
+				if hasNull(keys, row) {
+					continue
+				}
+				if keyBuf, err = datatypes.AppendRowKey(keyBuf[:0], keys, row); err != nil {
+					yield(datatypes.RecordBatch{}, err)
+					return
+				}
+				candidates[row] = table.index[string(keyBuf)]
+			}
+
+			accepted, err := h.applyFilter(rb, candidates, table.batches)
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+
+			for row := range rb.RowCount() {
+				select {
+				case <-ctx.Done():
+					yield(datatypes.RecordBatch{}, ctx.Err())
+					return
+				default:
+				}
+
+				rowMatched := false
+				for i, candidate := range candidates[row] {
+					if accepted != nil && !accepted[row][i] {
+						continue
+					}
+
+					rowMatched = true
+					if h.leftRowsOnly() {
+						break
+					}
+
+					if matched != nil {
+						matched[candidate.batch][candidate.row] = true
+					}
+					if !emit(&rb, row, &table.batches[candidate.batch], candidate.row) {
+						return
+					}
+				}
+
+				var keepRow bool
+				switch h.joinType {
+				case LeftJoin, FullJoin, LeftAntiJoin:
+					keepRow = !rowMatched
+				case LeftSemiJoin:
+					keepRow = rowMatched
+				case NullAwareLeftAntiJoin:
+					// `x NOT IN (...)` is null, not true, when x or one of the values is null
+					keepRow = table.rows == 0 || !rowMatched && !table.hasNullKey && !hasNull(keys, row)
+				}
+
+				if keepRow && !emit(&rb, row, nil, 0) {
+					return
+				}
+			}
+		}
+
+		for i, batchMatched := range matched {
+			if err := ctx.Err(); err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+
+			for row, rowMatched := range batchMatched {
+				if !rowMatched && !emit(nil, 0, &table.batches[i], row) {
+					return
+				}
+			}
+		}
+
+		if out.rows > 0 {
+			yield(out.flush(), nil)
+		}
+	}
+}
+
+// leftRowsOnly reports whether the join produces each left row at most once, without right fields
+func (h HashJoinExec) leftRowsOnly() bool {
+	return h.joinType == LeftSemiJoin || h.joinType == LeftAntiJoin || h.joinType == NullAwareLeftAntiJoin
+}
+
+// build reads the right input into memory and indexes its rows by join key, rows with a null
+// key are kept (for right and full joins) but never indexed
+func (h HashJoinExec) build(ctx context.Context) (*hashTable, error) {
+	table := &hashTable{index: make(map[string][]buildRow)}
+	var keyBuf []byte
+
+	for rb, err := range h.right.Execute(ctx) {
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		keys, err := evaluateAll(h.rightKeys, rb)
+		if err != nil {
+			return nil, err
+		}
+
+		for row := range rb.RowCount() {
+			if hasNull(keys, row) {
+				table.hasNullKey = true
+				continue
+			}
+			if keyBuf, err = datatypes.AppendRowKey(keyBuf[:0], keys, row); err != nil {
+				return nil, err
+			}
+			table.index[string(keyBuf)] = append(table.index[string(keyBuf)], buildRow{len(table.batches), row})
+		}
+		table.rows += rb.RowCount()
+		table.batches = append(table.batches, rb)
+	}
+
+	return table, nil
+}
+
+// applyFilter evaluates the filter against every candidate pair of the probe batch at once,
+// accepted[i][j] reports whether probe row i joins candidates[i][j]. A nil result means
+// every candidate is accepted
+func (h HashJoinExec) applyFilter(
+	probe datatypes.RecordBatch,
+	candidates [][]buildRow,
+	buildBatches []datatypes.RecordBatch,
+) ([][]bool, error) {
+	if h.filter == nil {
+		return nil, nil
+	}
+
+	pairs := newJoinOutput(h.joinedSchema, h.leftWidth, 0)
+	for row, rowCandidates := range candidates {
+		for _, candidate := range rowCandidates {
+			if err := pairs.append(&probe, row, &buildBatches[candidate.batch], candidate.row); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result, err := h.filter.Evaluate(pairs.flush())
+	if err != nil {
+		return nil, err
+	}
+
+	accepted := make([][]bool, len(candidates))
+	pair := 0
+	for row, rowCandidates := range candidates {
+		accepted[row] = make([]bool, len(rowCandidates))
+		for i := range rowCandidates {
+			// a null filter result doesn't join the rows
+			accepted[row][i], _ = result.GetValue(pair).(bool)
+			pair++
+		}
+	}
+	return accepted, nil
+}
+
+func (h HashJoinExec) Schema() datatypes.Schema {
+	return h.schema
+}
+
+func (h HashJoinExec) String() string {
+	if h.filter != nil {
+		return fmt.Sprintf("HashJoinExec: type=%s, leftKeys=%v, rightKeys=%v, filter=%v", h.joinType, h.leftKeys, h.rightKeys, h.filter)
+	}
+	return fmt.Sprintf("HashJoinExec: type=%s, leftKeys=%v, rightKeys=%v", h.joinType, h.leftKeys, h.rightKeys)
+}
+
+// joinOutput accumulates joined rows until a record batch of batchSize rows is ready,
+// a batchSize of zero never reports a full batch
+type joinOutput struct {
+	schema datatypes.Schema
+	// number of fields coming from the left input
+	leftWidth int
+	builders  []datatypes.ArrowArrayBuilder
+	rows      int
+	batchSize int
+}
+
+func newJoinOutput(schema datatypes.Schema, leftWidth int, batchSize int) *joinOutput {
+	builders := make([]datatypes.ArrowArrayBuilder, schema.NumFields())
+	for i, field := range schema.Fields() {
+		builders[i] = datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), field.Type)
+	}
+	return &joinOutput{schema, leftWidth, builders, 0, batchSize}
+}
+
+// append adds the row made of leftRow of left and rightRow of right, a nil side is padded
+// with nulls
+func (o *joinOutput) append(left *datatypes.RecordBatch, leftRow int, right *datatypes.RecordBatch, rightRow int) error {
+	for i := range o.builders {
+		var value any
+		switch {
+		case i < o.leftWidth && left != nil:
+			value = left.Field(i).GetValue(leftRow)
+		case i >= o.leftWidth && right != nil:
+			value = right.Field(i - o.leftWidth).GetValue(rightRow)
+		}
+		if err := o.builders[i].Append(value); err != nil {
+			return err
+		}
+	}
+
+	o.rows++
+	return nil
+}
+
+// full reports whether the batch has batchSize rows and must be flushed
+func (o *joinOutput) full() bool {
+	return o.rows == o.batchSize
+}
+
+func (o *joinOutput) flush() datatypes.RecordBatch {
+	fields := make([]datatypes.ColumnArray, len(o.builders))
+	for i := range o.builders {
+		fields[i] = o.builders[i].Build()
+	}
+	o.rows = 0
+	return *datatypes.NewRecordBatch(o.schema, fields)
+}
+
+func evaluateAll(exprList []physicalplan.PhysicalExpression, rb datatypes.RecordBatch) ([]datatypes.ColumnArray, error) {
+	columns := make([]datatypes.ColumnArray, len(exprList))
+	for i, e := range exprList {
+		var err error
+		if columns[i], err = e.Evaluate(rb); err != nil {
+			return nil, err
+		}
+	}
+	return columns, nil
+}
+
+func hasNull(columns []datatypes.ColumnArray, row int) bool {
+	for _, col := range columns {
+		if col.GetValue(row) == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package plans_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/fastbyt3/query-engine/datasources"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/physicalplan"
+	"github.com/fastbyt3/query-engine/physicalplan/exprs"
+	"github.com/fastbyt3/query-engine/physicalplan/plans"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	employeeStateSchema = *datatypes.NewSchema([]arrow.Field{
+		{Name: "name", Type: datatypes.StringType},
+		{Name: "state", Type: datatypes.StringType},
+	})
+	stateSchema = *datatypes.NewSchema([]arrow.Field{
+		{Name: "code", Type: datatypes.StringType},
+		{Name: "population", Type: datatypes.Int64Type},
+	})
+)
+
+func newJoin(t *testing.T, joinType plans.JoinType, filter physicalplan.PhysicalExpression, batchSize int) plans.HashJoinExec {
+	schema := *datatypes.NewSchema(append(employeeStateSchema.Fields(), stateSchema.Fields()...))
+	if joinType == plans.LeftSemiJoin || joinType == plans.LeftAntiJoin || joinType == plans.NullAwareLeftAntiJoin {
+		schema = employeeStateSchema
+	}
+
+	employees := plans.NewScanExec(datasources.NewMemoryDatasource(employeeStateSchema, []datatypes.RecordBatch{
+		newBatch(t, employeeStateSchema, []any{"Bill", "Gregg", "John"}, []any{"CA", "CO", "CO"}),
+		newBatch(t, employeeStateSchema, []any{"Von", "Amy"}, []any{nil, "TX"}),
+	}), []string{}, nil)
+	states := plans.NewScanExec(datasources.NewMemoryDatasource(stateSchema, []datatypes.RecordBatch{
+		newBatch(t, stateSchema, []any{"CO", "NY", nil}, []any{int64(5), int64(19), int64(1)}),
+		newBatch(t, stateSchema, []any{"CA"}, []any{int64(39)}),
+	}), []string{}, nil)
+
+	return plans.NewHashJoinExec(
+		employees, states, joinType,
+		[]physicalplan.PhysicalExpression{exprs.NewColumnIndexExpr(1)},
+		[]physicalplan.PhysicalExpression{exprs.NewColumnIndexExpr(0)},
+		filter, schema, batchSize,
+	)
+}
+
+func TestHashJoin(t *testing.T) {
+	tests := []struct {
+		joinType plans.JoinType
+		expected [][]any
+	}{
+		{plans.InnerJoin, [][]any{
+			{"Bill", "CA", "CA", int64(39)},
+			{"Gregg", "CO", "CO", int64(5)},
+			{"John", "CO", "CO", int64(5)},
+		}},
+		{plans.LeftJoin, [][]any{
+			{"Bill", "CA", "CA", int64(39)},
+			{"Gregg", "CO", "CO", int64(5)},
+			{"John", "CO", "CO", int64(5)},
+			{"Von", nil, nil, nil},
+			{"Amy", "TX", nil, nil},
+		}},
+		{plans.RightJoin, [][]any{
+			{"Bill", "CA", "CA", int64(39)},
+			{"Gregg", "CO", "CO", int64(5)},
+			{"John", "CO", "CO", int64(5)},
+			{nil, nil, "NY", int64(19)},
+			{nil, nil, nil, int64(1)},
+		}},
+		{plans.FullJoin, [][]any{
+			{"Bill", "CA", "CA", int64(39)},
+			{"Gregg", "CO", "CO", int64(5)},
+			{"John", "CO", "CO", int64(5)},
+			{"Von", nil, nil, nil},
+			{"Amy", "TX", nil, nil},
+			{nil, nil, "NY", int64(19)},
+			{nil, nil, nil, int64(1)},
+		}},
+	}
+
+	for _, tt := range tests {
+		rows, _ := collectRows(t, newJoin(t, tt.joinType, nil, 0))
+		require.Equal(t, tt.expected, rows, "%s join", tt.joinType)
+	}
+}
+
+func TestHashJoinFilter(t *testing.T) {
+	// only Gregg joins CO, John is null padded like the unmatched rows
+	filter := exprs.NewNeqExpr(exprs.NewColumnIndexExpr(0), exprs.NewLiteralStringExpr("John"))
+
+	rows, _ := collectRows(t, newJoin(t, plans.FullJoin, filter, 0))
+	require.Equal(t, [][]any{
+		{"Bill", "CA", "CA", int64(39)},
+		{"Gregg", "CO", "CO", int64(5)},
+		{"John", "CO", nil, nil},
+		{"Von", nil, nil, nil},
+		{"Amy", "TX", nil, nil},
+		{nil, nil, "NY", int64(19)},
+		{nil, nil, nil, int64(1)},
+	}, rows)
+}
+
+func TestHashJoinBatchSize(t *testing.T) {
+	_, batchSizes := collectRows(t, newJoin(t, plans.FullJoin, nil, 3))
+	require.Equal(t, []int{3, 3, 1}, batchSizes)
+}
+
+func TestHashJoinSemiAnti(t *testing.T) {
+	tests := []struct {
+		joinType plans.JoinType
+		expected [][]any
+	}{
+		{plans.LeftSemiJoin, [][]any{{"Bill", "CA"}, {"Gregg", "CO"}, {"John", "CO"}}},
+		{plans.LeftAntiJoin, [][]any{{"Von", nil}, {"Amy", "TX"}}},
+		// the right input has a null code, so `state NOT IN (codes)` is never true
+		{plans.NullAwareLeftAntiJoin, nil},
+	}
+
+	for _, tt := range tests {
+		rows, _ := collectRows(t, newJoin(t, tt.joinType, nil, 0))
+		require.Equal(t, tt.expected, rows, "%s join", tt.joinType)
+	}
+}
+
+func TestHashJoinNullAwareAnti(t *testing.T) {
+	employees := plans.NewScanExec(datasources.NewMemoryDatasource(employeeStateSchema, []datatypes.RecordBatch{
+		newBatch(t, employeeStateSchema, []any{"Bill", "Von", "Amy"}, []any{"CA", nil, "TX"}),
+	}), []string{}, nil)
+
+	newAntiJoin := func(states ...datatypes.RecordBatch) plans.HashJoinExec {
+		return plans.NewHashJoinExec(
+			employees,
+			plans.NewScanExec(datasources.NewMemoryDatasource(stateSchema, states), []string{}, nil),
+			plans.NullAwareLeftAntiJoin,
+			[]physicalplan.PhysicalExpression{exprs.NewColumnIndexExpr(1)},
+			[]physicalplan.PhysicalExpression{exprs.NewColumnIndexExpr(0)},
+			nil, employeeStateSchema, 0,
+		)
+	}
+
+	// a null state is unknown, it might be CA
+	rows, _ := collectRows(t, newAntiJoin(newBatch(t, stateSchema, []any{"CA"}, []any{int64(39)})))
+	require.Equal(t, [][]any{{"Amy", "TX"}}, rows)
+
+	// nothing is in an empty set, not even null
+	rows, _ = collectRows(t, newAntiJoin())
+	require.Equal(t, [][]any{{"Bill", "CA"}, {"Von", nil}, {"Amy", "TX"}}, rows)
+}
+
+func TestHashJoinUnsupportedValue(t *testing.T) {
+	employees := newEmployeeScan(newBatch(t, employeeSchema, []any{"CO"}, []any{int64(10)}))
+	states := plans.NewScanExec(datasources.NewMemoryDatasource(stateSchema, []datatypes.RecordBatch{
+		newBatch(t, stateSchema, []any{"CO"}, []any{int64(5)}),
+	}), []string{}, nil)
+	joined := *datatypes.NewSchema(append(employeeSchema.Fields(), stateSchema.Fields()...))
+	// the salary of the employees is declared a string
+	mismatched := *datatypes.NewSchema(append(employeeStateSchema.Fields(), stateSchema.Fields()...))
+
+	tests := []struct {
+		name     string
+		leftKey  physicalplan.PhysicalExpression
+		rightKey physicalplan.PhysicalExpression
+		schema   datatypes.Schema
+	}{
+		{"probe key", unsupportedExpr{}, exprs.NewColumnIndexExpr(0), joined},
+		{"build key", exprs.NewColumnIndexExpr(0), unsupportedExpr{}, joined},
+		{"output type", exprs.NewColumnIndexExpr(0), exprs.NewColumnIndexExpr(0), mismatched},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			join := plans.NewHashJoinExec(
+				employees, states, plans.InnerJoin,
+				[]physicalplan.PhysicalExpression{tt.leftKey},
+				[]physicalplan.PhysicalExpression{tt.rightKey},
+				nil, tt.schema, 0,
+			)
+
+			var err error
+			for _, err = range join.Execute(context.Background()) {
+			}
+			require.ErrorIs(t, err, datatypes.ErrUnsupportedValue)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package plans
+
+import (
+	"context"
+	"fmt"
+	"iter"
+
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/physicalplan"
+)
+
+// LimitExec skips the first skip rows of its input and produces at most fetch of the following
+// rows, a negative fetch produces all of them. Batches are sliced rather than copied
+type LimitExec struct {
+	input physicalplan.PhysicalPlan
+	skip  int
+	fetch int
+}
+
+func NewLimitExec(input physicalplan.PhysicalPlan, skip, fetch int) LimitExec {
+	return LimitExec{input, max(skip, 0), fetch}
+}
+
+func (l LimitExec) Children() []physicalplan.PhysicalPlan {
+	return []physicalplan.PhysicalPlan{l.input}
+}
+
+// Execute stops iterating over its input as soon as fetch rows were produced, which lets the
+// input stop its work as well, for example a CSV scan stops reading the file
+func (l LimitExec) Execute(ctx context.Context) iter.Seq2[datatypes.RecordBatch, error] {
+	return func(yield func(datatypes.RecordBatch, error) bool) {
+		if l.fetch == 0 {
+			if err := ctx.Err(); err != nil {
+				yield(datatypes.RecordBatch{}, err)
+			}
+			return
+		}
+
+		toSkip, produced := l.skip, 0
+		for rb, err := range l.input.Execute(ctx) {
+			if err == nil {
+				err = ctx.Err()
+			}
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+
+			rows := rb.RowCount()
+			start := min(toSkip, rows)
+			toSkip -= start
+
+			end := rows
+			if l.fetch > 0 {
+				end = min(rows, start+l.fetch-produced)
+			}
+			if start == end {
+				continue
+			}
+
+			out := rb
+			if start > 0 || end < rows {
+				sliced, err := rb.Slice(start, end)
+				if err != nil {
+					yield(datatypes.RecordBatch{}, err)
+					return
+				}
+				out = *sliced
+			}
+			produced += end - start
+			if !yield(out, nil) {
+				return
+			}
+
+			if produced == l.fetch {
+				return
+			}
+		}
+	}
+}
+
+func (l LimitExec) Schema() datatypes.Schema {
+	return l.input.Schema()
+}
+
+func (l LimitExec) String() string {
+	if l.fetch < 0 {
+		return fmt.Sprintf("LimitExec: skip=%d, fetch=None", l.skip)
+	}
+	return fmt.Sprintf("LimitExec: skip=%d, fetch=%d", l.skip, l.fetch)
+}
@@ -0,0 +1,67 @@
+package plans_test
+
+import (
+	"context"
+	"iter"
+	"testing"
+
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/physicalplan"
+	"github.com/fastbyt3/query-engine/physicalplan/plans"
+	"github.com/stretchr/testify/require"
+)
+
+// countingPlan counts the record batches pulled from its input
+type countingPlan struct {
+	physicalplan.PhysicalPlan
+	pulled *int
+}
+
+func (c countingPlan) Execute(ctx context.Context) iter.Seq2[datatypes.RecordBatch, error] {
+	return func(yield func(datatypes.RecordBatch, error) bool) {
+		for rb, err := range c.PhysicalPlan.Execute(ctx) {
+			*c.pulled++
+			if !yield(rb, err) {
+				return
+			}
+		}
+	}
+}
+
+func limitInput(t *testing.T, pulled *int) countingPlan {
+	return countingPlan{newEmployeeScan(
+		newBatch(t, employeeSchema, []any{"CO", "CA", "TX"}, []any{int64(1), int64(2), int64(3)}),
+		newBatch(t, employeeSchema, []any{"NY", "WA"}, []any{int64(4), int64(5)}),
+		newBatch(t, employeeSchema, []any{"OR"}, []any{int64(6)}),
+	), pulled}
+}
+
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		skip, fetch int
+		expected    []any
+		batchSizes  []int
+		pulled      int
+	}{
+		{0, 2, []any{int64(1), int64(2)}, []int{2}, 1},
+		{0, 3, []any{int64(1), int64(2), int64(3)}, []int{3}, 1},
+		{2, 2, []any{int64(3), int64(4)}, []int{1, 1}, 2},
+		{4, -1, []any{int64(5), int64(6)}, []int{1, 1}, 3},
+		{10, 5, nil, nil, 3},
+		{1, 0, nil, nil, 0},
+	}
+
+	for _, tt := range tests {
+		pulled := 0
+		rows, batchSizes := collectRows(t, plans.NewLimitExec(limitInput(t, &pulled), tt.skip, tt.fetch))
+
+		var salaries []any
+		for _, row := range rows {
+			salaries = append(salaries, row[1])
+		}
+		require.Equal(t, tt.expected, salaries, "skip=%d fetch=%d", tt.skip, tt.fetch)
+		require.Equal(t, tt.batchSizes, batchSizes, "skip=%d fetch=%d", tt.skip, tt.fetch)
+		// the input isn't pulled once enough rows were produced
+		require.Equal(t, tt.pulled, pulled, "skip=%d fetch=%d", tt.skip, tt.fetch)
+	}
+}
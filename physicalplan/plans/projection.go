@@ -1,6 +1,7 @@
 package plans
 
 import (
+	"context"
 	"fmt"
 	"iter"
 
@@ -16,21 +17,37 @@ type ProjectionExec struct {
 	exprs  []physicalplan.PhysicalExpression
 }
 
+func NewProjectionExec(input physicalplan.PhysicalPlan, schema datatypes.Schema, exprs []physicalplan.PhysicalExpression) ProjectionExec {
+	return ProjectionExec{input, schema, exprs}
+}
+
 func (p ProjectionExec) Children() []physicalplan.PhysicalPlan {
 	return []physicalplan.PhysicalPlan{p.input}
 }
 
 // Projection execute needs to apply list of expressions
-func (p ProjectionExec) Execute() iter.Seq[datatypes.RecordBatch] {
-	recordBatchIter := p.input.Execute()
-	return func(yield func(datatypes.RecordBatch) bool) {
-		for rb := range recordBatchIter {
+func (p ProjectionExec) Execute(ctx context.Context) iter.Seq2[datatypes.RecordBatch, error] {
+	recordBatchIter := p.input.Execute(ctx)
+	return func(yield func(datatypes.RecordBatch, error) bool) {
+		for rb, err := range recordBatchIter {
+			if err == nil {
+				err = ctx.Err()
+			}
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+
 			fields := make([]datatypes.ColumnArray, len(p.exprs))
 			for i := range len(p.exprs) {
-				fields[i] = p.exprs[i].Evaluate(rb)
+				fields[i], err = p.exprs[i].Evaluate(rb)
+				if err != nil {
+					yield(datatypes.RecordBatch{}, err)
+					return
+				}
 			}
 
-			if !yield(*datatypes.NewRecordBatch(p.schema, fields)) {
+			if !yield(*datatypes.NewRecordBatch(p.schema, fields), nil) {
 				return
 			}
 		}
@@ -1,6 +1,7 @@
 package plans
 
 import (
+	"context"
 	"fmt"
 	"iter"
 
@@ -12,6 +13,12 @@ import (
 type ScanExec struct {
 	ds         datasources.DataSource
 	projection []string
+	filters    []datasources.Filter
+}
+
+// NewScanExec creates a scan of ds, filters are only allowed when ds is a datasources.FilterableDataSource
+func NewScanExec(ds datasources.DataSource, projection []string, filters []datasources.Filter) ScanExec {
+	return ScanExec{ds, projection, filters}
 }
 
 func (s ScanExec) Children() []physicalplan.PhysicalPlan {
@@ -19,8 +26,16 @@ func (s ScanExec) Children() []physicalplan.PhysicalPlan {
 	return []physicalplan.PhysicalPlan{}
 }
 
-func (s ScanExec) Execute() iter.Seq[datatypes.RecordBatch] {
-	return s.ds.Scan(s.projection)
+func (s ScanExec) Execute(ctx context.Context) iter.Seq2[datatypes.RecordBatch, error] {
+	if len(s.filters) > 0 {
+		if fds, ok := s.ds.(datasources.FilterableDataSource); ok {
+			return fds.ScanWithFilters(ctx, s.projection, s.filters)
+		}
+		return func(yield func(datatypes.RecordBatch, error) bool) {
+			yield(datatypes.RecordBatch{}, fmt.Errorf("datasource %T doesn't support filters", s.ds))
+		}
+	}
+	return s.ds.Scan(ctx, s.projection)
 }
 
 func (s ScanExec) Schema() datatypes.Schema {
@@ -30,5 +45,8 @@ func (s ScanExec) Schema() datatypes.Schema {
 }
 
 func (s ScanExec) String() string {
+	if len(s.filters) > 0 {
+		return fmt.Sprintf("ScanExec: schema=%v, projection=%v, filters=%v", s.Schema(), s.projection, s.filters)
+	}
 	return fmt.Sprintf("ScanExec: schema=%v, projection=%v", s.Schema(), s.projection)
 }
@@ -1,9 +1,11 @@
 package plans
 
 import (
+	"context"
+	"fmt"
 	"iter"
-	"log/slog"
 
+	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/arrow/memory"
 	"github.com/fastbyt3/query-engine/datatypes"
 	"github.com/fastbyt3/query-engine/physicalplan"
@@ -14,25 +16,43 @@ type SelectionExec struct {
 	expr  physicalplan.PhysicalExpression
 }
 
+func NewSelectionExec(input physicalplan.PhysicalPlan, expr physicalplan.PhysicalExpression) *SelectionExec {
+	return &SelectionExec{input, expr}
+}
+
 func (s *SelectionExec) Children() []physicalplan.PhysicalPlan {
 	return []physicalplan.PhysicalPlan{s.input}
 }
 
-func (s *SelectionExec) Execute() iter.Seq[datatypes.RecordBatch] {
-	rbIter := s.input.Execute()
-	return func(yield func(datatypes.RecordBatch) bool) {
-		for rb := range rbIter {
-			evalRes := s.expr.Evaluate(rb)
-			if evalRes.GetType() != datatypes.BooleanType {
-				slog.Error(
-					"SelectionExec expression result not boolean",
-					slog.String("Type", evalRes.GetType().String()),
-				)
-				panic("Selection Exec expression not boolean")
+func (s *SelectionExec) Execute(ctx context.Context) iter.Seq2[datatypes.RecordBatch, error] {
+	rbIter := s.input.Execute(ctx)
+	return func(yield func(datatypes.RecordBatch, error) bool) {
+		for rb, err := range rbIter {
+			if err == nil {
+				err = ctx.Err()
+			}
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+
+			evalRes, err := s.expr.Evaluate(rb)
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+
+			if !arrow.TypeEqual(evalRes.GetType(), datatypes.BooleanType) {
+				yield(datatypes.RecordBatch{}, fmt.Errorf("%w: %s evaluates to %s", physicalplan.ErrNonBooleanPredicate, s.expr, evalRes.GetType()))
+				return
 			}
 
-			filteredColArr := s.filter(rb, evalRes)
-			if !yield(*datatypes.NewRecordBatch(rb.Schema, filteredColArr)) {
+			filteredColArr, err := s.filter(rb, evalRes)
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+			if !yield(*datatypes.NewRecordBatch(rb.Schema, filteredColArr), nil) {
 				return
 			}
 		}
@@ -40,21 +60,28 @@ func (s *SelectionExec) Execute() iter.Seq[datatypes.RecordBatch] {
 }
 
 func (s *SelectionExec) Schema() datatypes.Schema {
-	return s.Schema()
+	return s.input.Schema()
+}
+
+func (s *SelectionExec) String() string {
+	return fmt.Sprintf("SelectionExec: %v", s.expr)
 }
 
-func (s *SelectionExec) filter(rb datatypes.RecordBatch, selectExprRes datatypes.ColumnArray) []datatypes.ColumnArray {
+func (s *SelectionExec) filter(rb datatypes.RecordBatch, selectExprRes datatypes.ColumnArray) ([]datatypes.ColumnArray, error) {
 	fields := make([]datatypes.ColumnArray, rb.ColumnCount())
 	for i := range rb.ColumnCount() {
 		colArr := rb.Field(i)
 		newColArr := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), colArr.GetType())
 		for j := range colArr.Size() {
-			if selectExprRes.GetValue(j).(bool) {
-				newColArr.Append(colArr.GetValue(j))
+			// a null predicate result doesn't select the row
+			if selected, _ := selectExprRes.GetValue(j).(bool); selected {
+				if err := newColArr.Append(colArr.GetValue(j)); err != nil {
+					return nil, err
+				}
 			}
 		}
 		fields[i] = newColArr.Build()
 	}
 
-	return fields
+	return fields, nil
 }
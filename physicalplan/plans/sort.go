@@ -0,0 +1,429 @@
+package plans
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"iter"
+	"os"
+	"slices"
+
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/physicalplan"
+)
+
+// SortExpr orders rows by the value of Expr, nulls come before or after every other value
+// depending on NullsFirst whatever the direction
+type SortExpr struct {
+	Expr       physicalplan.PhysicalExpression
+	Asc        bool
+	NullsFirst bool
+}
+
+func (s SortExpr) String() string {
+	direction, nulls := "ASC", "NULLS LAST"
+	if !s.Asc {
+		direction = "DESC"
+	}
+	if s.NullsFirst {
+		nulls = "NULLS FIRST"
+	}
+	return fmt.Sprintf("%v %s %s", s.Expr, direction, nulls)
+}
+
+// SortExec produces the rows of its input ordered by the sort expressions, the first one has the
+// highest priority. The sort is stable, rows which compare equal keep the order of the input.
+//
+// Input batches are buffered in memory, once they hold more than memoryLimit bytes they are
+// sorted and written to a temporary Arrow IPC file (a run). When the input is exhausted the runs
+// are merged, which only keeps a single batch of every run in memory
+type SortExec struct {
+	input     physicalplan.PhysicalPlan
+	sortExprs []SortExpr
+
+	// max number of rows in every output record batch
+	batchSize int
+
+	// estimated number of bytes of input buffered before a sorted run is spilled to disk,
+	// zero or less keeps the entire input in memory
+	memoryLimit int64
+
+	// directory of the temporary run files, empty means the default directory for temporary files
+	spillDir string
+}
+
+func NewSortExec(
+	input physicalplan.PhysicalPlan,
+	sortExprs []SortExpr,
+	batchSize int,
+	memoryLimit int64,
+	spillDir string,
+) SortExec {
+	if batchSize <= 0 {
+		batchSize = physicalplan.DefaultBatchSize
+	}
+	return SortExec{input, sortExprs, batchSize, memoryLimit, spillDir}
+}
+
+func (s SortExec) Children() []physicalplan.PhysicalPlan {
+	return []physicalplan.PhysicalPlan{s.input}
+}
+
+// sortRow is a row of a record batch along with the values of the sort expressions for that batch
+type sortRow struct {
+	batch *datatypes.RecordBatch
+	keys  []datatypes.ColumnArray
+	row   int
+}
+
+// Execute consumes the entire input before producing any output, which only happens once
+// the returned iterator is consumed. Temporary files are removed once the iterator returns,
+// whether the sort completed, failed, was cancelled or the consumer stopped early
+func (s SortExec) Execute(ctx context.Context) iter.Seq2[datatypes.RecordBatch, error] {
+	return func(yield func(datatypes.RecordBatch, error) bool) {
+		var runs []*os.File
+		defer func() {
+			for _, f := range runs {
+				f.Close()
+				os.Remove(f.Name())
+			}
+		}()
+
+		var rows []sortRow
+		var bufferedBytes int64
+
+		for rb, err := range s.input.Execute(ctx) {
+			if err == nil {
+				err = ctx.Err()
+			}
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+
+			batch := &rb
+			keys, err := evaluateKeys(s.sortExprs, batch)
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+			for row := range batch.RowCount() {
+				rows = append(rows, sortRow{batch, keys, row})
+			}
+
+			bufferedBytes += batch.MemorySize()
+			if s.memoryLimit > 0 && bufferedBytes > s.memoryLimit {
+				run, err := s.spill(rows)
+				if run != nil {
+					runs = append(runs, run)
+				}
+				if err != nil {
+					yield(datatypes.RecordBatch{}, err)
+					return
+				}
+				rows, bufferedBytes = nil, 0
+			}
+		}
+
+		if len(runs) == 0 {
+			if err := s.sortRows(rows); err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+			for chunk := range slices.Chunk(rows, s.batchSize) {
+				if err := ctx.Err(); err != nil {
+					yield(datatypes.RecordBatch{}, err)
+					return
+				}
+				if !yieldRows(s.input.Schema(), chunk, yield) {
+					return
+				}
+			}
+			return
+		}
+
+		if len(rows) > 0 {
+			run, err := s.spill(rows)
+			if run != nil {
+				runs = append(runs, run)
+			}
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+		}
+
+		s.merge(ctx, runs, yield)
+	}
+}
+
+// evaluateKeys evaluates the sort expressions against rb
+func evaluateKeys(sortExprs []SortExpr, rb *datatypes.RecordBatch) ([]datatypes.ColumnArray, error) {
+	keys := make([]datatypes.ColumnArray, len(sortExprs))
+	for i, e := range sortExprs {
+		var err error
+		if keys[i], err = e.Expr.Evaluate(*rb); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+func (s SortExec) sortRows(rows []sortRow) error {
+	var compareErr error
+	slices.SortStableFunc(rows, func(a, b sortRow) int {
+		c, err := compareRows(s.sortExprs, a, b)
+		if err != nil && compareErr == nil {
+			compareErr = err
+		}
+		return c
+	})
+	return compareErr
+}
+
+func compareRows(sortExprs []SortExpr, a, b sortRow) (int, error) {
+	for i, e := range sortExprs {
+		c, err := compareSortValues(a.keys[i].GetValue(a.row), b.keys[i].GetValue(b.row), e)
+		if err != nil || c != 0 {
+			return c, err
+		}
+	}
+	return 0, nil
+}
+
+// compareSortValues orders two values of a sort expression, nulls are placed according to
+// NullsFirst before the direction is applied to the other values
+func compareSortValues(a, b any, e SortExpr) (int, error) {
+	switch {
+	case a == nil && b == nil:
+		return 0, nil
+	case a == nil && e.NullsFirst, b == nil && !e.NullsFirst:
+		return -1, nil
+	case a == nil, b == nil:
+		return 1, nil
+	}
+
+	c, err := datatypes.CompareValues(a, b)
+	if err != nil {
+		return 0, err
+	}
+	if !e.Asc {
+		c = -c
+	}
+	return c, nil
+}
+
+// spill sorts rows and writes them to a new temporary file. The file is returned even when writing
+// fails so the caller can remove it
+func (s SortExec) spill(rows []sortRow) (*os.File, error) {
+	if err := s.sortRows(rows); err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp(s.spillDir, "sort-run-*.arrow")
+	if err != nil {
+		return nil, fmt.Errorf("creating sort run: %w", err)
+	}
+
+	schema := s.input.Schema()
+	w, err := ipc.NewFileWriter(f, ipc.WithSchema(&schema.Schema), ipc.WithAllocator(memory.NewGoAllocator()))
+	if err != nil {
+		return f, fmt.Errorf("writing sort run %s: %w", f.Name(), err)
+	}
+	for chunk := range slices.Chunk(rows, s.batchSize) {
+		rb, err := gatherRows(schema, chunk)
+		if err != nil {
+			return f, err
+		}
+		rec, err := rb.ToArrowRecord()
+		if err != nil {
+			return f, err
+		}
+		if err := w.Write(rec); err != nil {
+			return f, fmt.Errorf("writing sort run %s: %w", f.Name(), err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return f, fmt.Errorf("writing sort run %s: %w", f.Name(), err)
+	}
+	return f, nil
+}
+
+// merge produces the rows of every sorted run in order, ties go to the run which was written
+// first to keep the sort stable
+func (s SortExec) merge(ctx context.Context, runs []*os.File, yield func(datatypes.RecordBatch, error) bool) {
+	cursors := &mergeHeap{sort: s}
+	for i, f := range runs {
+		reader, err := ipc.NewFileReader(f, ipc.WithAllocator(memory.NewGoAllocator()))
+		if err != nil {
+			yield(datatypes.RecordBatch{}, fmt.Errorf("reading sort run %s: %w", f.Name(), err))
+			return
+		}
+		defer reader.Close()
+
+		cursor := &runCursor{reader: reader, run: i}
+		if ok, err := s.advance(cursor); err != nil {
+			yield(datatypes.RecordBatch{}, err)
+			return
+		} else if ok {
+			cursors.cursors = append(cursors.cursors, cursor)
+		}
+	}
+	if heap.Init(cursors); cursors.err != nil {
+		yield(datatypes.RecordBatch{}, cursors.err)
+		return
+	}
+
+	schema := s.input.Schema()
+	pending := make([]sortRow, 0, s.batchSize)
+	for cursors.Len() > 0 {
+		select {
+		case <-ctx.Done():
+			yield(datatypes.RecordBatch{}, ctx.Err())
+			return
+		default:
+		}
+
+		cursor := cursors.cursors[0]
+		pending = append(pending, cursor.current)
+
+		ok, err := s.advance(cursor)
+		if err != nil {
+			yield(datatypes.RecordBatch{}, err)
+			return
+		}
+		if ok {
+			heap.Fix(cursors, 0)
+		} else {
+			heap.Pop(cursors)
+		}
+		if cursors.err != nil {
+			yield(datatypes.RecordBatch{}, cursors.err)
+			return
+		}
+
+		if len(pending) == s.batchSize {
+			if !yieldRows(schema, pending, yield) {
+				return
+			}
+			pending = pending[:0]
+		}
+	}
+
+	if len(pending) > 0 {
+		yieldRows(schema, pending, yield)
+	}
+}
+
+// runCursor points at the next row of a sorted run
+type runCursor struct {
+	reader *ipc.FileReader
+	run    int
+	// index of the next record to read from the run
+	nextRecord int
+	current    sortRow
+}
+
+// advance moves the cursor to the next row of its run and reports whether there was one
+func (s SortExec) advance(c *runCursor) (bool, error) {
+	if c.current.batch != nil && c.current.row+1 < c.current.batch.RowCount() {
+		c.current.row++
+		return true, nil
+	}
+
+	for c.nextRecord < c.reader.NumRecords() {
+		rec, err := c.reader.RecordAt(c.nextRecord)
+		if err != nil {
+			return false, fmt.Errorf("reading sort run %d: %w", c.run, err)
+		}
+		c.nextRecord++
+
+		batch := datatypes.NewRecordBatchFromArrow(rec)
+		if batch.RowCount() == 0 {
+			continue
+		}
+		keys, err := evaluateKeys(s.sortExprs, batch)
+		if err != nil {
+			return false, err
+		}
+		c.current = sortRow{batch, keys, 0}
+		return true, nil
+	}
+	return false, nil
+}
+
+// mergeHeap orders the run cursors by their current row, the first comparison error is kept in err
+type mergeHeap struct {
+	sort    SortExec
+	cursors []*runCursor
+	err     error
+}
+
+func (h *mergeHeap) Len() int {
+	return len(h.cursors)
+}
+
+func (h *mergeHeap) Less(i, j int) bool {
+	a, b := h.cursors[i], h.cursors[j]
+	c, err := compareRows(h.sort.sortExprs, a.current, b.current)
+	if err != nil && h.err == nil {
+		h.err = err
+	}
+	if c != 0 {
+		return c < 0
+	}
+	return a.run < b.run
+}
+
+func (h *mergeHeap) Swap(i, j int) {
+	h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i]
+}
+
+func (h *mergeHeap) Push(x any) {
+	h.cursors = append(h.cursors, x.(*runCursor))
+}
+
+func (h *mergeHeap) Pop() any {
+	last := h.cursors[len(h.cursors)-1]
+	h.cursors = h.cursors[:len(h.cursors)-1]
+	return last
+}
+
+// gatherRows copies rows, which may come from different record batches, into a single record batch
+func gatherRows(schema datatypes.Schema, rows []sortRow) (datatypes.RecordBatch, error) {
+	fields := make([]datatypes.ColumnArray, schema.NumFields())
+	for i, field := range schema.Fields() {
+		builder := datatypes.NewArrowArrayBuilder(memory.NewGoAllocator(), field.Type)
+		for _, r := range rows {
+			if err := builder.Append(r.batch.Field(i).GetValue(r.row)); err != nil {
+				return datatypes.RecordBatch{}, err
+			}
+		}
+		fields[i] = builder.Build()
+	}
+	return *datatypes.NewRecordBatch(schema, fields), nil
+}
+
+// yieldRows yields the rows gathered into a single record batch, or the error gathering them,
+// and reports whether the caller goes on
+func yieldRows(schema datatypes.Schema, rows []sortRow, yield func(datatypes.RecordBatch, error) bool) bool {
+	rb, err := gatherRows(schema, rows)
+	if err != nil {
+		yield(datatypes.RecordBatch{}, err)
+		return false
+	}
+	return yield(rb, nil)
+}
+
+func (s SortExec) Schema() datatypes.Schema {
+	return s.input.Schema()
+}
+
+func (s SortExec) String() string {
+	if s.memoryLimit > 0 {
+		return fmt.Sprintf("SortExec: %v, memoryLimit=%d", s.sortExprs, s.memoryLimit)
+	}
+	return fmt.Sprintf("SortExec: %v", s.sortExprs)
+}
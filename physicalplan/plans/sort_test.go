@@ -0,0 +1,160 @@
+package plans_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/fastbyt3/query-engine/datasources"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/physicalplan/exprs"
+	"github.com/fastbyt3/query-engine/physicalplan/plans"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortMultipleKeys(t *testing.T) {
+	scan := newEmployeeScan(
+		newBatch(t, employeeSchema, []any{"CO", "CA", nil}, []any{int64(10), int64(12), int64(11)}),
+		newBatch(t, employeeSchema, []any{"CO", "CA"}, []any{nil, int64(15)}),
+	)
+
+	tests := []struct {
+		name      string
+		sortExprs []plans.SortExpr
+		expected  [][]any
+	}{
+		{
+			name: "state asc nulls last, salary desc nulls first",
+			sortExprs: []plans.SortExpr{
+				{Expr: exprs.NewColumnIndexExpr(0), Asc: true},
+				{Expr: exprs.NewColumnIndexExpr(1), NullsFirst: true},
+			},
+			expected: [][]any{
+				{"CA", int64(15)}, {"CA", int64(12)}, {"CO", nil}, {"CO", int64(10)}, {nil, int64(11)},
+			},
+		},
+		{
+			name: "state desc nulls first, salary asc nulls last",
+			sortExprs: []plans.SortExpr{
+				{Expr: exprs.NewColumnIndexExpr(0), NullsFirst: true},
+				{Expr: exprs.NewColumnIndexExpr(1), Asc: true},
+			},
+			expected: [][]any{
+				{nil, int64(11)}, {"CO", int64(10)}, {"CO", nil}, {"CA", int64(12)}, {"CA", int64(15)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rows, _ := collectRows(t, plans.NewSortExec(scan, test.sortExprs, 0, 0, ""))
+			require.Equal(t, test.expected, rows)
+		})
+	}
+}
+
+func TestSortBatchSize(t *testing.T) {
+	scan := newEmployeeScan(
+		newBatch(t, employeeSchema, []any{"CO", "CA", "TX"}, []any{int64(3), int64(5), int64(1)}),
+		newBatch(t, employeeSchema, []any{"NY", "WA"}, []any{int64(4), int64(2)}),
+	)
+
+	sort := plans.NewSortExec(scan, []plans.SortExpr{{Expr: exprs.NewColumnIndexExpr(1), Asc: true}}, 2, 0, "")
+	rows, batchSizes := collectRows(t, sort)
+	require.Equal(t, []int{2, 2, 1}, batchSizes)
+	require.Equal(t, [][]any{
+		{"TX", int64(1)}, {"WA", int64(2)}, {"CO", int64(3)}, {"NY", int64(4)}, {"CA", int64(5)},
+	}, rows)
+}
+
+func TestSortTypes(t *testing.T) {
+	schema := *datatypes.NewSchema([]arrow.Field{
+		{Name: "b", Type: datatypes.BooleanType},
+		{Name: "i8", Type: datatypes.Int8Type},
+		{Name: "u32", Type: datatypes.UInt32Type},
+		{Name: "f", Type: datatypes.FloatType},
+		{Name: "d", Type: datatypes.DoubleType},
+	})
+	scan := plans.NewScanExec(datasources.NewMemoryDatasource(schema, []datatypes.RecordBatch{
+		newBatch(t, schema,
+			[]any{true, false, true},
+			[]any{int8(-1), int8(7), int8(3)},
+			[]any{uint32(9), uint32(2), uint32(5)},
+			[]any{float32(0.5), float32(-2), float32(1.5)},
+			[]any{2.5, 1.25, -3.0},
+		),
+	}), []string{}, nil)
+
+	for i := range schema.NumFields() {
+		t.Run(schema.Field(i).Name, func(t *testing.T) {
+			rows, _ := collectRows(t, plans.NewSortExec(scan, []plans.SortExpr{{Expr: exprs.NewColumnIndexExpr(i), Asc: true}}, 0, 0, ""))
+			require.Len(t, rows, 3)
+			for j := 1; j < len(rows); j++ {
+				c, err := datatypes.CompareValues(rows[j-1][i], rows[j][i])
+				require.NoError(t, err)
+				require.LessOrEqual(t, c, 0)
+			}
+		})
+	}
+}
+
+func spillScan(t *testing.T) plans.ScanExec {
+	return newEmployeeScan(
+		newBatch(t, employeeSchema, []any{"CO", "CA", nil}, []any{int64(10), int64(12), int64(11)}),
+		newBatch(t, employeeSchema, []any{"TX", "CA"}, []any{nil, int64(15)}),
+		newBatch(t, employeeSchema, []any{"CO", "NY", "WA"}, []any{int64(9), int64(12), int64(1)}),
+	)
+}
+
+func TestSortSpillsToDisk(t *testing.T) {
+	sortExprs := []plans.SortExpr{
+		{Expr: exprs.NewColumnIndexExpr(1), NullsFirst: true},
+		{Expr: exprs.NewColumnIndexExpr(0), Asc: true},
+	}
+	expected, _ := collectRows(t, plans.NewSortExec(spillScan(t), sortExprs, 0, 0, ""))
+
+	// a limit of a single byte writes every input batch to its own run
+	dir := t.TempDir()
+	rows, batchSizes := collectRows(t, plans.NewSortExec(spillScan(t), sortExprs, 3, 1, dir))
+	require.Equal(t, expected, rows)
+	require.Equal(t, []int{3, 3, 2}, batchSizes)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestSortSpillCleanup(t *testing.T) {
+	sortExprs := []plans.SortExpr{{Expr: exprs.NewColumnIndexExpr(1), Asc: true}}
+
+	t.Run("consumer stops early", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, err := range plans.NewSortExec(spillScan(t), sortExprs, 1, 1, dir).Execute(context.Background()) {
+			require.NoError(t, err)
+			break
+		}
+
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, files)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		dir := t.TempDir()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var err error
+		for _, err = range plans.NewSortExec(spillScan(t), sortExprs, 1, 1, dir).Execute(ctx) {
+			if err != nil {
+				break
+			}
+			cancel()
+		}
+		require.ErrorIs(t, err, context.Canceled)
+
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, files)
+	})
+}
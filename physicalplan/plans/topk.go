@@ -0,0 +1,212 @@
+package plans
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/physicalplan"
+)
+
+// TopKExec produces the first k rows of its input ordered by the sort expressions, it is
+// equivalent to a SortExec followed by a LimitExec but only keeps the best k rows seen so far
+// in a bounded heap instead of the entire input.
+//
+// Rows kept in the heap are regularly copied out of the input batches they come from so memory
+// stays proportional to k rather than to the size of the input
+type TopKExec struct {
+	input     physicalplan.PhysicalPlan
+	sortExprs []SortExpr
+	k         int
+
+	// max number of rows in every output record batch
+	batchSize int
+}
+
+func NewTopKExec(input physicalplan.PhysicalPlan, sortExprs []SortExpr, k int, batchSize int) TopKExec {
+	if batchSize <= 0 {
+		batchSize = physicalplan.DefaultBatchSize
+	}
+	return TopKExec{input, sortExprs, max(k, 0), batchSize}
+}
+
+func (t TopKExec) Children() []physicalplan.PhysicalPlan {
+	return []physicalplan.PhysicalPlan{t.input}
+}
+
+// Execute consumes the entire input before producing any output, which only happens once
+// the returned iterator is consumed. Like SortExec, rows which compare equal keep the order of
+// the input
+func (t TopKExec) Execute(ctx context.Context) iter.Seq2[datatypes.RecordBatch, error] {
+	return func(yield func(datatypes.RecordBatch, error) bool) {
+		if t.k == 0 {
+			if err := ctx.Err(); err != nil {
+				yield(datatypes.RecordBatch{}, err)
+			}
+			return
+		}
+
+		rows := &topKHeap{sortExprs: t.sortExprs}
+		// number of rows in the input batches the heap points at, the heap is compacted when
+		// that gets much larger than k
+		retainedRows := 0
+		seq := 0
+
+		for rb, err := range t.input.Execute(ctx) {
+			if err == nil {
+				err = ctx.Err()
+			}
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+
+			batch := &rb
+			keys, err := evaluateKeys(t.sortExprs, batch)
+			if err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+
+			retained := false
+			for row := range batch.RowCount() {
+				candidate := topKRow{sortRow{batch, keys, row}, seq}
+				seq++
+
+				switch {
+				case rows.Len() < t.k:
+					heap.Push(rows, candidate)
+					retained = true
+				case rows.less(candidate, rows.rows[0]):
+					// the candidate replaces the worst row kept so far
+					rows.rows[0] = candidate
+					heap.Fix(rows, 0)
+					retained = true
+				}
+				if rows.err != nil {
+					yield(datatypes.RecordBatch{}, rows.err)
+					return
+				}
+			}
+
+			if retained {
+				retainedRows += batch.RowCount()
+			}
+			if retainedRows > 2*t.k {
+				if err := t.compact(rows); err != nil {
+					yield(datatypes.RecordBatch{}, err)
+					return
+				}
+				retainedRows = rows.Len()
+			}
+		}
+
+		sorted := slices.Clone(rows.rows)
+		slices.SortFunc(sorted, func(a, b topKRow) int {
+			if rows.less(a, b) {
+				return -1
+			}
+			return 1
+		})
+		if rows.err != nil {
+			yield(datatypes.RecordBatch{}, rows.err)
+			return
+		}
+
+		out := make([]sortRow, len(sorted))
+		for i, r := range sorted {
+			out[i] = r.sortRow
+		}
+		for chunk := range slices.Chunk(out, t.batchSize) {
+			if err := ctx.Err(); err != nil {
+				yield(datatypes.RecordBatch{}, err)
+				return
+			}
+			if !yieldRows(t.input.Schema(), chunk, yield) {
+				return
+			}
+		}
+	}
+}
+
+// compact copies the rows of the heap into a single record batch so the input batches they
+// come from can be released
+func (t TopKExec) compact(rows *topKHeap) error {
+	kept := make([]sortRow, rows.Len())
+	for i, r := range rows.rows {
+		kept[i] = r.sortRow
+	}
+
+	batch, err := gatherRows(t.input.Schema(), kept)
+	if err != nil {
+		return err
+	}
+	keys, err := evaluateKeys(t.sortExprs, &batch)
+	if err != nil {
+		return err
+	}
+
+	// the position of the rows in the heap doesn't change
+	for i := range rows.rows {
+		rows.rows[i].sortRow = sortRow{&batch, keys, i}
+	}
+	return nil
+}
+
+// topKRow is a row kept by TopKExec, seq is its position in the input and breaks ties
+type topKRow struct {
+	sortRow
+	seq int
+}
+
+// topKHeap is a max heap, the root is the worst row kept. The first comparison error is kept in err
+type topKHeap struct {
+	sortExprs []SortExpr
+	rows      []topKRow
+	err       error
+}
+
+// less reports whether a comes before b in the output
+func (h *topKHeap) less(a, b topKRow) bool {
+	c, err := compareRows(h.sortExprs, a.sortRow, b.sortRow)
+	if err != nil && h.err == nil {
+		h.err = err
+	}
+	if c != 0 {
+		return c < 0
+	}
+	return a.seq < b.seq
+}
+
+func (h *topKHeap) Len() int {
+	return len(h.rows)
+}
+
+func (h *topKHeap) Less(i, j int) bool {
+	return h.less(h.rows[j], h.rows[i])
+}
+
+func (h *topKHeap) Swap(i, j int) {
+	h.rows[i], h.rows[j] = h.rows[j], h.rows[i]
+}
+
+func (h *topKHeap) Push(x any) {
+	h.rows = append(h.rows, x.(topKRow))
+}
+
+func (h *topKHeap) Pop() any {
+	last := h.rows[len(h.rows)-1]
+	h.rows = h.rows[:len(h.rows)-1]
+	return last
+}
+
+func (t TopKExec) Schema() datatypes.Schema {
+	return t.input.Schema()
+}
+
+func (t TopKExec) String() string {
+	return fmt.Sprintf("TopKExec: %v, k=%d", t.sortExprs, t.k)
+}
@@ -0,0 +1,47 @@
+package plans_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/physicalplan/exprs"
+	"github.com/fastbyt3/query-engine/physicalplan/plans"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopKMatchesSortAndLimit(t *testing.T) {
+	// salaries repeat so ties have to keep the input order
+	var batches []datatypes.RecordBatch
+	for b := range 5 {
+		var states, salaries []any
+		for i := range 7 {
+			n := b*7 + i
+			states = append(states, fmt.Sprintf("S%02d", n))
+			if n%6 == 0 {
+				salaries = append(salaries, nil)
+			} else {
+				salaries = append(salaries, int64(n*37%11))
+			}
+		}
+		batches = append(batches, newBatch(t, employeeSchema, states, salaries))
+	}
+	scan := newEmployeeScan(batches...)
+
+	sortExprs := []plans.SortExpr{{Expr: exprs.NewColumnIndexExpr(1), NullsFirst: true}}
+	for _, k := range []int{1, 3, 10, 35, 50} {
+		expected, _ := collectRows(t, plans.NewLimitExec(plans.NewSortExec(scan, sortExprs, 0, 0, ""), 0, k))
+		rows, batchSizes := collectRows(t, plans.NewTopKExec(scan, sortExprs, k, 4))
+		require.Equal(t, expected, rows, "k=%d", k)
+		for _, size := range batchSizes {
+			require.LessOrEqual(t, size, 4)
+		}
+	}
+}
+
+func TestTopKZero(t *testing.T) {
+	pulled := 0
+	rows, _ := collectRows(t, plans.NewTopKExec(limitInput(t, &pulled), []plans.SortExpr{{Expr: exprs.NewColumnIndexExpr(1)}}, 0, 0))
+	require.Empty(t, rows)
+	require.Zero(t, pulled)
+}
@@ -0,0 +1,426 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/logicalplans"
+	"github.com/fastbyt3/query-engine/optimizer"
+	"github.com/fastbyt3/query-engine/physicalplan"
+	"github.com/fastbyt3/query-engine/physicalplan/exprs"
+	"github.com/fastbyt3/query-engine/physicalplan/plans"
+)
+
+// ErrUnsupported is returned when a logical plan or expression has no physical counterpart
+var ErrUnsupported = errors.New("unsupported")
+
+// QueryPlanner translates a logical plan into a physical plan that can be executed.
+//
+// The logical plan is first rewritten by the optimizer, then column references are resolved by
+// name against the schema of the input logical plan and replaced with index based column expressions
+type QueryPlanner struct {
+	// max number of rows in record batches produced by operators which build their own output
+	batchSize int
+
+	// SortMemoryLimit is the number of bytes a sort buffers before spilling sorted runs to
+	// temporary files in SpillDir, zero means sorts stay in memory
+	SortMemoryLimit int64
+	SpillDir        string
+
+	optimizer *optimizer.Optimizer
+}
+
+func NewQueryPlanner(batchSize int) *QueryPlanner {
+	return &QueryPlanner{batchSize: batchSize, optimizer: optimizer.NewOptimizer(optimizer.DefaultRules()...)}
+}
+
+// RegisterRule adds an optimizer rule which runs after the default rules
+func (q *QueryPlanner) RegisterRule(rule optimizer.OptimizerRule) {
+	q.optimizer.AddRule(rule)
+}
+
+func (q *QueryPlanner) CreatePhysicalPlan(lp logicalplans.LogicalPlan) (physicalplan.PhysicalPlan, error) {
+	optimized, err := q.optimizer.Optimize(lp)
+	if err != nil {
+		return nil, err
+	}
+	return q.createPhysicalPlan(optimized)
+}
+
+func (q *QueryPlanner) createPhysicalPlan(lp logicalplans.LogicalPlan) (physicalplan.PhysicalPlan, error) {
+	switch p := lp.(type) {
+	case logicalplans.Scan:
+		return plans.NewScanExec(p.Datasource, p.Projections, p.Filters), nil
+
+	case logicalplans.EmptyRelation:
+		schema, err := p.Schema()
+		if err != nil {
+			return nil, err
+		}
+		return plans.NewEmptyExec(schema), nil
+
+	case *logicalplans.Projection:
+		input, err := q.createPhysicalPlan(p.Input)
+		if err != nil {
+			return nil, err
+		}
+
+		projExprs := make([]physicalplan.PhysicalExpression, len(p.Exprs))
+		for i, e := range p.Exprs {
+			projExprs[i], err = q.CreatePhysicalExpr(e, p.Input)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		schema, err := p.Schema()
+		if err != nil {
+			return nil, err
+		}
+
+		return plans.NewProjectionExec(input, schema, projExprs), nil
+
+	case *logicalplans.Selection:
+		input, err := q.createPhysicalPlan(p.Input)
+		if err != nil {
+			return nil, err
+		}
+
+		filterExpr, err := q.CreatePhysicalExpr(p.Expr, p.Input)
+		if err != nil {
+			return nil, err
+		}
+
+		return plans.NewSelectionExec(input, filterExpr), nil
+
+	case *logicalplans.Aggregate:
+		input, err := q.createPhysicalPlan(p.Input)
+		if err != nil {
+			return nil, err
+		}
+
+		groupExprs := make([]physicalplan.PhysicalExpression, len(p.GroupExprs))
+		for i, e := range p.GroupExprs {
+			groupExprs[i], err = q.CreatePhysicalExpr(e, p.Input)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		aggrExprs := make([]exprs.AggregateExpression, len(p.AggregateExprs))
+		for i, e := range p.AggregateExprs {
+			aggrExprs[i], err = q.createAggregateExpr(e, p.Input)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		schema, err := p.Schema()
+		if err != nil {
+			return nil, err
+		}
+
+		return plans.NewHashAggregateExec(input, groupExprs, aggrExprs, schema, q.batchSize), nil
+
+	case *logicalplans.Sort:
+		input, err := q.createPhysicalPlan(p.Input)
+		if err != nil {
+			return nil, err
+		}
+
+		sortExprs := make([]plans.SortExpr, len(p.Exprs))
+		for i, e := range p.Exprs {
+			expr, err := q.CreatePhysicalExpr(e.Expr, p.Input)
+			if err != nil {
+				return nil, err
+			}
+			sortExprs[i] = plans.SortExpr{Expr: expr, Asc: e.Asc, NullsFirst: e.NullsFirst}
+		}
+
+		if p.Fetch >= 0 {
+			return plans.NewTopKExec(input, sortExprs, p.Fetch, q.batchSize), nil
+		}
+		return plans.NewSortExec(input, sortExprs, q.batchSize, q.SortMemoryLimit, q.SpillDir), nil
+
+	case *logicalplans.Limit:
+		input, err := q.createPhysicalPlan(p.Input)
+		if err != nil {
+			return nil, err
+		}
+		return plans.NewLimitExec(input, p.Skip, p.Fetch), nil
+
+	case *logicalplans.Distinct:
+		input, err := q.createPhysicalPlan(p.Input)
+		if err != nil {
+			return nil, err
+		}
+
+		// grouping by every column without aggregates keeps one row per distinct row
+		schema := input.Schema()
+		groupExprs := make([]physicalplan.PhysicalExpression, schema.NumFields())
+		for i := range groupExprs {
+			groupExprs[i] = exprs.NewColumnIndexExpr(i)
+		}
+
+		return plans.NewHashAggregateExec(input, groupExprs, nil, schema, q.batchSize), nil
+
+	case *logicalplans.Join:
+		return q.createJoin(p)
+
+	default:
+		return nil, fmt.Errorf("%w: logical plan %T", ErrUnsupported, lp)
+	}
+}
+
+var joinTypes = map[logicalplans.JoinType]plans.JoinType{
+	logicalplans.InnerJoin: plans.InnerJoin,
+	logicalplans.LeftJoin:  plans.LeftJoin,
+	logicalplans.RightJoin: plans.RightJoin,
+	logicalplans.FullJoin:  plans.FullJoin,
+
+	logicalplans.LeftSemiJoin: plans.LeftSemiJoin,
+	logicalplans.LeftAntiJoin: plans.LeftAntiJoin,
+}
+
+func (q *QueryPlanner) createJoin(j *logicalplans.Join) (physicalplan.PhysicalPlan, error) {
+	joinType, ok := joinTypes[j.JoinType]
+	if !ok {
+		return nil, fmt.Errorf("%w: join type %s", ErrUnsupported, j.JoinType)
+	}
+
+	if j.NullAware {
+		// NOT IN compares a single value and its null semantics don't extend to extra conditions
+		if j.JoinType != logicalplans.LeftAntiJoin || len(j.Keys) != 1 || j.Filter != nil {
+			return nil, fmt.Errorf("%w: null aware %s join with %d keys and filter %v", ErrUnsupported, j.JoinType, len(j.Keys), j.Filter)
+		}
+		joinType = plans.NullAwareLeftAntiJoin
+	}
+
+	left, err := q.createPhysicalPlan(j.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := q.createPhysicalPlan(j.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	leftKeys := make([]physicalplan.PhysicalExpression, len(j.Keys))
+	rightKeys := make([]physicalplan.PhysicalExpression, len(j.Keys))
+	for i, key := range j.Keys {
+		// keys of different types would never be equal
+		leftField, err := key.Left.ToField(j.Left)
+		if err != nil {
+			return nil, err
+		}
+		rightField, err := key.Right.ToField(j.Right)
+		if err != nil {
+			return nil, err
+		}
+		if !arrow.TypeEqual(leftField.Type, rightField.Type) {
+			return nil, fmt.Errorf("%w: join key %s compares %s and %s", physicalplan.ErrTypeMismatch, key, leftField.Type, rightField.Type)
+		}
+
+		if leftKeys[i], err = q.CreatePhysicalExpr(key.Left, j.Left); err != nil {
+			return nil, err
+		}
+		if rightKeys[i], err = q.CreatePhysicalExpr(key.Right, j.Right); err != nil {
+			return nil, err
+		}
+	}
+
+	var filter physicalplan.PhysicalExpression
+	if j.Filter != nil {
+		if filter, err = q.CreatePhysicalExpr(j.Filter, j.JoinedRows()); err != nil {
+			return nil, err
+		}
+	}
+
+	schema, err := j.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	return plans.NewHashJoinExec(left, right, joinType, leftKeys, rightKeys, filter, schema, q.batchSize), nil
+}
+
+func (q *QueryPlanner) CreatePhysicalExpr(e logicalplans.LogicalExpr, input logicalplans.LogicalPlan) (physicalplan.PhysicalExpression, error) {
+	switch expr := e.(type) {
+	case logicalplans.Column:
+		schema, err := input.Schema()
+		if err != nil {
+			return nil, err
+		}
+
+		index, err := expr.Index(schema, input)
+		if err != nil {
+			return nil, err
+		}
+		return exprs.NewColumnIndexExpr(index), nil
+
+	case logicalplans.LiteralString:
+		return exprs.NewLiteralStringExpr(expr.Str), nil
+
+	case logicalplans.LiteralLong:
+		return exprs.NewLiteralLongExpr(expr.N), nil
+
+	case logicalplans.LiteralFloat:
+		return exprs.NewLiteralFloatExpr(expr.N), nil
+
+	case logicalplans.LiteralDouble:
+		return exprs.NewLiteralDoubleExpr(expr.N), nil
+
+	case logicalplans.LiteralBoolean:
+		return exprs.NewLiteralBooleanExpr(expr.B), nil
+
+	case logicalplans.Not:
+		inner, err := q.CreatePhysicalExpr(expr.Expr, input)
+		if err != nil {
+			return nil, err
+		}
+		return exprs.NewNotExpr(inner), nil
+
+	case logicalplans.Cast:
+		inner, err := q.CreatePhysicalExpr(expr.Expr, input)
+		if err != nil {
+			return nil, err
+		}
+		return exprs.NewCastExpr(inner, expr.Type, expr.Lenient), nil
+
+	case logicalplans.Alias:
+		// the alias only names the output field, which comes from the logical schema
+		return q.CreatePhysicalExpr(expr.Expr, input)
+
+	case logicalplans.BooleanBinaryExpr:
+		l, r, err := q.createBinaryOperands(expr.BinaryExpr, input)
+		if err != nil {
+			return nil, err
+		}
+
+		switch expr.Op {
+		case "=":
+			return exprs.NewEqExpr(l, r), nil
+		case "!=":
+			return exprs.NewNeqExpr(l, r), nil
+		case ">":
+			return exprs.NewGtExpr(l, r), nil
+		case ">=":
+			return exprs.NewGtEqExpr(l, r), nil
+		case "<":
+			return exprs.NewLtExpr(l, r), nil
+		case "<=":
+			return exprs.NewLtEqExpr(l, r), nil
+		case "&":
+			return exprs.NewAndExpr(l, r), nil
+		case "OR":
+			return exprs.NewOrExpr(l, r), nil
+		default:
+			return nil, fmt.Errorf("%w: boolean operator %q", ErrUnsupported, expr.Op)
+		}
+
+	case logicalplans.MathExpr:
+		l, r, err := q.createBinaryOperands(expr.BinaryExpr, input)
+		if err != nil {
+			return nil, err
+		}
+
+		switch expr.Op {
+		case "+":
+			return exprs.NewAddExpr(l, r), nil
+		case "-":
+			return exprs.NewSubtractExpr(l, r), nil
+		case "*":
+			return exprs.NewMultiplyExpr(l, r), nil
+		case "/":
+			return exprs.NewDivideExpr(l, r), nil
+		case "%":
+			return exprs.NewModuloExpr(l, r), nil
+		default:
+			return nil, fmt.Errorf("%w: math operator %q", ErrUnsupported, expr.Op)
+		}
+
+	case logicalplans.AggregateExpr:
+		return nil, fmt.Errorf("%w: aggregate expression %s outside of an aggregate", ErrUnsupported, expr)
+
+	case logicalplans.InSubquery, logicalplans.Exists:
+		// the optimizer only turns subqueries which are conjuncts of a filter into joins
+		return nil, fmt.Errorf("%w: subquery %s outside of a filter conjunct", ErrUnsupported, expr)
+
+	case logicalplans.OuterColumn:
+		// only the correlations of EXISTS subqueries which are filter conjuncts are rewritten
+		return nil, fmt.Errorf("%w: correlated reference %s", ErrUnsupported, expr)
+
+	default:
+		return nil, fmt.Errorf("%w: logical expression %T", ErrUnsupported, e)
+	}
+}
+
+// createBinaryOperands casts the narrower operand when both are numbers of different types, so
+// `salary > 11000.5` compares int64 salaries as float64 values
+func (q *QueryPlanner) createBinaryOperands(
+	e logicalplans.BinaryExpr,
+	input logicalplans.LogicalPlan,
+) (physicalplan.PhysicalExpression, physicalplan.PhysicalExpression, error) {
+	l, err := q.CreatePhysicalExpr(e.L, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := q.CreatePhysicalExpr(e.R, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lField, err := e.L.ToField(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rField, err := e.R.ToField(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if widened, ok := datatypes.WidenNumeric(lField.Type, rField.Type); ok {
+		if !arrow.TypeEqual(lField.Type, widened) {
+			l = exprs.NewCastExpr(l, widened, false)
+		}
+		if !arrow.TypeEqual(rField.Type, widened) {
+			r = exprs.NewCastExpr(r, widened, false)
+		}
+	}
+
+	return l, r, nil
+}
+
+func (q *QueryPlanner) createAggregateExpr(e logicalplans.AggregateExpr, input logicalplans.LogicalPlan) (exprs.AggregateExpression, error) {
+	inputExpr, err := q.CreatePhysicalExpr(e.Expr, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.Distinct {
+		if e.Name != "COUNT" {
+			return nil, fmt.Errorf("%w: aggregate function %s with DISTINCT", ErrUnsupported, e.Name)
+		}
+		return exprs.NewCountDistinctExpr(inputExpr), nil
+	}
+
+	switch e.Name {
+	case "SUM":
+		return exprs.NewSumExpr(inputExpr), nil
+	case "MIN":
+		return exprs.NewMinExpr(inputExpr), nil
+	case "MAX":
+		return exprs.NewMaxExpr(inputExpr), nil
+	case "AVG":
+		return exprs.NewAvgExpr(inputExpr), nil
+	case "COUNT":
+		return exprs.NewCountExpr(inputExpr), nil
+	default:
+		return nil, fmt.Errorf("%w: aggregate function %s", ErrUnsupported, e.Name)
+	}
+}
@@ -0,0 +1,288 @@
+package planner_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/fastbyt3/query-engine/datasources"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/logicalplans"
+	"github.com/fastbyt3/query-engine/optimizer"
+	"github.com/fastbyt3/query-engine/physicalplan"
+	"github.com/fastbyt3/query-engine/physicalplan/plans"
+	"github.com/fastbyt3/query-engine/planner"
+	"github.com/stretchr/testify/require"
+)
+
+const EMPLOYEE_CSV_FILE = "../test-data/employee.csv"
+
+func employeeScan(t *testing.T) logicalplans.Scan {
+	ds, err := datasources.NewCSVDatasource(EMPLOYEE_CSV_FILE, 10, datasources.DefaultCSVOptions())
+	require.NoError(t, err)
+	return logicalplans.NewScan(EMPLOYEE_CSV_FILE, ds, []string{})
+}
+
+func TestCreatePhysicalPlan(t *testing.T) {
+	df := logicalplans.NewDefaultDataframe(employeeScan(t)).
+		Filter(logicalplans.NewNegExpr(logicalplans.NewColumn("state"), logicalplans.NewColumn("first_name"))).
+		Project([]logicalplans.LogicalExpr{
+			logicalplans.NewColumn("id"),
+			logicalplans.NewColumn("last_name"),
+		})
+
+	plan, err := planner.NewQueryPlanner(1024).CreatePhysicalPlan(df.LogicalPlan())
+	require.NoError(t, err)
+
+	projection, ok := plan.(plans.ProjectionExec)
+	require.True(t, ok)
+	// projection pushdown only scans id, first_name, last_name and state
+	require.Equal(t, "ProjectionExec: [#0 #2]", fmt.Sprint(projection))
+	require.Equal(t, []string{"id", "last_name"}, fieldNames(projection))
+
+	selection, ok := projection.Children()[0].(*plans.SelectionExec)
+	require.True(t, ok)
+	require.Equal(t, "SelectionExec: #3 != #1", fmt.Sprint(selection))
+
+	scan, ok := selection.Children()[0].(plans.ScanExec)
+	require.True(t, ok)
+	require.Equal(t, []string{"id", "first_name", "last_name", "state"}, fieldNames(scan))
+}
+
+func TestCreatePhysicalPlanFilterPushedToScan(t *testing.T) {
+	df := logicalplans.NewDefaultDataframe(employeeScan(t)).
+		Filter(logicalplans.NewEqExpr(logicalplans.NewColumn("state"), logicalplans.NewLiteralString("CO"))).
+		Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("id")})
+
+	plan, err := planner.NewQueryPlanner(1024).CreatePhysicalPlan(df.LogicalPlan())
+	require.NoError(t, err)
+
+	scan, ok := plan.Children()[0].(plans.ScanExec)
+	require.True(t, ok)
+	require.Contains(t, fmt.Sprint(scan), "projection=[id], filters=[state = 'CO']")
+}
+
+func TestCreatePhysicalPlanAggregate(t *testing.T) {
+	df := logicalplans.NewDefaultDataframe(employeeScan(t)).
+		Aggregate(
+			[]logicalplans.LogicalExpr{logicalplans.NewColumn("state")},
+			[]logicalplans.AggregateExpr{
+				logicalplans.NewMaxExpr(logicalplans.NewColumn("salary")),
+				logicalplans.NewAggregateCountExpr(logicalplans.NewColumn("id")),
+			},
+		)
+
+	plan, err := planner.NewQueryPlanner(1024).CreatePhysicalPlan(df.LogicalPlan())
+	require.NoError(t, err)
+
+	aggregate, ok := plan.(plans.HashAggregateExec)
+	require.True(t, ok)
+	require.Equal(t, "HashAggregateExec: groupExpr=[#1], aggrExpr=[MAX(#2) COUNT(#0)]", fmt.Sprint(aggregate))
+	require.Equal(t, []string{"state", "MAX(salary)", "COUNT(id)"}, fieldNames(aggregate))
+}
+
+func TestCreatePhysicalPlanUnknownColumn(t *testing.T) {
+	df := logicalplans.NewDefaultDataframe(employeeScan(t)).
+		Filter(logicalplans.NewEqExpr(logicalplans.NewColumn("country"), logicalplans.NewLiteralString("US")))
+
+	_, err := planner.NewQueryPlanner(1024).CreatePhysicalPlan(df.LogicalPlan())
+	require.ErrorIs(t, err, logicalplans.ErrColumnNotFound)
+}
+
+func TestCreatePhysicalExprUnsupported(t *testing.T) {
+	scan := employeeScan(t)
+	_, err := planner.NewQueryPlanner(1024).CreatePhysicalExpr(logicalplans.NewSumExpr(logicalplans.NewColumn("salary")), scan)
+	require.ErrorIs(t, err, planner.ErrUnsupported)
+}
+
+func fieldNames(plan physicalplan.PhysicalPlan) []string {
+	schema := plan.Schema()
+	names := make([]string, schema.NumFields())
+	for i, f := range schema.Fields() {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// dropFilters removes every selection from the plan
+type dropFilters struct{}
+
+func (dropFilters) Name() string {
+	return "drop_filters"
+}
+
+func (dropFilters) Optimize(plan logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+	return optimizer.TransformUp(plan, func(p logicalplans.LogicalPlan) (logicalplans.LogicalPlan, bool, error) {
+		if selection, ok := p.(*logicalplans.Selection); ok {
+			return selection.Input, true, nil
+		}
+		return p, false, nil
+	})
+}
+
+func TestCreatePhysicalPlanRunsOptimizer(t *testing.T) {
+	df := logicalplans.NewDefaultDataframe(employeeScan(t)).
+		Filter(logicalplans.NewEqExpr(logicalplans.NewColumn("state"), logicalplans.NewLiteralString("CO")))
+
+	queryPlanner := planner.NewQueryPlanner(1024)
+	queryPlanner.RegisterRule(dropFilters{})
+	plan, err := queryPlanner.CreatePhysicalPlan(df.LogicalPlan())
+	require.NoError(t, err)
+	require.IsType(t, plans.ScanExec{}, plan)
+}
+
+func TestCreatePhysicalPlanAlwaysFalseFilter(t *testing.T) {
+	df := logicalplans.NewDefaultDataframe(employeeScan(t)).
+		Filter(logicalplans.NewNot(logicalplans.NewEqExpr(logicalplans.NewLiteralLong(1), logicalplans.NewLiteralLong(1))))
+
+	plan, err := planner.NewQueryPlanner(1024).CreatePhysicalPlan(df.LogicalPlan())
+	require.NoError(t, err)
+	require.IsType(t, plans.EmptyExec{}, plan)
+
+	for _, err := range plan.Execute(context.Background()) {
+		require.NoError(t, err)
+		t.Fatal("EmptyExec produced a record batch")
+	}
+}
+
+func stateScan() logicalplans.Scan {
+	schema := *datatypes.NewSchema([]arrow.Field{
+		{Name: "code", Type: datatypes.StringType},
+		{Name: "name", Type: datatypes.StringType},
+		{Name: "population", Type: datatypes.Int64Type},
+	})
+	return logicalplans.NewScan("states", datasources.NewMemoryDatasource(schema, nil), []string{})
+}
+
+func TestCreatePhysicalPlanJoin(t *testing.T) {
+	df := logicalplans.NewDefaultDataframe(employeeScan(t)).
+		Join(
+			logicalplans.NewDefaultDataframe(stateScan()),
+			logicalplans.LeftJoin,
+			[]logicalplans.JoinKey{{Left: logicalplans.NewColumn("state"), Right: logicalplans.NewColumn("code")}},
+			logicalplans.NewGtExpr(logicalplans.NewColumn("population"), logicalplans.NewLiteralLong(10)),
+		).
+		Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("last_name"), logicalplans.NewColumn("name")})
+
+	plan, err := planner.NewQueryPlanner(1024).CreatePhysicalPlan(df.LogicalPlan())
+	require.NoError(t, err)
+
+	join, ok := plan.Children()[0].(plans.HashJoinExec)
+	require.True(t, ok)
+	// projection pushdown only scans the projected, key and filter columns of both sides
+	require.Equal(t, []string{"last_name", "state", "code", "name", "population"}, fieldNames(join))
+	require.Equal(t, "HashJoinExec: type=LEFT, leftKeys=[#1], rightKeys=[#0], filter=#4 > 10", fmt.Sprint(join))
+	require.Equal(t, "ProjectionExec: [#0 #3]", fmt.Sprint(plan))
+}
+
+func TestCreatePhysicalPlanSelfJoin(t *testing.T) {
+	self := func(right logicalplans.Dataframe, filter logicalplans.LogicalExpr) logicalplans.Dataframe {
+		return logicalplans.NewDefaultDataframe(employeeScan(t)).
+			Join(right, logicalplans.InnerJoin, []logicalplans.JoinKey{{Left: logicalplans.NewColumn("id"), Right: logicalplans.NewColumn("id")}}, filter).
+			Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("first_name")})
+	}
+
+	// both sides have a first_name and a salary field
+	_, err := planner.NewQueryPlanner(1024).CreatePhysicalPlan(self(logicalplans.NewDefaultDataframe(employeeScan(t)), nil).LogicalPlan())
+	require.ErrorIs(t, err, logicalplans.ErrDuplicateColumn)
+	require.ErrorContains(t, err, `"first_name" is ambiguous`)
+
+	filter := logicalplans.NewGtExpr(logicalplans.NewColumn("salary"), logicalplans.NewLiteralLong(10))
+	_, err = planner.NewQueryPlanner(1024).CreatePhysicalPlan(self(logicalplans.NewDefaultDataframe(employeeScan(t)), filter).LogicalPlan())
+	require.ErrorIs(t, err, logicalplans.ErrDuplicateColumn)
+
+	// renaming the fields of one side makes them unambiguous
+	renamed := logicalplans.NewDefaultDataframe(employeeScan(t)).Project([]logicalplans.LogicalExpr{
+		logicalplans.NewAlias(logicalplans.NewColumn("id"), "manager_id"),
+		logicalplans.NewAlias(logicalplans.NewColumn("first_name"), "manager_name"),
+		logicalplans.NewAlias(logicalplans.NewColumn("salary"), "manager_salary"),
+	})
+	df := logicalplans.NewDefaultDataframe(employeeScan(t)).
+		Join(renamed, logicalplans.InnerJoin, []logicalplans.JoinKey{{Left: logicalplans.NewColumn("id"), Right: logicalplans.NewColumn("manager_id")}},
+			logicalplans.NewGtExpr(logicalplans.NewColumn("manager_salary"), logicalplans.NewColumn("salary"))).
+		Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("first_name"), logicalplans.NewColumn("manager_name")})
+	_, err = planner.NewQueryPlanner(1024).CreatePhysicalPlan(df.LogicalPlan())
+	require.NoError(t, err)
+}
+
+func TestCreatePhysicalPlanJoinKeyTypeMismatch(t *testing.T) {
+	df := logicalplans.NewDefaultDataframe(employeeScan(t)).
+		Join(
+			logicalplans.NewDefaultDataframe(stateScan()),
+			logicalplans.InnerJoin,
+			[]logicalplans.JoinKey{{Left: logicalplans.NewColumn("first_name"), Right: logicalplans.NewColumn("population")}},
+			nil,
+		)
+
+	_, err := planner.NewQueryPlanner(1024).CreatePhysicalPlan(df.LogicalPlan())
+	require.ErrorIs(t, err, physicalplan.ErrTypeMismatch)
+}
+
+func TestCreatePhysicalPlanSort(t *testing.T) {
+	df := logicalplans.NewDefaultDataframe(employeeScan(t)).
+		Sort(
+			logicalplans.NewSortExpr(logicalplans.NewColumn("state"), true),
+			logicalplans.SortExpr{Expr: logicalplans.NewColumn("salary"), Asc: false, NullsFirst: false},
+		).
+		Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("id")})
+
+	plan, err := planner.NewQueryPlanner(1024).CreatePhysicalPlan(df.LogicalPlan())
+	require.NoError(t, err)
+
+	sort, ok := plan.Children()[0].(plans.SortExec)
+	require.True(t, ok)
+	// the sort keys are scanned along with the projected columns
+	require.Equal(t, "SortExec: [#1 ASC NULLS LAST #2 DESC NULLS LAST]", fmt.Sprint(sort))
+	require.Equal(t, []string{"id", "state", "salary"}, fieldNames(sort))
+}
+
+func TestCreatePhysicalPlanLimit(t *testing.T) {
+	df := logicalplans.NewDefaultDataframe(employeeScan(t)).
+		Limit(1, 2).
+		Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("id")})
+
+	plan, err := planner.NewQueryPlanner(1024).CreatePhysicalPlan(df.LogicalPlan())
+	require.NoError(t, err)
+
+	limit, ok := plan.Children()[0].(plans.LimitExec)
+	require.True(t, ok)
+	require.Equal(t, "LimitExec: skip=1, fetch=2", fmt.Sprint(limit))
+	// the limit doesn't keep columns the projection doesn't read
+	require.Equal(t, []string{"id"}, fieldNames(limit.Children()[0]))
+
+	var ids []any
+	for rb, err := range plan.Execute(context.Background()) {
+		require.NoError(t, err)
+		for row := range rb.RowCount() {
+			ids = append(ids, rb.Field(0).GetValue(row))
+		}
+	}
+	require.Equal(t, []any{int64(2), int64(3)}, ids)
+}
+
+func TestCreatePhysicalPlanTopK(t *testing.T) {
+	df := logicalplans.NewDefaultDataframe(employeeScan(t)).
+		Sort(logicalplans.NewSortExpr(logicalplans.NewColumn("salary"), false)).
+		Project([]logicalplans.LogicalExpr{logicalplans.NewColumn("last_name")}).
+		Limit(1, 2)
+
+	plan, err := planner.NewQueryPlanner(1024).CreatePhysicalPlan(df.LogicalPlan())
+	require.NoError(t, err)
+
+	// the limit is moved below the projection, right above the sort it is fused with
+	limit := plan.Children()[0]
+	require.Equal(t, "LimitExec: skip=1, fetch=2", fmt.Sprint(limit))
+	topK, ok := limit.Children()[0].(plans.TopKExec)
+	require.True(t, ok)
+	require.Equal(t, "TopKExec: [#1 DESC NULLS FIRST], k=3", fmt.Sprint(topK))
+
+	var names []any
+	for rb, err := range plan.Execute(context.Background()) {
+		require.NoError(t, err)
+		for row := range rb.RowCount() {
+			names = append(names, rb.Field(0).GetValue(row))
+		}
+	}
+	require.Equal(t, []any{"Travis", "Mill"}, names)
+}
@@ -0,0 +1,236 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expr is a node of the syntax tree of a SQL expression
+type Expr interface {
+	String() string
+}
+
+// Identifier references a column by name, Table is set when the name is qualified: `table.name`
+type Identifier struct {
+	Table string
+	Name  string
+	Pos   Position
+}
+
+func (e Identifier) String() string {
+	if e.Table != "" {
+		return e.Table + "." + e.Name
+	}
+	return e.Name
+}
+
+type StringLit struct {
+	Value string
+}
+
+func (e StringLit) String() string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(e.Value, "'", "''"))
+}
+
+type LongLit struct {
+	Value int64
+}
+
+func (e LongLit) String() string {
+	return fmt.Sprintf("%d", e.Value)
+}
+
+type DoubleLit struct {
+	Value float64
+}
+
+func (e DoubleLit) String() string {
+	return fmt.Sprintf("%g", e.Value)
+}
+
+type BooleanLit struct {
+	Value bool
+}
+
+func (e BooleanLit) String() string {
+	return strings.ToUpper(fmt.Sprintf("%t", e.Value))
+}
+
+// Star is the `*` of `SELECT *` and `COUNT(*)`
+type Star struct{}
+
+func (e Star) String() string {
+	return "*"
+}
+
+// UnaryExpr is a prefix operator, NOT or -
+type UnaryExpr struct {
+	Op   string
+	Expr Expr
+}
+
+func (e UnaryExpr) String() string {
+	if e.Op == "NOT" {
+		return fmt.Sprintf("(NOT %s)", e.Expr)
+	}
+	return fmt.Sprintf("(%s%s)", e.Op, e.Expr)
+}
+
+// BinaryExpr is an infix operator, Op is one of =, !=, <, <=, >, >=, AND, OR, +, -, *, / and %
+type BinaryExpr struct {
+	Op   string
+	L, R Expr
+}
+
+func (e BinaryExpr) String() string {
+	return fmt.Sprintf("(%s %s %s)", e.L, e.Op, e.R)
+}
+
+// FunctionCall is a call of a scalar or aggregate function, Name is upper cased. Distinct is set
+// for aggregates of distinct values, `COUNT(DISTINCT x)`
+type FunctionCall struct {
+	Name     string
+	Args     []Expr
+	Distinct bool
+	Pos      Position
+}
+
+func (e FunctionCall) String() string {
+	args := make([]string, len(e.Args))
+	for i, a := range e.Args {
+		args[i] = a.String()
+	}
+	if e.Distinct {
+		return fmt.Sprintf("%s(DISTINCT %s)", e.Name, strings.Join(args, ", "))
+	}
+	return fmt.Sprintf("%s(%s)", e.Name, strings.Join(args, ", "))
+}
+
+// Cast converts an expression to the type named Type, `CAST(salary AS DOUBLE)`. Try is set for
+// `TRY_CAST`, which produces NULL for values that can't be converted instead of failing
+type Cast struct {
+	Expr Expr
+	Type string
+	Try  bool
+	Pos  Position
+}
+
+func (e Cast) String() string {
+	if e.Try {
+		return fmt.Sprintf("TRY_CAST(%s AS %s)", e.Expr, e.Type)
+	}
+	return fmt.Sprintf("CAST(%s AS %s)", e.Expr, e.Type)
+}
+
+// InSubquery tests whether Expr is one of the values produced by the subquery, `Expr [NOT] IN (SELECT ...)`
+type InSubquery struct {
+	Expr     Expr
+	Subquery *SelectStatement
+	Negated  bool
+}
+
+func (e InSubquery) String() string {
+	if e.Negated {
+		return fmt.Sprintf("(%s NOT IN (%s))", e.Expr, e.Subquery)
+	}
+	return fmt.Sprintf("(%s IN (%s))", e.Expr, e.Subquery)
+}
+
+// Exists tests whether the subquery produces any row, `EXISTS (SELECT ...)`
+type Exists struct {
+	Subquery *SelectStatement
+}
+
+func (e Exists) String() string {
+	return fmt.Sprintf("EXISTS (%s)", e.Subquery)
+}
+
+// Alias names the result of a projected expression, `expr AS alias`
+type Alias struct {
+	Expr  Expr
+	Alias string
+}
+
+func (e Alias) String() string {
+	return fmt.Sprintf("%s AS %s", e.Expr, e.Alias)
+}
+
+// OrderBy is a single sort key of the ORDER BY clause, when NULLS FIRST or NULLS LAST is omitted
+// nulls sort like the largest value: last in ascending order and first in descending order
+type OrderBy struct {
+	Expr       Expr
+	Asc        bool
+	NullsFirst bool
+}
+
+func (o OrderBy) String() string {
+	s := fmt.Sprintf("%s DESC", o.Expr)
+	if o.Asc {
+		s = fmt.Sprintf("%s ASC", o.Expr)
+	}
+
+	// only print the null ordering when it isn't the default one
+	switch {
+	case o.NullsFirst && o.Asc:
+		s += " NULLS FIRST"
+	case !o.NullsFirst && !o.Asc:
+		s += " NULLS LAST"
+	}
+	return s
+}
+
+// SelectStatement is a parsed `SELECT` query, optional clauses which are missing are nil
+type SelectStatement struct {
+	Distinct   bool
+	Projection []Expr
+	Table      string
+	Where      Expr
+	GroupBy    []Expr
+	Having     Expr
+	OrderBy    []OrderBy
+	Limit      *int64
+	Offset     *int64
+}
+
+func (s SelectStatement) String() string {
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	if s.Distinct {
+		sb.WriteString("DISTINCT ")
+	}
+	sb.WriteString(joinExprs(s.Projection))
+	sb.WriteString(" FROM ")
+	sb.WriteString(s.Table)
+
+	if s.Where != nil {
+		fmt.Fprintf(&sb, " WHERE %s", s.Where)
+	}
+	if len(s.GroupBy) > 0 {
+		fmt.Fprintf(&sb, " GROUP BY %s", joinExprs(s.GroupBy))
+	}
+	if s.Having != nil {
+		fmt.Fprintf(&sb, " HAVING %s", s.Having)
+	}
+	if len(s.OrderBy) > 0 {
+		keys := make([]string, len(s.OrderBy))
+		for i, o := range s.OrderBy {
+			keys[i] = o.String()
+		}
+		fmt.Fprintf(&sb, " ORDER BY %s", strings.Join(keys, ", "))
+	}
+	if s.Limit != nil {
+		fmt.Fprintf(&sb, " LIMIT %d", *s.Limit)
+	}
+	if s.Offset != nil {
+		fmt.Fprintf(&sb, " OFFSET %d", *s.Offset)
+	}
+	return sb.String()
+}
+
+func joinExprs(exprs []Expr) string {
+	s := make([]string, len(exprs))
+	for i, e := range exprs {
+		s[i] = e.String()
+	}
+	return strings.Join(s, ", ")
+}
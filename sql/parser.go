@@ -0,0 +1,524 @@
+package sql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// binding power of the infix operators, higher binds tighter
+const (
+	precedenceLowest     = 0
+	precedenceOr         = 10
+	precedenceAnd        = 20
+	precedenceNot        = 30
+	precedenceComparison = 40
+	precedenceAdditive   = 50
+	precedenceMultiply   = 60
+	precedenceUnary      = 70
+)
+
+var infixPrecedence = map[string]int{
+	"OR":  precedenceOr,
+	"AND": precedenceAnd,
+	"=":   precedenceComparison,
+	"!=":  precedenceComparison,
+	"<>":  precedenceComparison,
+	"<":   precedenceComparison,
+	"<=":  precedenceComparison,
+	">":   precedenceComparison,
+	">=":  precedenceComparison,
+	"+":   precedenceAdditive,
+	"-":   precedenceAdditive,
+	"*":   precedenceMultiply,
+	"/":   precedenceMultiply,
+	"%":   precedenceMultiply,
+}
+
+// Parse parses a single SELECT statement, an optional trailing `;` is allowed
+func Parse(query string) (*SelectStatement, error) {
+	tokens, err := Tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Parser{tokens: tokens}
+	stmt, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+
+	p.consumeSymbol(";")
+	if tok := p.peek(); tok.Type != TokenEOF {
+		return nil, p.errorf(tok, "unexpected %s after end of statement", tok)
+	}
+	return stmt, nil
+}
+
+// ParseExpr parses a standalone expression, like the condition of a WHERE clause
+func ParseExpr(expr string) (Expr, error) {
+	tokens, err := Tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Parser{tokens: tokens}
+	e, err := p.parseExpr(precedenceLowest)
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.Type != TokenEOF {
+		return nil, p.errorf(tok, "unexpected %s after end of expression", tok)
+	}
+	return e, nil
+}
+
+// Parser is a Pratt parser over the tokens of a query
+type Parser struct {
+	tokens []Token
+	offset int
+}
+
+func (p *Parser) peek() Token {
+	return p.tokens[p.offset]
+}
+
+// peekAt returns the token n positions after the next one, or the trailing TokenEOF
+func (p *Parser) peekAt(n int) Token {
+	return p.tokens[min(p.offset+n, len(p.tokens)-1)]
+}
+
+func (p *Parser) next() Token {
+	tok := p.tokens[p.offset]
+	// the trailing TokenEOF is never consumed
+	if tok.Type != TokenEOF {
+		p.offset++
+	}
+	return tok
+}
+
+func (p *Parser) errorf(tok Token, format string, args ...any) error {
+	return &SyntaxError{Pos: tok.Pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *Parser) isKeyword(keyword string) bool {
+	tok := p.peek()
+	return tok.Type == TokenKeyword && tok.Text == keyword
+}
+
+func (p *Parser) consumeKeyword(keyword string) bool {
+	if p.isKeyword(keyword) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *Parser) expectKeyword(keyword string) error {
+	if tok := p.peek(); !p.consumeKeyword(keyword) {
+		return p.errorf(tok, "expected %s, found %s", keyword, tok)
+	}
+	return nil
+}
+
+func (p *Parser) isSymbol(symbol string) bool {
+	tok := p.peek()
+	return tok.Type == TokenSymbol && tok.Text == symbol
+}
+
+func (p *Parser) consumeSymbol(symbol string) bool {
+	if p.isSymbol(symbol) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *Parser) expectSymbol(symbol string) error {
+	if tok := p.peek(); !p.consumeSymbol(symbol) {
+		return p.errorf(tok, "expected %q, found %s", symbol, tok)
+	}
+	return nil
+}
+
+func (p *Parser) expectIdentifier() (Token, error) {
+	tok := p.next()
+	if tok.Type != TokenIdentifier {
+		return Token{}, p.errorf(tok, "expected identifier, found %s", tok)
+	}
+	return tok, nil
+}
+
+func (p *Parser) parseSelect() (*SelectStatement, error) {
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+
+	stmt := &SelectStatement{Distinct: p.consumeKeyword("DISTINCT")}
+
+	var err error
+	if stmt.Projection, err = p.parseProjection(); err != nil {
+		return nil, err
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	table, err := p.expectIdentifier()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Table = table.Text
+
+	if p.consumeKeyword("WHERE") {
+		if stmt.Where, err = p.parseExpr(precedenceLowest); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.consumeKeyword("GROUP") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		if stmt.GroupBy, err = p.parseExprList(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.consumeKeyword("HAVING") {
+		if stmt.Having, err = p.parseExpr(precedenceLowest); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.consumeKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		if stmt.OrderBy, err = p.parseOrderBy(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.consumeKeyword("LIMIT") {
+		if stmt.Limit, err = p.parseRowCount("LIMIT"); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.consumeKeyword("OFFSET") {
+		if stmt.Offset, err = p.parseRowCount("OFFSET"); err != nil {
+			return nil, err
+		}
+	}
+
+	return stmt, nil
+}
+
+// parseRowCount parses the number of rows following LIMIT or OFFSET
+func (p *Parser) parseRowCount(clause string) (*int64, error) {
+	tok := p.next()
+	if tok.Type != TokenLong {
+		return nil, p.errorf(tok, "expected row count after %s, found %s", clause, tok)
+	}
+	n, err := strconv.ParseInt(tok.Text, 10, 64)
+	if err != nil {
+		return nil, p.errorf(tok, "invalid %s %s: %s", clause, tok.Text, err)
+	}
+	return &n, nil
+}
+
+// parseProjection parses the select list, every expression can be followed by an alias
+func (p *Parser) parseProjection() ([]Expr, error) {
+	var projection []Expr
+	for {
+		var e Expr
+		if p.consumeSymbol("*") {
+			e = Star{}
+		} else {
+			var err error
+			if e, err = p.parseExpr(precedenceLowest); err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case p.consumeKeyword("AS"):
+			alias, err := p.expectIdentifier()
+			if err != nil {
+				return nil, err
+			}
+			e = Alias{Expr: e, Alias: alias.Text}
+		case p.peek().Type == TokenIdentifier:
+			// implicit alias, `SELECT salary * 2 double_salary`
+			e = Alias{Expr: e, Alias: p.next().Text}
+		}
+
+		projection = append(projection, e)
+		if !p.consumeSymbol(",") {
+			return projection, nil
+		}
+	}
+}
+
+func (p *Parser) parseExprList() ([]Expr, error) {
+	var exprs []Expr
+	for {
+		e, err := p.parseExpr(precedenceLowest)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e)
+		if !p.consumeSymbol(",") {
+			return exprs, nil
+		}
+	}
+}
+
+func (p *Parser) parseOrderBy() ([]OrderBy, error) {
+	var keys []OrderBy
+	for {
+		e, err := p.parseExpr(precedenceLowest)
+		if err != nil {
+			return nil, err
+		}
+
+		asc := true
+		if p.consumeKeyword("DESC") {
+			asc = false
+		} else {
+			p.consumeKeyword("ASC")
+		}
+
+		nullsFirst := !asc
+		if p.consumeKeyword("NULLS") {
+			switch tok := p.next(); {
+			case tok.Type == TokenKeyword && tok.Text == "FIRST":
+				nullsFirst = true
+			case tok.Type == TokenKeyword && tok.Text == "LAST":
+				nullsFirst = false
+			default:
+				return nil, p.errorf(tok, "expected FIRST or LAST, found %s", tok)
+			}
+		}
+
+		keys = append(keys, OrderBy{Expr: e, Asc: asc, NullsFirst: nullsFirst})
+		if !p.consumeSymbol(",") {
+			return keys, nil
+		}
+	}
+}
+
+// parseExpr parses an expression whose infix operators all bind tighter than precedence
+func (p *Parser) parseExpr(precedence int) (Expr, error) {
+	left, err := p.parsePrefix()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if negated, ok := p.peekIn(); ok && precedenceComparison > precedence {
+			if negated {
+				p.next()
+			}
+			p.next()
+
+			subquery, err := p.parseSubquery()
+			if err != nil {
+				return nil, err
+			}
+			left = InSubquery{Expr: left, Subquery: subquery, Negated: negated}
+			continue
+		}
+
+		op, opPrecedence, ok := p.peekInfix()
+		if !ok || opPrecedence <= precedence {
+			return left, nil
+		}
+		p.next()
+
+		// operators are left associative, the right operand only takes tighter operators
+		right, err := p.parseExpr(opPrecedence)
+		if err != nil {
+			return nil, err
+		}
+		left = BinaryExpr{Op: op, L: left, R: right}
+	}
+}
+
+// peekIn reports whether the next tokens are IN or NOT IN, and which one
+func (p *Parser) peekIn() (negated bool, ok bool) {
+	if p.isKeyword("IN") {
+		return false, true
+	}
+
+	if next := p.peekAt(1); p.isKeyword("NOT") && next.Type == TokenKeyword && next.Text == "IN" {
+		return true, true
+	}
+	return false, false
+}
+
+// parseSubquery parses a parenthesized SELECT statement
+func (p *Parser) parseSubquery() (*SelectStatement, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+
+	stmt, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+func (p *Parser) peekInfix() (string, int, bool) {
+	tok := p.peek()
+	if tok.Type != TokenSymbol && tok.Type != TokenKeyword {
+		return "", 0, false
+	}
+
+	precedence, ok := infixPrecedence[tok.Text]
+	if !ok {
+		return "", 0, false
+	}
+
+	if tok.Text == "<>" {
+		return "!=", precedence, true
+	}
+	return tok.Text, precedence, true
+}
+
+func (p *Parser) parsePrefix() (Expr, error) {
+	tok := p.next()
+	switch tok.Type {
+	case TokenIdentifier:
+		if p.isSymbol("(") {
+			return p.parseFunctionCall(tok)
+		}
+		if p.consumeSymbol(".") {
+			column, err := p.expectIdentifier()
+			if err != nil {
+				return nil, err
+			}
+			return Identifier{Table: tok.Text, Name: column.Text, Pos: tok.Pos}, nil
+		}
+		return Identifier{Name: tok.Text, Pos: tok.Pos}, nil
+
+	case TokenString:
+		return StringLit{Value: tok.Text}, nil
+
+	case TokenLong:
+		n, err := strconv.ParseInt(tok.Text, 10, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid integer %s: %s", tok.Text, err)
+		}
+		return LongLit{Value: n}, nil
+
+	case TokenDouble:
+		f, err := strconv.ParseFloat(tok.Text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid number %s: %s", tok.Text, err)
+		}
+		return DoubleLit{Value: f}, nil
+
+	case TokenKeyword:
+		switch tok.Text {
+		case "TRUE", "FALSE":
+			return BooleanLit{Value: tok.Text == "TRUE"}, nil
+		case "NOT":
+			e, err := p.parseExpr(precedenceNot)
+			if err != nil {
+				return nil, err
+			}
+			return UnaryExpr{Op: "NOT", Expr: e}, nil
+		case "EXISTS":
+			subquery, err := p.parseSubquery()
+			if err != nil {
+				return nil, err
+			}
+			return Exists{Subquery: subquery}, nil
+		case "CAST", "TRY_CAST":
+			return p.parseCast(tok)
+		}
+
+	case TokenSymbol:
+		switch tok.Text {
+		case "(":
+			e, err := p.parseExpr(precedenceLowest)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectSymbol(")"); err != nil {
+				return nil, err
+			}
+			return e, nil
+		case "-":
+			e, err := p.parseExpr(precedenceUnary)
+			if err != nil {
+				return nil, err
+			}
+			return UnaryExpr{Op: "-", Expr: e}, nil
+		}
+	}
+
+	return nil, p.errorf(tok, "expected expression, found %s", tok)
+}
+
+// parseCast parses the parenthesized part of `CAST(expr AS type)`, the type name is upper cased
+func (p *Parser) parseCast(cast Token) (Expr, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+
+	e, err := p.parseExpr(precedenceLowest)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("AS"); err != nil {
+		return nil, err
+	}
+	typeName, err := p.expectIdentifier()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+	return Cast{Expr: e, Type: strings.ToUpper(typeName.Text), Try: cast.Text == "TRY_CAST", Pos: typeName.Pos}, nil
+}
+
+func (p *Parser) parseFunctionCall(name Token) (Expr, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+
+	call := FunctionCall{Name: strings.ToUpper(name.Text), Pos: name.Pos}
+	if p.consumeSymbol(")") {
+		return call, nil
+	}
+	call.Distinct = p.consumeKeyword("DISTINCT")
+
+	for {
+		if p.consumeSymbol("*") {
+			call.Args = append(call.Args, Star{})
+		} else {
+			arg, err := p.parseExpr(precedenceLowest)
+			if err != nil {
+				return nil, err
+			}
+			call.Args = append(call.Args, arg)
+		}
+
+		if p.consumeSymbol(")") {
+			return call, nil
+		}
+		if err := p.expectSymbol(","); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package sql_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fastbyt3/query-engine/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelect(t *testing.T) {
+	stmt, err := sql.Parse(`
+		SELECT state, MAX(salary) AS max_salary, COUNT(*) total
+		FROM employee
+		WHERE salary > 1000 AND NOT state = 'CA'
+		GROUP BY state
+		HAVING COUNT(*) > 1
+		ORDER BY max_salary DESC, state
+		LIMIT 10;`)
+	require.NoError(t, err)
+
+	require.Equal(t, "employee", stmt.Table)
+	require.Equal(t, []sql.Expr{
+		sql.Identifier{Name: "state", Pos: sql.Position{Line: 2, Column: 10}},
+		sql.Alias{
+			Expr:  sql.FunctionCall{Name: "MAX", Args: []sql.Expr{sql.Identifier{Name: "salary", Pos: sql.Position{Line: 2, Column: 21}}}, Pos: sql.Position{Line: 2, Column: 17}},
+			Alias: "max_salary",
+		},
+		sql.Alias{
+			Expr:  sql.FunctionCall{Name: "COUNT", Args: []sql.Expr{sql.Star{}}, Pos: sql.Position{Line: 2, Column: 44}},
+			Alias: "total",
+		},
+	}, stmt.Projection)
+	require.NotNil(t, stmt.Limit)
+	require.Equal(t, int64(10), *stmt.Limit)
+
+	require.Equal(t,
+		"SELECT state, MAX(salary) AS max_salary, COUNT(*) AS total FROM employee "+
+			"WHERE ((salary > 1000) AND (NOT (state = 'CA'))) GROUP BY state HAVING (COUNT(*) > 1) "+
+			"ORDER BY max_salary DESC, state ASC LIMIT 10",
+		stmt.String(),
+	)
+}
+
+func TestParseOrderBy(t *testing.T) {
+	stmt, err := sql.Parse("SELECT a FROM t ORDER BY a, b DESC, c NULLS FIRST, d DESC NULLS LAST")
+	require.NoError(t, err)
+
+	nullsFirst := make([]bool, len(stmt.OrderBy))
+	for i, o := range stmt.OrderBy {
+		nullsFirst[i] = o.NullsFirst
+	}
+	require.Equal(t, []bool{false, true, true, false}, nullsFirst)
+	require.Equal(t, "SELECT a FROM t ORDER BY a ASC, b DESC, c ASC NULLS FIRST, d DESC NULLS LAST", stmt.String())
+}
+
+func TestParseDistinct(t *testing.T) {
+	stmt, err := sql.Parse("SELECT DISTINCT state, COUNT(DISTINCT salary) FROM employee")
+	require.NoError(t, err)
+	require.True(t, stmt.Distinct)
+	require.True(t, stmt.Projection[1].(sql.FunctionCall).Distinct)
+	require.Equal(t, "SELECT DISTINCT state, COUNT(DISTINCT salary) FROM employee", stmt.String())
+}
+
+func TestParseCast(t *testing.T) {
+	e, err := sql.ParseExpr("CAST(salary AS double) * 2 + try_cast(id AS BigInt)")
+	require.NoError(t, err)
+	require.Equal(t, "((CAST(salary AS DOUBLE) * 2) + TRY_CAST(id AS BIGINT))", e.String())
+
+	_, err = sql.ParseExpr("CAST(salary double)")
+	require.ErrorContains(t, err, "expected AS")
+}
+
+func TestParseExprPrecedence(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"a + b * c", "(a + (b * c))"},
+		{"a - b - c", "((a - b) - c)"},
+		{"(a + b) % c", "((a + b) % c)"},
+		{"a * -b + 1", "((a * (-b)) + 1)"},
+		{"a + 1 >= b * 2", "((a + 1) >= (b * 2))"},
+		{"a = 1 OR b = 2 AND c <> 3", "((a = 1) OR ((b = 2) AND (c != 3)))"},
+		{"NOT a = 1 AND b", "((NOT (a = 1)) AND b)"},
+		{"NOT NOT TRUE", "(NOT (NOT TRUE))"},
+	}
+
+	for _, tt := range tests {
+		e, err := sql.ParseExpr(tt.expr)
+		require.NoError(t, err, "parsing %q", tt.expr)
+		require.Equal(t, tt.expected, e.String(), "parsing %q", tt.expr)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT FROM employee", "syntax error at line 1, column 8: expected expression, found FROM"},
+		{"SELECT a\nFROM employee\nWHERE (a = 1", "syntax error at line 3, column 13: expected \")\", found end of input"},
+		{"SELECT a FROM employee LIMIT ten", "syntax error at line 1, column 30: expected row count after LIMIT, found ten"},
+		{"SELECT a FROM employee LIMIT 1 OFFSET -1", "syntax error at line 1, column 39: expected row count after OFFSET, found -"},
+		{"SELECT a FROM employee GROUP a", "syntax error at line 1, column 30: expected BY, found a"},
+		{"SELECT a FROM employee WHERE a = 1 b", "syntax error at line 1, column 36: unexpected b after end of statement"},
+		{"SELECT a FROM employee ORDER BY a NULLS a", "syntax error at line 1, column 41: expected FIRST or LAST, found a"},
+	}
+
+	for _, tt := range tests {
+		_, err := sql.Parse(tt.query)
+		require.EqualError(t, err, tt.expected)
+
+		var syntaxErr *sql.SyntaxError
+		require.True(t, errors.As(err, &syntaxErr))
+	}
+}
+
+func TestParseSubqueries(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"a IN (SELECT b FROM t) AND c", "((a IN (SELECT b FROM t)) AND c)"},
+		{"a + 1 NOT IN (SELECT b FROM t WHERE b > 1)", "((a + 1) NOT IN (SELECT b FROM t WHERE (b > 1)))"},
+		{"NOT EXISTS (SELECT * FROM t WHERE t_id = id) OR a", "((NOT EXISTS (SELECT * FROM t WHERE (t_id = id))) OR a)"},
+		{"EXISTS (SELECT u.name FROM u WHERE u.a = t.a)", "EXISTS (SELECT u.name FROM u WHERE (u.a = t.a))"},
+	}
+
+	for _, tt := range tests {
+		e, err := sql.ParseExpr(tt.expr)
+		require.NoError(t, err, "parsing %q", tt.expr)
+		require.Equal(t, tt.expected, e.String(), "parsing %q", tt.expr)
+	}
+
+	_, err := sql.ParseExpr("a IN (1, 2)")
+	require.EqualError(t, err, "syntax error at line 1, column 7: expected SELECT, found 1")
+}
@@ -0,0 +1,508 @@
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/logicalplans"
+	"github.com/fastbyt3/query-engine/optimizer"
+)
+
+var (
+	// ErrUnsupported is returned for valid SQL the engine can't run yet
+	ErrUnsupported = errors.New("unsupported SQL")
+
+	// ErrInvalidQuery is returned for queries which parse but have no meaning, like selecting a
+	// column which is neither grouped nor aggregated
+	ErrInvalidQuery = errors.New("invalid query")
+)
+
+// Catalog resolves the table names used in the FROM clause
+type Catalog interface {
+	Table(name string) (logicalplans.Dataframe, error)
+}
+
+// sqlTypes are the type names accepted by CAST
+var sqlTypes = map[string]arrow.DataType{
+	"BOOLEAN":   datatypes.BooleanType,
+	"BOOL":      datatypes.BooleanType,
+	"TINYINT":   datatypes.Int8Type,
+	"SMALLINT":  datatypes.Int16Type,
+	"INT":       datatypes.Int32Type,
+	"INTEGER":   datatypes.Int32Type,
+	"BIGINT":    datatypes.Int64Type,
+	"UTINYINT":  datatypes.UInt8Type,
+	"USMALLINT": datatypes.UInt16Type,
+	"UINTEGER":  datatypes.UInt32Type,
+	"UBIGINT":   datatypes.UInt64Type,
+	"REAL":      datatypes.FloatType,
+	"FLOAT":     datatypes.FloatType,
+	"DOUBLE":    datatypes.DoubleType,
+	"VARCHAR":   datatypes.StringType,
+	"TEXT":      datatypes.StringType,
+	"STRING":    datatypes.StringType,
+	"DATE":      datatypes.Date32Type,
+	"TIMESTAMP": datatypes.TimestampType,
+}
+
+var aggregateFunctions = map[string]func(logicalplans.LogicalExpr) logicalplans.AggregateExpr{
+	"MIN":   logicalplans.NewMinExpr,
+	"MAX":   logicalplans.NewMaxExpr,
+	"SUM":   logicalplans.NewSumExpr,
+	"AVG":   logicalplans.NewAvgExpr,
+	"COUNT": logicalplans.NewAggregateCountExpr,
+}
+
+// CreateLogicalPlan translates a parsed SELECT statement into a dataframe over the tables of catalog.
+//
+// The WHERE clause filters the table, an aggregate is added when the query groups rows or calls an
+// aggregate function, HAVING filters the aggregated rows, ORDER BY sorts them, the select list is
+// projected, DISTINCT removes duplicate rows and LIMIT and OFFSET are applied last
+func CreateLogicalPlan(stmt *SelectStatement, catalog Catalog) (logicalplans.Dataframe, error) {
+	return sqlPlanner{catalog: catalog}.createLogicalPlan(stmt)
+}
+
+// sqlPlanner holds what the translation of a statement and its subqueries needs
+type sqlPlanner struct {
+	catalog Catalog
+
+	// table read by the statement and by the statement enclosing it, which qualified names refer to
+	table, outer string
+}
+
+func (q sqlPlanner) createLogicalPlan(stmt *SelectStatement) (logicalplans.Dataframe, error) {
+	q = sqlPlanner{catalog: q.catalog, table: stmt.Table, outer: q.table}
+
+	df, err := q.catalog.Table(stmt.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	projection, err := q.translateProjection(stmt.Projection, df)
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.Where != nil {
+		where, err := q.translateExpr(stmt.Where)
+		if err != nil {
+			return nil, err
+		}
+		if len(collectAggregates(where)) > 0 {
+			return nil, fmt.Errorf("%w: aggregate functions are not allowed in WHERE", ErrInvalidQuery)
+		}
+		df = df.Filter(where)
+	}
+
+	groupBy := make([]logicalplans.LogicalExpr, len(stmt.GroupBy))
+	for i, e := range stmt.GroupBy {
+		if groupBy[i], err = q.translateExpr(e); err != nil {
+			return nil, err
+		}
+		if len(collectAggregates(groupBy[i])) > 0 {
+			return nil, fmt.Errorf("%w: aggregate functions are not allowed in GROUP BY", ErrInvalidQuery)
+		}
+	}
+
+	var having logicalplans.LogicalExpr
+	if stmt.Having != nil {
+		if having, err = q.translateExpr(stmt.Having); err != nil {
+			return nil, err
+		}
+	}
+
+	// ORDER BY may refer to a select list item by its alias, the alias wins over a column of the
+	// table with the same name
+	aliases := make(map[string]logicalplans.LogicalExpr)
+	for _, e := range projection {
+		if alias, ok := e.(logicalplans.Alias); ok {
+			aliases[alias.Alias] = alias.Expr
+		}
+	}
+
+	orderBy := make([]logicalplans.SortExpr, len(stmt.OrderBy))
+	for i, o := range stmt.OrderBy {
+		e, err := q.translateExpr(o.Expr)
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := e.(logicalplans.Column); ok {
+			if aliased, ok := aliases[c.Name]; ok {
+				e = aliased
+			}
+		}
+		orderBy[i] = logicalplans.SortExpr{Expr: e, Asc: o.Asc, NullsFirst: o.NullsFirst}
+	}
+
+	var aggregates []logicalplans.AggregateExpr
+	for _, e := range projection {
+		aggregates = appendAggregates(aggregates, e)
+	}
+	if having != nil {
+		aggregates = appendAggregates(aggregates, having)
+	}
+	for _, o := range orderBy {
+		aggregates = appendAggregates(aggregates, o.Expr)
+	}
+
+	if len(groupBy) == 0 && len(aggregates) == 0 {
+		if having != nil {
+			return nil, fmt.Errorf("%w: HAVING without GROUP BY or aggregate functions", ErrInvalidQuery)
+		}
+		// rows are sorted before the projection so ORDER BY can use columns which aren't selected
+		if len(orderBy) > 0 {
+			df = df.Sort(orderBy...)
+		}
+		df = df.Project(projection)
+	} else if df, err = planAggregate(df, projection, groupBy, aggregates, having, orderBy); err != nil {
+		return nil, err
+	}
+
+	// duplicates are removed from the sorted rows, which keeps the first occurrence of each row
+	// and therefore the order
+	if stmt.Distinct {
+		df = df.Distinct()
+	}
+
+	if stmt.Limit != nil || stmt.Offset != nil {
+		skip, fetch := 0, -1
+		if stmt.Offset != nil {
+			skip = int(*stmt.Offset)
+		}
+		if stmt.Limit != nil {
+			fetch = int(*stmt.Limit)
+		}
+		df = df.Limit(skip, fetch)
+	}
+
+	// resolving the schema reports unknown columns and duplicate output names when planning
+	if _, err := df.Schema(); err != nil {
+		return nil, err
+	}
+	return df, nil
+}
+
+// planAggregate aggregates df and rewrites the select list, HAVING and ORDER BY to read the output
+// columns of the aggregate
+func planAggregate(
+	df logicalplans.Dataframe,
+	projection []logicalplans.LogicalExpr,
+	groupBy []logicalplans.LogicalExpr,
+	aggregates []logicalplans.AggregateExpr,
+	having logicalplans.LogicalExpr,
+	orderBy []logicalplans.SortExpr,
+) (logicalplans.Dataframe, error) {
+	// grouping expressions are matched by their text, `GROUP BY salary * 2` lets the select list
+	// use `salary * 2` but not `salary`
+	groupColumns := make(map[string]string, len(groupBy))
+	for _, e := range groupBy {
+		field, err := e.ToField(df.LogicalPlan())
+		if err != nil {
+			return nil, err
+		}
+		groupColumns[e.String()] = field.Name
+	}
+
+	df = df.Aggregate(groupBy, aggregates)
+
+	if having != nil {
+		predicate, err := rewriteOverAggregate(having, groupColumns)
+		if err != nil {
+			return nil, err
+		}
+		df = df.Filter(predicate)
+	}
+
+	if len(orderBy) > 0 {
+		keys := make([]logicalplans.SortExpr, len(orderBy))
+		for i, o := range orderBy {
+			e, err := rewriteOverAggregate(o.Expr, groupColumns)
+			if err != nil {
+				return nil, err
+			}
+			keys[i] = logicalplans.SortExpr{Expr: e, Asc: o.Asc, NullsFirst: o.NullsFirst}
+		}
+		df = df.Sort(keys...)
+	}
+
+	rewritten := make([]logicalplans.LogicalExpr, len(projection))
+	for i, e := range projection {
+		var err error
+		if rewritten[i], err = rewriteOverAggregate(e, groupColumns); err != nil {
+			return nil, err
+		}
+	}
+	return df.Project(rewritten), nil
+}
+
+// rewriteOverAggregate replaces the grouping expressions and the aggregates of expr by references to
+// the matching output column of the aggregate
+func rewriteOverAggregate(expr logicalplans.LogicalExpr, groupColumns map[string]string) (logicalplans.LogicalExpr, error) {
+	if name, ok := groupColumns[expr.String()]; ok {
+		return logicalplans.NewColumn(name), nil
+	}
+
+	switch e := expr.(type) {
+	case logicalplans.AggregateExpr:
+		// aggregate fields are named after the aggregate expression
+		return logicalplans.NewColumn(e.String()), nil
+
+	case logicalplans.Column:
+		return nil, fmt.Errorf("%w: column %q must appear in GROUP BY or be used in an aggregate function", ErrInvalidQuery, e.Name)
+	}
+
+	children := optimizer.ExprChildren(expr)
+	if len(children) == 0 {
+		return expr, nil
+	}
+
+	newChildren := make([]logicalplans.LogicalExpr, len(children))
+	for i, child := range children {
+		var err error
+		if newChildren[i], err = rewriteOverAggregate(child, groupColumns); err != nil {
+			return nil, err
+		}
+	}
+	return optimizer.ExprWithNewChildren(expr, newChildren)
+}
+
+func (q sqlPlanner) translateProjection(items []Expr, df logicalplans.Dataframe) ([]logicalplans.LogicalExpr, error) {
+	var projection []logicalplans.LogicalExpr
+	for _, item := range items {
+		if _, ok := item.(Star); ok {
+			schema, err := df.Schema()
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range schema.Fields() {
+				projection = append(projection, logicalplans.NewColumn(f.Name))
+			}
+			continue
+		}
+
+		if alias, ok := item.(Alias); ok {
+			e, err := q.translateExpr(alias.Expr)
+			if err != nil {
+				return nil, err
+			}
+			projection = append(projection, logicalplans.NewAlias(e, alias.Alias))
+			continue
+		}
+
+		e, err := q.translateExpr(item)
+		if err != nil {
+			return nil, err
+		}
+		projection = append(projection, e)
+	}
+	return projection, nil
+}
+
+// translateExpr converts a SQL expression into the equivalent logical expression
+func (q sqlPlanner) translateExpr(expr Expr) (logicalplans.LogicalExpr, error) {
+	switch e := expr.(type) {
+	case Identifier:
+		return q.translateIdentifier(e)
+
+	case StringLit:
+		return logicalplans.NewLiteralString(e.Value), nil
+
+	case LongLit:
+		return logicalplans.NewLiteralLong(e.Value), nil
+
+	case DoubleLit:
+		return logicalplans.NewLiteralDouble(e.Value), nil
+
+	case BooleanLit:
+		return logicalplans.NewLiteralBoolean(e.Value), nil
+
+	case UnaryExpr:
+		return q.translateUnary(e)
+
+	case BinaryExpr:
+		return q.translateBinary(e)
+
+	case FunctionCall:
+		return q.translateFunctionCall(e)
+
+	case Cast:
+		dataType, ok := sqlTypes[e.Type]
+		if !ok {
+			return nil, fmt.Errorf("%w: type %s at %s", ErrUnsupported, e.Type, e.Pos)
+		}
+		inner, err := q.translateExpr(e.Expr)
+		if err != nil {
+			return nil, err
+		}
+		if e.Try {
+			return logicalplans.NewTryCast(inner, dataType), nil
+		}
+		return logicalplans.NewCast(inner, dataType), nil
+
+	case InSubquery:
+		return q.translateInSubquery(e)
+
+	case Exists:
+		subquery, err := q.createLogicalPlan(e.Subquery)
+		if err != nil {
+			return nil, err
+		}
+		return logicalplans.NewExists(subquery.LogicalPlan(), false), nil
+
+	case Alias:
+		return nil, fmt.Errorf("%w: alias %s is only allowed in the select list", ErrInvalidQuery, e.Alias)
+
+	case Star:
+		return nil, fmt.Errorf("%w: * is only allowed in the select list and COUNT(*)", ErrInvalidQuery)
+
+	default:
+		return nil, fmt.Errorf("%w: expression %s", ErrUnsupported, expr)
+	}
+}
+
+// translateIdentifier resolves a qualified name against the table of the statement, then against
+// the table of the enclosing statement which makes it a reference to the outer query. The table
+// of the statement wins when both statements read the same table
+func (q sqlPlanner) translateIdentifier(e Identifier) (logicalplans.LogicalExpr, error) {
+	switch e.Table {
+	case "", q.table:
+		return logicalplans.NewColumn(e.Name), nil
+	case q.outer:
+		return logicalplans.NewOuterColumn(e.Name), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown table %s at %s", ErrInvalidQuery, e.Table, e.Pos)
+	}
+}
+
+func (q sqlPlanner) translateUnary(e UnaryExpr) (logicalplans.LogicalExpr, error) {
+	if e.Op == "NOT" {
+		inner, err := q.translateExpr(e.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return logicalplans.NewNot(inner), nil
+	}
+
+	// there is no negation expression, only numeric literals can be negated
+	switch lit := e.Expr.(type) {
+	case LongLit:
+		return logicalplans.NewLiteralLong(-lit.Value), nil
+	case DoubleLit:
+		return logicalplans.NewLiteralDouble(-lit.Value), nil
+	default:
+		return nil, fmt.Errorf("%w: negation of %s", ErrUnsupported, e.Expr)
+	}
+}
+
+var binaryOperators = map[string]func(l, r logicalplans.LogicalExpr) logicalplans.LogicalExpr{
+	"=":   func(l, r logicalplans.LogicalExpr) logicalplans.LogicalExpr { return logicalplans.NewEqExpr(l, r) },
+	"!=":  func(l, r logicalplans.LogicalExpr) logicalplans.LogicalExpr { return logicalplans.NewNegExpr(l, r) },
+	"<":   func(l, r logicalplans.LogicalExpr) logicalplans.LogicalExpr { return logicalplans.NewLtExpr(l, r) },
+	"<=":  func(l, r logicalplans.LogicalExpr) logicalplans.LogicalExpr { return logicalplans.NewLtEqExpr(l, r) },
+	">":   func(l, r logicalplans.LogicalExpr) logicalplans.LogicalExpr { return logicalplans.NewGtExpr(l, r) },
+	">=":  func(l, r logicalplans.LogicalExpr) logicalplans.LogicalExpr { return logicalplans.NewGtEqExpr(l, r) },
+	"AND": func(l, r logicalplans.LogicalExpr) logicalplans.LogicalExpr { return logicalplans.NewAndExpr(l, r) },
+	"OR":  func(l, r logicalplans.LogicalExpr) logicalplans.LogicalExpr { return logicalplans.NewOrExpr(l, r) },
+	"+":   func(l, r logicalplans.LogicalExpr) logicalplans.LogicalExpr { return logicalplans.NewAdd(l, r) },
+	"-":   func(l, r logicalplans.LogicalExpr) logicalplans.LogicalExpr { return logicalplans.NewSub(l, r) },
+	"*":   func(l, r logicalplans.LogicalExpr) logicalplans.LogicalExpr { return logicalplans.NewMult(l, r) },
+	"/":   func(l, r logicalplans.LogicalExpr) logicalplans.LogicalExpr { return logicalplans.NewDiv(l, r) },
+	"%":   func(l, r logicalplans.LogicalExpr) logicalplans.LogicalExpr { return logicalplans.NewMod(l, r) },
+}
+
+func (q sqlPlanner) translateBinary(e BinaryExpr) (logicalplans.LogicalExpr, error) {
+	newExpr, ok := binaryOperators[e.Op]
+	if !ok {
+		return nil, fmt.Errorf("%w: operator %s", ErrUnsupported, e.Op)
+	}
+
+	l, err := q.translateExpr(e.L)
+	if err != nil {
+		return nil, err
+	}
+	r, err := q.translateExpr(e.R)
+	if err != nil {
+		return nil, err
+	}
+	return newExpr(l, r), nil
+}
+
+func (q sqlPlanner) translateFunctionCall(call FunctionCall) (logicalplans.LogicalExpr, error) {
+	newAggregate, ok := aggregateFunctions[call.Name]
+	if !ok {
+		return nil, fmt.Errorf("%w: function %s at %s", ErrUnsupported, call.Name, call.Pos)
+	}
+
+	if len(call.Args) != 1 {
+		return nil, fmt.Errorf("%w: %s at %s expects 1 argument, got %d", ErrInvalidQuery, call.Name, call.Pos, len(call.Args))
+	}
+
+	if _, ok := call.Args[0].(Star); ok {
+		if call.Name != "COUNT" || call.Distinct {
+			return nil, fmt.Errorf("%w: %s(*) at %s", ErrInvalidQuery, call.Name, call.Pos)
+		}
+		// every row has a non null literal so COUNT(*) counts rows
+		return newAggregate(logicalplans.NewLiteralLong(1)), nil
+	}
+
+	arg, err := q.translateExpr(call.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(collectAggregates(arg)) > 0 {
+		return nil, fmt.Errorf("%w: nested aggregate functions in %s at %s", ErrInvalidQuery, call.Name, call.Pos)
+	}
+
+	aggregate := newAggregate(arg)
+	aggregate.Distinct = call.Distinct
+	return aggregate, nil
+}
+
+func (q sqlPlanner) translateInSubquery(in InSubquery) (logicalplans.LogicalExpr, error) {
+	e, err := q.translateExpr(in.Expr)
+	if err != nil {
+		return nil, err
+	}
+
+	subquery, err := q.createLogicalPlan(in.Subquery)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := subquery.Schema()
+	if err != nil {
+		return nil, err
+	}
+	if schema.NumFields() != 1 {
+		return nil, fmt.Errorf("%w: subquery of IN must select 1 column, got %d", ErrInvalidQuery, schema.NumFields())
+	}
+
+	return logicalplans.NewInSubquery(e, subquery.LogicalPlan(), in.Negated), nil
+}
+
+// collectAggregates returns the aggregate expressions of expr, outermost first
+func collectAggregates(expr logicalplans.LogicalExpr) []logicalplans.AggregateExpr {
+	if a, ok := expr.(logicalplans.AggregateExpr); ok {
+		return []logicalplans.AggregateExpr{a}
+	}
+
+	var aggregates []logicalplans.AggregateExpr
+	for _, child := range optimizer.ExprChildren(expr) {
+		aggregates = append(aggregates, collectAggregates(child)...)
+	}
+	return aggregates
+}
+
+// appendAggregates adds the aggregates of expr which aren't in aggregates yet
+func appendAggregates(aggregates []logicalplans.AggregateExpr, expr logicalplans.LogicalExpr) []logicalplans.AggregateExpr {
+	for _, a := range collectAggregates(expr) {
+		sameAggregate := func(existing logicalplans.AggregateExpr) bool { return existing.String() == a.String() }
+		if !slices.ContainsFunc(aggregates, sameAggregate) {
+			aggregates = append(aggregates, a)
+		}
+	}
+	return aggregates
+}
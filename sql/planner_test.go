@@ -0,0 +1,175 @@
+package sql_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/fastbyt3/query-engine/datasources"
+	"github.com/fastbyt3/query-engine/datatypes"
+	"github.com/fastbyt3/query-engine/logicalplans"
+	"github.com/fastbyt3/query-engine/sql"
+	"github.com/stretchr/testify/require"
+)
+
+type catalog map[string]logicalplans.Dataframe
+
+func (c catalog) Table(name string) (logicalplans.Dataframe, error) {
+	df, ok := c[name]
+	if !ok {
+		return nil, fmt.Errorf("no table %s", name)
+	}
+	return df, nil
+}
+
+func employeeCatalog() catalog {
+	schema := *datatypes.NewSchema([]arrow.Field{
+		{Name: "id", Type: datatypes.Int64Type},
+		{Name: "state", Type: datatypes.StringType},
+		{Name: "salary", Type: datatypes.Int64Type},
+	})
+	scan := logicalplans.NewScan("employee", datasources.NewMemoryDatasource(schema, nil), []string{})
+	return catalog{"employee": logicalplans.NewDefaultDataframe(scan)}
+}
+
+func planSQL(t *testing.T, query string) (logicalplans.Dataframe, error) {
+	stmt, err := sql.Parse(query)
+	require.NoError(t, err)
+	return sql.CreateLogicalPlan(stmt, employeeCatalog())
+}
+
+func TestCreateLogicalPlan(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{
+			"SELECT * FROM employee",
+			"Projection: id, state, salary\n" +
+				"  Scan: employee; path=None\n",
+		},
+		{
+			"SELECT id, salary * 2 FROM employee WHERE state = 'CO' AND NOT salary < 10",
+			"Projection: id, salary * 2\n" +
+				"  Filter: state = 'CO' & NOT salary < 10\n" +
+				"    Scan: employee; path=None\n",
+		},
+		{
+			"SELECT state, MAX(salary) - MIN(salary), COUNT(*) FROM employee GROUP BY state HAVING AVG(salary) > 10.5",
+			"Projection: state, MAX(salary) - MIN(salary), COUNT(1)\n" +
+				"  Filter: AVG(salary) > 10.5\n" +
+				"    Aggregate: groupExpr=[state], aggregateExprs=[MAX(salary) MIN(salary) COUNT(1) AVG(salary)]\n" +
+				"      Scan: employee; path=None\n",
+		},
+		{
+			"SELECT salary * 2, SUM(id) FROM employee GROUP BY salary * 2",
+			"Projection: salary * 2, SUM(id)\n" +
+				"  Aggregate: groupExpr=[salary * 2], aggregateExprs=[SUM(id)]\n" +
+				"    Scan: employee; path=None\n",
+		},
+		{
+			"SELECT id FROM employee ORDER BY state DESC, salary NULLS FIRST",
+			"Projection: id\n" +
+				"  Sort: [state DESC NULLS FIRST salary ASC NULLS FIRST]\n" +
+				"    Scan: employee; path=None\n",
+		},
+		{
+			"SELECT state FROM employee GROUP BY state ORDER BY COUNT(*) DESC, state",
+			"Projection: state\n" +
+				"  Sort: [COUNT(1) DESC NULLS FIRST state ASC NULLS LAST]\n" +
+				"    Aggregate: groupExpr=[state], aggregateExprs=[COUNT(1)]\n" +
+				"      Scan: employee; path=None\n",
+		},
+		{
+			"SELECT id FROM employee ORDER BY salary LIMIT 10 OFFSET 5",
+			"Limit: skip=5, fetch=10\n" +
+				"  Projection: id\n" +
+				"    Sort: [salary ASC NULLS LAST]\n" +
+				"      Scan: employee; path=None\n",
+		},
+		{
+			"SELECT DISTINCT state FROM employee ORDER BY state LIMIT 2",
+			"Limit: skip=0, fetch=2\n" +
+				"  Distinct\n" +
+				"    Projection: state\n" +
+				"      Sort: [state ASC NULLS LAST]\n" +
+				"        Scan: employee; path=None\n",
+		},
+		{
+			"SELECT state, COUNT(DISTINCT salary), COUNT(salary) FROM employee GROUP BY state",
+			"Projection: state, COUNT(DISTINCT salary), COUNT(salary)\n" +
+				"  Aggregate: groupExpr=[state], aggregateExprs=[COUNT(DISTINCT salary) COUNT(salary)]\n" +
+				"    Scan: employee; path=None\n",
+		},
+		{
+			"SELECT salary * 2 AS double_salary, salary * 3 FROM employee ORDER BY double_salary DESC",
+			"Projection: salary * 2 AS double_salary, salary * 3\n" +
+				"  Sort: [salary * 2 DESC NULLS FIRST]\n" +
+				"    Scan: employee; path=None\n",
+		},
+		{
+			"SELECT salary * 2 AS d, SUM(id) AS total FROM employee GROUP BY salary * 2 ORDER BY total",
+			"Projection: salary * 2 AS d, SUM(id) AS total\n" +
+				"  Sort: [SUM(id) ASC NULLS LAST]\n" +
+				"    Aggregate: groupExpr=[salary * 2], aggregateExprs=[SUM(id)]\n" +
+				"      Scan: employee; path=None\n",
+		},
+		{
+			"SELECT (salary + 1) * 2, salary - (id - 1), salary - id - 1 FROM employee",
+			"Projection: (salary + 1) * 2, salary - (id - 1), salary - id - 1\n" +
+				"  Scan: employee; path=None\n",
+		},
+		{
+			"SELECT CAST(salary AS DOUBLE) * 2.5 FROM employee WHERE TRY_CAST(id AS INT) > 1",
+			"Projection: CAST(salary AS float64) * 2.5\n" +
+				"  Filter: TRY_CAST(id AS int32) > 1\n" +
+				"    Scan: employee; path=None\n",
+		},
+		{
+			"SELECT state, 'state' FROM employee",
+			"Projection: state, 'state'\n" +
+				"  Scan: employee; path=None\n",
+		},
+		{
+			"SELECT id FROM employee OFFSET 5",
+			"Limit: skip=5, fetch=None\n" +
+				"  Projection: id\n" +
+				"    Scan: employee; path=None\n",
+		},
+	}
+
+	for _, tt := range tests {
+		df, err := planSQL(t, tt.query)
+		require.NoError(t, err, tt.query)
+		require.Equal(t, tt.expected, logicalplans.PprintPlan(df.LogicalPlan(), 2), tt.query)
+
+		_, err = df.Schema()
+		require.NoError(t, err, tt.query)
+	}
+}
+
+func TestCreateLogicalPlanErrors(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected error
+	}{
+		{"SELECT id, MAX(salary) FROM employee", sql.ErrInvalidQuery},
+		{"SELECT state, salary FROM employee GROUP BY state", sql.ErrInvalidQuery},
+		{"SELECT salary FROM employee GROUP BY salary * 2", sql.ErrInvalidQuery},
+		{"SELECT id FROM employee WHERE COUNT(id) > 1", sql.ErrInvalidQuery},
+		{"SELECT MAX(COUNT(id)) FROM employee", sql.ErrInvalidQuery},
+		{"SELECT UPPER(state) FROM employee", sql.ErrUnsupported},
+		{"SELECT COUNT(DISTINCT *) FROM employee", sql.ErrInvalidQuery},
+		{"SELECT CAST(salary AS MONEY) FROM employee", sql.ErrUnsupported},
+		{"SELECT id, salary AS id FROM employee", logicalplans.ErrDuplicateColumn},
+		{"SELECT unknown FROM employee", logicalplans.ErrColumnNotFound},
+		{"SELECT state FROM employee GROUP BY state ORDER BY salary", sql.ErrInvalidQuery},
+		{"SELECT states.code FROM employee", sql.ErrInvalidQuery},
+		{"SELECT NOT salary > 11000 AND id = 1 AS x, COUNT(*) FROM employee GROUP BY NOT (salary > 11000 AND id = 1)", sql.ErrInvalidQuery},
+	}
+
+	for _, tt := range tests {
+		_, err := planSQL(t, tt.query)
+		require.ErrorIs(t, err, tt.expected, tt.query)
+	}
+}
@@ -0,0 +1,261 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type TokenType int
+
+const (
+	TokenEOF TokenType = iota
+	TokenIdentifier
+	TokenKeyword
+	TokenString
+	TokenLong
+	TokenDouble
+	TokenSymbol
+)
+
+func (t TokenType) String() string {
+	switch t {
+	case TokenEOF:
+		return "end of input"
+	case TokenIdentifier:
+		return "identifier"
+	case TokenKeyword:
+		return "keyword"
+	case TokenString:
+		return "string"
+	case TokenLong, TokenDouble:
+		return "number"
+	case TokenSymbol:
+		return "symbol"
+	default:
+		return fmt.Sprintf("TokenType(%d)", int(t))
+	}
+}
+
+// Position of a token in the query text, lines and columns start at 1
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
+}
+
+// Token is a single lexical unit of a query, keywords are upper cased and string literals
+// are unquoted
+type Token struct {
+	Type TokenType
+	Text string
+	Pos  Position
+}
+
+func (t Token) String() string {
+	switch t.Type {
+	case TokenEOF:
+		return t.Type.String()
+	case TokenString:
+		return fmt.Sprintf("'%s'", t.Text)
+	default:
+		return t.Text
+	}
+}
+
+// SyntaxError reports where a query stops being valid SQL
+type SyntaxError struct {
+	Pos Position
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at %s: %s", e.Pos, e.Msg)
+}
+
+var keywords = map[string]bool{
+	"SELECT":   true,
+	"DISTINCT": true,
+	"FROM":     true,
+	"WHERE":    true,
+	"GROUP":    true,
+	"BY":       true,
+	"HAVING":   true,
+	"ORDER":    true,
+	"ASC":      true,
+	"DESC":     true,
+	"NULLS":    true,
+	"FIRST":    true,
+	"LAST":     true,
+	"LIMIT":    true,
+	"OFFSET":   true,
+	"AS":       true,
+	"AND":      true,
+	"OR":       true,
+	"NOT":      true,
+	"IN":       true,
+	"EXISTS":   true,
+	"CAST":     true,
+	"TRY_CAST": true,
+	"TRUE":     true,
+	"FALSE":    true,
+}
+
+// symbols made of two characters, checked before the single character ones
+var twoCharSymbols = []string{"!=", "<>", "<=", ">="}
+
+const singleCharSymbols = "=<>+-*/%(),.;"
+
+// Tokenize splits a query into tokens, the last token is always a TokenEOF
+func Tokenize(query string) ([]Token, error) {
+	t := tokenizer{input: []rune(query), pos: Position{Line: 1, Column: 1}}
+	var tokens []Token
+	for {
+		token, err := t.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+		if token.Type == TokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+type tokenizer struct {
+	input  []rune
+	offset int
+	pos    Position
+}
+
+func (t *tokenizer) peek(n int) rune {
+	if t.offset+n >= len(t.input) {
+		return 0
+	}
+	return t.input[t.offset+n]
+}
+
+func (t *tokenizer) advance() rune {
+	r := t.input[t.offset]
+	t.offset++
+	if r == '\n' {
+		t.pos.Line++
+		t.pos.Column = 1
+	} else {
+		t.pos.Column++
+	}
+	return r
+}
+
+func (t *tokenizer) skipWhitespaceAndComments() {
+	for t.offset < len(t.input) {
+		switch r := t.peek(0); {
+		case unicode.IsSpace(r):
+			t.advance()
+		case r == '-' && t.peek(1) == '-':
+			for t.offset < len(t.input) && t.peek(0) != '\n' {
+				t.advance()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (t *tokenizer) next() (Token, error) {
+	t.skipWhitespaceAndComments()
+
+	start := t.pos
+	if t.offset >= len(t.input) {
+		return Token{Type: TokenEOF, Pos: start}, nil
+	}
+
+	r := t.peek(0)
+	switch {
+	case isIdentifierStart(r):
+		var sb strings.Builder
+		for isIdentifierPart(t.peek(0)) {
+			sb.WriteRune(t.advance())
+		}
+		word := sb.String()
+		if upper := strings.ToUpper(word); keywords[upper] {
+			return Token{Type: TokenKeyword, Text: upper, Pos: start}, nil
+		}
+		return Token{Type: TokenIdentifier, Text: word, Pos: start}, nil
+
+	case unicode.IsDigit(r):
+		return t.number(start), nil
+
+	case r == '\'':
+		return t.quoted(start, '\'', TokenString)
+
+	case r == '"':
+		return t.quoted(start, '"', TokenIdentifier)
+	}
+
+	for _, symbol := range twoCharSymbols {
+		if string([]rune{r, t.peek(1)}) == symbol {
+			t.advance()
+			t.advance()
+			return Token{Type: TokenSymbol, Text: symbol, Pos: start}, nil
+		}
+	}
+
+	if strings.ContainsRune(singleCharSymbols, r) {
+		t.advance()
+		return Token{Type: TokenSymbol, Text: string(r), Pos: start}, nil
+	}
+
+	return Token{}, &SyntaxError{Pos: start, Msg: fmt.Sprintf("unexpected character %q", r)}
+}
+
+func (t *tokenizer) number(start Position) Token {
+	var sb strings.Builder
+	for unicode.IsDigit(t.peek(0)) {
+		sb.WriteRune(t.advance())
+	}
+
+	if t.peek(0) != '.' || !unicode.IsDigit(t.peek(1)) {
+		return Token{Type: TokenLong, Text: sb.String(), Pos: start}
+	}
+
+	sb.WriteRune(t.advance())
+	for unicode.IsDigit(t.peek(0)) {
+		sb.WriteRune(t.advance())
+	}
+	return Token{Type: TokenDouble, Text: sb.String(), Pos: start}
+}
+
+// quoted reads a string literal or a quoted identifier, a doubled quote stands for the quote itself
+func (t *tokenizer) quoted(start Position, quote rune, tokenType TokenType) (Token, error) {
+	t.advance()
+
+	var sb strings.Builder
+	for {
+		if t.offset >= len(t.input) {
+			return Token{}, &SyntaxError{Pos: start, Msg: fmt.Sprintf("unterminated %s", tokenType)}
+		}
+
+		r := t.advance()
+		if r != quote {
+			sb.WriteRune(r)
+			continue
+		}
+
+		if t.peek(0) != quote {
+			return Token{Type: tokenType, Text: sb.String(), Pos: start}, nil
+		}
+		sb.WriteRune(t.advance())
+	}
+}
+
+func isIdentifierStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentifierPart(r rune) bool {
+	return isIdentifierStart(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,52 @@
+package sql_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fastbyt3/query-engine/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens, err := sql.Tokenize("select \"first name\", salary*2.5 FROM employee\n-- comment\nWHERE state <> 'it''s' AND id>=10")
+	require.NoError(t, err)
+
+	var texts []string
+	var types []sql.TokenType
+	for _, tok := range tokens {
+		texts = append(texts, tok.Text)
+		types = append(types, tok.Type)
+	}
+
+	require.Equal(t, []string{
+		"SELECT", "first name", ",", "salary", "*", "2.5", "FROM", "employee",
+		"WHERE", "state", "<>", "it's", "AND", "id", ">=", "10", "",
+	}, texts)
+	require.Equal(t, []sql.TokenType{
+		sql.TokenKeyword, sql.TokenIdentifier, sql.TokenSymbol, sql.TokenIdentifier, sql.TokenSymbol, sql.TokenDouble, sql.TokenKeyword, sql.TokenIdentifier,
+		sql.TokenKeyword, sql.TokenIdentifier, sql.TokenSymbol, sql.TokenString, sql.TokenKeyword, sql.TokenIdentifier, sql.TokenSymbol, sql.TokenLong, sql.TokenEOF,
+	}, types)
+
+	// WHERE starts the third line
+	require.Equal(t, sql.Position{Line: 3, Column: 1}, tokens[8].Pos)
+	require.Equal(t, sql.Position{Line: 3, Column: 16}, tokens[11].Pos)
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   sql.Position
+	}{
+		{"SELECT a FROM t WHERE a = 'open", sql.Position{Line: 1, Column: 27}},
+		{"SELECT a\nFROM t WHERE a # 1", sql.Position{Line: 2, Column: 16}},
+	}
+
+	for _, tt := range tests {
+		_, err := sql.Tokenize(tt.query)
+
+		var syntaxErr *sql.SyntaxError
+		require.True(t, errors.As(err, &syntaxErr), "tokenizing %q", tt.query)
+		require.Equal(t, tt.pos, syntaxErr.Pos)
+	}
+}